@@ -1,44 +1,58 @@
 package database_actions
 
 import (
-	"database/sql"
+	"context"
+	"errors"
 	"fmt"
+	"os"
+	"sort"
 
-	"github.com/go-sql-driver/mysql"
+	"github.com/japhy-tech/backend-test/internal/petfile"
+	"github.com/japhy-tech/backend-test/internal/repository"
+	"github.com/japhy-tech/backend-test/internal/usecase"
 )
 
-// FillPetsTable fill the "pets" table with the contents of the CSV file.
-//
-// The table will only be filled if it is empty.
-//
-// Returns the number of rows affected or an error.
-func LoadPetsTable(db *sql.DB, filePath string) (int64, error) {
+const (
+	// SeedActor is the actor of the changes made by the seed in the audit log.
+	SeedActor = "seed"
+	// SeedBatchSize is the number of lines of the seed written per transaction.
+	SeedBatchSize = 100
+)
 
-	//Check if the table is empty
-	var count int
-	err := db.QueryRow("SELECT COUNT(*) FROM pets").Scan(&count)
+// LoadPets upserts the pets of the CSV file, see usecase.PetSeeder.
+//
+// The columns are mapped through the header. The lines that can't be read or are
+// invalid are reported, the other lines are still loaded.
+func LoadPets(ctx context.Context, petRepo repository.PetRepository, filePath string) (*usecase.SeedReport, error) {
+	file, err := os.Open(filePath)
 	if err != nil {
-		return 0, err
+		return nil, fmt.Errorf("unable to open the seed file: %w", err)
 	}
+	defer file.Close()
 
-	if count == 0 {
-		mysql.RegisterLocalFile(filePath)
+	records, err := petfile.Read(file, petfile.FormatCSV)
+	var parseErrs *petfile.ParseErrors
+	if err != nil && !errors.As(err, &parseErrs) {
+		return nil, fmt.Errorf("unable to read the seed file %s: %w", filePath, err)
+	}
 
-		query := fmt.Sprintf(
-			`LOAD DATA LOCAL INFILE '%s' 
-			INTO TABLE pets 
-			FIELDS TERMINATED BY ","
-			LINES TERMINATED BY "\n"
-			IGNORE 1 LINES
-			`, filePath)
+	report, err := usecase.NewPetSeeder(petRepo, SeedBatchSize).Seed(usecase.WithActor(ctx, SeedActor), records)
+	if err != nil {
+		return nil, err
+	}
 
-		result, err := db.Exec(query)
-		if err != nil {
-			return 0, err
+	if parseErrs != nil {
+		for _, lineErr := range parseErrs.Errors {
+			report.Invalid = append(report.Invalid, usecase.SeedLine{
+				Line:   lineErr.Line,
+				Reason: "unreadable line",
+				Errors: []usecase.FieldError{{Field: lineErr.Field, Message: lineErr.Message}},
+			})
 		}
-
-		return result.RowsAffected()
+		sort.SliceStable(report.Invalid, func(i, j int) bool {
+			return report.Invalid[i].Line < report.Invalid[j].Line
+		})
 	}
 
-	return 0, nil
+	return report, nil
 }
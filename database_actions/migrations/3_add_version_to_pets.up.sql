@@ -0,0 +1 @@
+ALTER TABLE pets ADD COLUMN version INT UNSIGNED NOT NULL DEFAULT 1;
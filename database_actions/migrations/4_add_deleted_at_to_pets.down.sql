@@ -0,0 +1 @@
+ALTER TABLE pets DROP INDEX idx_pets_deleted_at, DROP COLUMN deleted_at;
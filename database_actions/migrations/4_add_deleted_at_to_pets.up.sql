@@ -0,0 +1 @@
+ALTER TABLE pets ADD COLUMN deleted_at DATETIME NULL DEFAULT NULL, ADD INDEX idx_pets_deleted_at (deleted_at);
@@ -0,0 +1,12 @@
+CREATE TABLE audit_log (
+    id INT NOT NULL AUTO_INCREMENT PRIMARY KEY,
+    entity_type VARCHAR(64) NOT NULL,
+    entity_id INT NOT NULL,
+    action VARCHAR(32) NOT NULL,
+    actor VARCHAR(255) NOT NULL,
+    created_at DATETIME(6) NOT NULL,
+    before_state JSON NULL,
+    after_state JSON NULL,
+    INDEX idx_audit_log_entity (entity_type, entity_id),
+    INDEX idx_audit_log_created_at (created_at)
+);
@@ -0,0 +1,20 @@
+ALTER TABLE pets
+    DROP INDEX idx_pets_weights,
+    DROP INDEX idx_pets_species_weights,
+    DROP INDEX uq_pets_species_name_live,
+    DROP CHECK chk_pets_name,
+    DROP CHECK chk_pets_pet_size,
+    DROP CHECK chk_pets_species,
+    DROP COLUMN live,
+    DROP COLUMN updated_at,
+    DROP COLUMN created_at,
+    MODIFY species VARCHAR(255),
+    MODIFY pet_size VARCHAR(255),
+    MODIFY name VARCHAR(255),
+    MODIFY average_male_adult_weight INT UNSIGNED,
+    MODIFY average_female_adult_weight INT UNSIGNED;
+
+-- The cleaned values and the trashed duplicates are kept, the set aside rows are put back
+INSERT INTO pets SELECT * FROM pets_rejected;
+
+DROP TABLE pets_rejected;
@@ -0,0 +1,44 @@
+-- The rows that can't be repaired are set aside for review rather than deleted
+CREATE TABLE pets_rejected AS
+SELECT * FROM pets
+WHERE name IS NULL OR TRIM(name) = ''
+    OR LOWER(TRIM(species)) NOT IN ('dog', 'cat') OR species IS NULL
+    OR LOWER(TRIM(pet_size)) NOT IN ('small', 'medium', 'tall') OR pet_size IS NULL;
+
+DELETE FROM pets WHERE id IN (SELECT id FROM pets_rejected);
+
+UPDATE pets SET
+    species = LOWER(TRIM(species)),
+    pet_size = LOWER(TRIM(pet_size)),
+    name = LOWER(TRIM(name)),
+    average_male_adult_weight = COALESCE(average_male_adult_weight, 0),
+    average_female_adult_weight = COALESCE(average_female_adult_weight, 0);
+
+-- Only the first of the duplicates stays out of the trash
+UPDATE pets
+JOIN (
+    SELECT species, name, MIN(id) AS kept_id
+    FROM pets
+    WHERE deleted_at IS NULL
+    GROUP BY species, name
+    HAVING COUNT(*) > 1
+) duplicates ON pets.species = duplicates.species AND pets.name = duplicates.name
+SET pets.deleted_at = UTC_TIMESTAMP(), pets.version = pets.version + 1
+WHERE pets.deleted_at IS NULL AND pets.id <> duplicates.kept_id;
+
+-- live is 1 out of the trash and NULL in the trash, so that the unique key ignores the trash
+ALTER TABLE pets
+    MODIFY species VARCHAR(255) NOT NULL,
+    MODIFY pet_size VARCHAR(255) NOT NULL,
+    MODIFY name VARCHAR(255) NOT NULL,
+    MODIFY average_male_adult_weight INT UNSIGNED NOT NULL DEFAULT 0,
+    MODIFY average_female_adult_weight INT UNSIGNED NOT NULL DEFAULT 0,
+    ADD COLUMN created_at DATETIME(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6),
+    ADD COLUMN updated_at DATETIME(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6),
+    ADD COLUMN live TINYINT AS (IF(deleted_at IS NULL, 1, NULL)) VIRTUAL,
+    ADD CONSTRAINT chk_pets_species CHECK (species IN ('dog', 'cat')),
+    ADD CONSTRAINT chk_pets_pet_size CHECK (pet_size IN ('small', 'medium', 'tall')),
+    ADD CONSTRAINT chk_pets_name CHECK (name <> ''),
+    ADD UNIQUE INDEX uq_pets_species_name_live (species, name, live),
+    ADD INDEX idx_pets_species_weights (species, average_male_adult_weight, average_female_adult_weight),
+    ADD INDEX idx_pets_weights (average_male_adult_weight, average_female_adult_weight);
@@ -0,0 +1,25 @@
+ALTER TABLE pets
+    DROP FOREIGN KEY fk_pets_pet_size,
+    DROP FOREIGN KEY fk_pets_species,
+    DROP INDEX idx_pets_pet_size;
+
+-- The pets of the species and sizes added since don't pass the checks, they are set aside for review.
+-- Their species or size is gone once migrated down, so the table is kept across the migrations:
+-- the next downs add their rows to it
+CREATE TABLE IF NOT EXISTS pets_unknown_references AS
+SELECT * FROM pets
+WHERE FALSE;
+
+INSERT INTO pets_unknown_references
+SELECT * FROM pets
+WHERE species NOT IN ('dog', 'cat') OR pet_size NOT IN ('small', 'medium', 'tall');
+
+DELETE FROM pets WHERE species NOT IN ('dog', 'cat') OR pet_size NOT IN ('small', 'medium', 'tall');
+
+ALTER TABLE pets
+    ADD CONSTRAINT chk_pets_species CHECK (species IN ('dog', 'cat')),
+    ADD CONSTRAINT chk_pets_pet_size CHECK (pet_size IN ('small', 'medium', 'tall'));
+
+DROP TABLE pet_sizes;
+
+DROP TABLE species;
@@ -0,0 +1,28 @@
+-- The species and the sizes of the pets become reference data, renamed along with the pets
+CREATE TABLE species (
+    name VARCHAR(255) NOT NULL PRIMARY KEY,
+    min_weight INT UNSIGNED NOT NULL,
+    max_weight INT UNSIGNED NOT NULL,
+    CONSTRAINT chk_species_name CHECK (name <> ''),
+    CONSTRAINT chk_species_weights CHECK (min_weight <= max_weight)
+);
+
+CREATE TABLE pet_sizes (
+    name VARCHAR(255) NOT NULL PRIMARY KEY,
+    position INT NOT NULL,
+    CONSTRAINT chk_pet_sizes_name CHECK (name <> '')
+);
+
+INSERT INTO species (name, min_weight, max_weight) VALUES ('cat', 1000, 15000), ('dog', 1000, 100000);
+
+INSERT INTO pet_sizes (name, position) VALUES ('small', 1), ('medium', 2), ('tall', 3);
+
+-- The checks are dropped first, MySQL refuses a cascading foreign key on a checked column
+ALTER TABLE pets
+    DROP CHECK chk_pets_pet_size,
+    DROP CHECK chk_pets_species;
+
+ALTER TABLE pets
+    ADD INDEX idx_pets_pet_size (pet_size),
+    ADD CONSTRAINT fk_pets_species FOREIGN KEY (species) REFERENCES species (name) ON UPDATE CASCADE ON DELETE RESTRICT,
+    ADD CONSTRAINT fk_pets_pet_size FOREIGN KEY (pet_size) REFERENCES pet_sizes (name) ON UPDATE CASCADE ON DELETE RESTRICT;
@@ -2,22 +2,47 @@ package database_actions
 
 import (
 	"database/sql"
+	"embed"
 	"errors"
 	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
 	"strconv"
 	"strings"
 
 	"github.com/golang-migrate/migrate/v4"
 	"github.com/golang-migrate/migrate/v4/database"
 	"github.com/golang-migrate/migrate/v4/database/mysql"
+	"github.com/golang-migrate/migrate/v4/source"
 	_ "github.com/golang-migrate/migrate/v4/source/file"
+	"github.com/golang-migrate/migrate/v4/source/iofs"
 )
 
-var driver database.Driver
+// MigrationsDir is the directory of the migrations in the repository, where the new ones are created
+const MigrationsDir = "database_actions/migrations"
+
+// migrationsFS holds the migrations built into the binary
+//
+//go:embed migrations/*.sql
+var migrationsFS embed.FS
+
+var (
+	driver database.Driver
+	// migrationsPath is the directory the migrations are read from, the embedded ones when empty
+	migrationsPath string
+)
+
+// migrationNamePattern matches the names given to the new migrations
+var migrationNamePattern = regexp.MustCompile(`^[a-z0-9]+(_[a-z0-9]+)*$`)
 
 // InitMigrator initiates values essential for migrations
-func InitMigrator(db *sql.DB) error {
+//
+// The migrations are read from migrationsPath, or from the binary when empty
+func InitMigrator(db *sql.DB, path string) error {
 	var err error
+	migrationsPath = path
 	driver, err = mysql.WithInstance(db, &mysql.Config{})
 	if err != nil {
 		return fmt.Errorf("error while instanciating migration driver: %w", err)
@@ -26,52 +51,186 @@ func InitMigrator(db *sql.DB) error {
 	return nil
 }
 
+// openSource opens the migrations of the binary or of the migrations directory
+func openSource() (source.Driver, error) {
+	if migrationsPath == "" {
+		return iofs.New(migrationsFS, "migrations")
+	}
+
+	return source.Open("file://" + filepath.ToSlash(migrationsPath))
+}
+
+// newMigrate returns a migration instance, closing its source is up to the caller
+//
+// The instance itself is not closed, that would close the database
+func newMigrate() (*migrate.Migrate, source.Driver, error) {
+	if driver == nil {
+		return nil, nil, fmt.Errorf("error the migrator is not initiated")
+	}
+
+	src, err := openSource()
+	if err != nil {
+		return nil, nil, fmt.Errorf("error while opening the migrations: %w", err)
+	}
+
+	m, err := migrate.NewWithInstance("migrations", src, "mysql", driver)
+	if err != nil {
+		src.Close()
+		return nil, nil, err
+	}
+
+	return m, src, nil
+}
+
 // RunMigrate performs all or only some up/down migrations
 //
-// Default 'steps' as 0 (runs all migrations)
+// Default 'steps' as 0 (runs all migrations), a negative number of steps goes down
 func RunMigrate(migrationType string, steps int) (string, error) {
-	m, err := migrate.NewWithDatabaseInstance(
-		"file://database_actions/migrations",
-		"mysql",
-		driver,
-	)
+	m, src, err := newMigrate()
 	if err != nil {
 		return "", fmt.Errorf("error while instanciating new migration ("+migrationType+") with DB : %w", err)
 	}
+	defer src.Close()
 
 	if steps != 0 {
-		m.Steps(steps)
+		err = m.Steps(steps)
+	} else if migrationType == "up" {
+		err = m.Up()
+	} else if migrationType == "down" {
+		err = m.Down()
 	} else {
-		if migrationType == "up" {
-			err = m.Up()
-			if errors.Is(err, migrate.ErrNoChange) {
-				return "Migration(s) : " + migrate.ErrNoChange.Error(), nil
-			}
-			if err != nil {
-				return "", fmt.Errorf("error while running up migration(s): %w", err)
-			}
-		} else if migrationType == "down" {
-			err = m.Down()
-			if errors.Is(err, migrate.ErrNoChange) {
-				return "Migration(s) : " + migrate.ErrNoChange.Error(), nil
-			}
-			if err != nil {
-				return "", fmt.Errorf("error while running down migration(s): %w", err)
-			}
-		} else {
-			return "", fmt.Errorf("error unknown migration type: " + migrationType)
-		}
+		return "", fmt.Errorf("error unknown migration type: %s", migrationType)
+	}
+
+	if errors.Is(err, migrate.ErrNoChange) {
+		return "Migration(s) : " + migrate.ErrNoChange.Error(), nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("error while running %s migration(s): %w", migrationType, err)
 	}
 
 	return migrationsSuccessMessage(migrationType, steps), nil
 }
 
+// MigrateTo runs the up or down migrations leading to the version
+func MigrateTo(version uint) (string, error) {
+	m, src, err := newMigrate()
+	if err != nil {
+		return "", fmt.Errorf("error while instanciating new migration (goto) with DB : %w", err)
+	}
+	defer src.Close()
+
+	err = m.Migrate(version)
+	if errors.Is(err, migrate.ErrNoChange) {
+		return "Migration(s) : " + migrate.ErrNoChange.Error(), nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("error while migrating to the version %d: %w", version, err)
+	}
+
+	return fmt.Sprintf("Successfully migrated to the version %d", version), nil
+}
+
+// ForceMigrationVersion sets the version without running any migration and clears the
+// failed state, once the database has been fixed by hand. -1 means no migration applied
+func ForceMigrationVersion(version int) (string, error) {
+	m, src, err := newMigrate()
+	if err != nil {
+		return "", fmt.Errorf("error while instanciating new migration (force) with DB : %w", err)
+	}
+	defer src.Close()
+
+	err = m.Force(version)
+	if err != nil {
+		return "", fmt.Errorf("error while forcing the version %d: %w", version, err)
+	}
+
+	return fmt.Sprintf("Successfully forced the version %d", version), nil
+}
+
+// MigrationVersion returns the version of the last applied migration, -1 when none,
+// and whether it failed midway
+func MigrationVersion() (int, bool, error) {
+	if driver == nil {
+		return 0, false, fmt.Errorf("error the migrator is not initiated")
+	}
+
+	version, dirty, err := driver.Version()
+	if err != nil {
+		return 0, false, fmt.Errorf("error while reading the migration version: %w", err)
+	}
+
+	return version, dirty, nil
+}
+
+// LatestMigrationVersion returns the version of the last migration
+func LatestMigrationVersion() (int, error) {
+	src, err := openSource()
+	if err != nil {
+		return 0, fmt.Errorf("error while opening the migrations: %w", err)
+	}
+	defer src.Close()
+
+	version, err := src.First()
+	if err != nil {
+		return 0, fmt.Errorf("error while reading the first migration: %w", err)
+	}
+
+	for {
+		next, err := src.Next(version)
+		if errors.Is(err, fs.ErrNotExist) {
+			return int(version), nil
+		}
+		if err != nil {
+			return 0, fmt.Errorf("error while reading the migration after %d: %w", version, err)
+		}
+		version = next
+	}
+}
+
+// CreateMigration writes the empty up and down files of a new migration in the directory,
+// numbered after the last one, and returns their paths
+func CreateMigration(dir string, name string) ([]string, error) {
+	if !migrationNamePattern.MatchString(name) {
+		return nil, fmt.Errorf("error the migration name must only contain lowercase letters and digits separated by underscores")
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("error while reading the migrations directory: %w", err)
+	}
+
+	var last uint64
+	for _, entry := range entries {
+		migration, err := source.DefaultParse(entry.Name())
+		if err != nil {
+			continue
+		}
+		last = max(last, uint64(migration.Version))
+	}
+
+	var paths []string
+	for _, direction := range []string{"up", "down"} {
+		path := filepath.Join(dir, fmt.Sprintf("%d_%s.%s.sql", last+1, name, direction))
+
+		file, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o644)
+		if err != nil {
+			return nil, fmt.Errorf("error while creating the migration: %w", err)
+		}
+		file.Close()
+
+		paths = append(paths, path)
+	}
+
+	return paths, nil
+}
+
 func migrationsSuccessMessage(migrationType string, steps int) string {
 	msg := "Successfully ran"
 	if steps == 0 {
 		return msg + " all " + migrationType + " migrations"
 	}
-	if steps == 1 {
+	if steps == 1 || steps == -1 {
 		return msg + " 1 " + migrationType + " migration"
 	}
 
@@ -0,0 +1,147 @@
+package database_actions
+
+import (
+	"database/sql"
+	"embed"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// sqliteStepsFS holds the steps upgrading the SQLite schema, the migrations being
+// written for MySQL. The step N, named N_<name>.sql, upgrades the schema from the
+// version N-1 to N.
+//
+//go:embed sqlite/*.sql
+var sqliteStepsFS embed.FS
+
+// sqliteStep is a step of the SQLite schema
+type sqliteStep struct {
+	version int
+	file    string
+}
+
+// SQLiteSchemaVersion returns the version of the SQLite schema of the binary, its last step
+func SQLiteSchemaVersion() (int, error) {
+	steps, err := sqliteSteps()
+	if err != nil {
+		return 0, err
+	}
+
+	return len(steps), nil
+}
+
+// UpgradeSQLiteSchema applies to the SQLite database the steps it misses, recorded in
+// its user_version, and returns the version it had.
+//
+// Each step runs in a transaction. The files created before the schema had a version
+// are recognized by their tables. A file of a newer version than the binary is refused.
+func UpgradeSQLiteSchema(db *sql.DB) (int, error) {
+	steps, err := sqliteSteps()
+	if err != nil {
+		return 0, err
+	}
+
+	version, err := sqliteVersion(db)
+	if err != nil {
+		return 0, fmt.Errorf("error while reading the SQLite schema version: %w", err)
+	}
+
+	if version > len(steps) {
+		return version, fmt.Errorf("error the SQLite schema version %d is newer than the version %d of the binary", version, len(steps))
+	}
+
+	for _, step := range steps[version:] {
+		err = applySQLiteStep(db, step)
+		if err != nil {
+			return version, fmt.Errorf("error while upgrading the SQLite schema to the version %d: %w", step.version, err)
+		}
+	}
+
+	return version, nil
+}
+
+// sqliteSteps returns the steps of the SQLite schema, by version
+func sqliteSteps() ([]sqliteStep, error) {
+	files, err := fs.Glob(sqliteStepsFS, "sqlite/*.sql")
+	if err != nil {
+		return nil, err
+	}
+
+	steps := make([]sqliteStep, 0, len(files))
+	for _, file := range files {
+		prefix, _, _ := strings.Cut(path.Base(file), "_")
+		version, err := strconv.Atoi(prefix)
+		if err != nil {
+			return nil, fmt.Errorf("error the SQLite schema step %s has no version", file)
+		}
+
+		steps = append(steps, sqliteStep{version: version, file: file})
+	}
+
+	sort.Slice(steps, func(i, j int) bool { return steps[i].version < steps[j].version })
+
+	for i, step := range steps {
+		if step.version != i+1 {
+			return nil, fmt.Errorf("error the SQLite schema step %d is missing", i+1)
+		}
+	}
+
+	return steps, nil
+}
+
+// sqliteVersion returns the user_version of the database, or the version of its tables
+// when it was created before the schema had a version.
+func sqliteVersion(db *sql.DB) (int, error) {
+	var version int
+	err := db.QueryRow("PRAGMA user_version").Scan(&version)
+	if err != nil || version > 0 {
+		return version, err
+	}
+
+	var species, pets, updatedAt int
+	err = db.QueryRow(`SELECT
+		(SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = 'species'),
+		(SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = 'pets'),
+		(SELECT COUNT(*) FROM pragma_table_info('pets') WHERE name = 'updated_at')`,
+	).Scan(&species, &pets, &updatedAt)
+	if err != nil {
+		return 0, err
+	}
+
+	switch {
+	case species > 0:
+		return 3, nil
+	case updatedAt > 0:
+		return 2, nil
+	case pets > 0:
+		return 1, nil
+	}
+
+	return 0, nil
+}
+
+// applySQLiteStep runs the step and sets the user_version to its version, in a transaction
+func applySQLiteStep(db *sql.DB, step sqliteStep) error {
+	query, err := sqliteStepsFS.ReadFile(step.file)
+	if err != nil {
+		return err
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+
+	// The pragma takes no parameter
+	_, err = tx.Exec(string(query) + ";\nPRAGMA user_version = " + strconv.Itoa(step.version))
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
@@ -0,0 +1,27 @@
+-- The tables of the first SQLite files, before the schema had a version
+CREATE TABLE pets (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    species VARCHAR(255),
+    pet_size VARCHAR(255),
+    name VARCHAR(255),
+    average_male_adult_weight INTEGER,
+    average_female_adult_weight INTEGER,
+    version INTEGER NOT NULL DEFAULT 1,
+    deleted_at DATETIME NULL DEFAULT NULL
+);
+
+CREATE INDEX idx_pets_deleted_at ON pets (deleted_at);
+
+CREATE TABLE audit_log (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    entity_type VARCHAR(64) NOT NULL,
+    entity_id INTEGER NOT NULL,
+    action VARCHAR(32) NOT NULL,
+    actor VARCHAR(255) NOT NULL,
+    created_at DATETIME NOT NULL,
+    before_state TEXT NULL,
+    after_state TEXT NULL
+);
+
+CREATE INDEX idx_audit_log_entity ON audit_log (entity_type, entity_id);
+CREATE INDEX idx_audit_log_created_at ON audit_log (created_at);
@@ -0,0 +1,51 @@
+-- Same cleaning as the MySQL migration 6: the rows that can't be repaired are set aside for review
+CREATE TABLE pets_rejected AS
+SELECT * FROM pets
+WHERE name IS NULL OR TRIM(name) = ''
+    OR LOWER(TRIM(species)) NOT IN ('dog', 'cat') OR species IS NULL
+    OR LOWER(TRIM(pet_size)) NOT IN ('small', 'medium', 'tall') OR pet_size IS NULL;
+
+DELETE FROM pets WHERE id IN (SELECT id FROM pets_rejected);
+
+UPDATE pets SET
+    species = LOWER(TRIM(species)),
+    pet_size = LOWER(TRIM(pet_size)),
+    name = LOWER(TRIM(name)),
+    average_male_adult_weight = COALESCE(average_male_adult_weight, 0),
+    average_female_adult_weight = COALESCE(average_female_adult_weight, 0);
+
+-- Only the first of the duplicates stays out of the trash
+UPDATE pets SET deleted_at = strftime('%Y-%m-%d %H:%M:%f+00:00', 'now'), version = version + 1
+WHERE deleted_at IS NULL AND id NOT IN (
+    SELECT MIN(id) FROM pets WHERE deleted_at IS NULL GROUP BY species, name
+);
+
+-- SQLite can't add constraints to a table, it is copied into a new one
+CREATE TABLE pets_hardened (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    species VARCHAR(255) NOT NULL CHECK (species IN ('dog', 'cat')),
+    pet_size VARCHAR(255) NOT NULL CHECK (pet_size IN ('small', 'medium', 'tall')),
+    name VARCHAR(255) NOT NULL CHECK (name <> ''),
+    average_male_adult_weight INTEGER NOT NULL DEFAULT 0,
+    average_female_adult_weight INTEGER NOT NULL DEFAULT 0,
+    version INTEGER NOT NULL DEFAULT 1,
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    deleted_at DATETIME NULL DEFAULT NULL
+);
+
+INSERT INTO pets_hardened (id, species, pet_size, name, average_male_adult_weight, average_female_adult_weight, version, deleted_at)
+SELECT id, species, pet_size, name, average_male_adult_weight, average_female_adult_weight, version, deleted_at FROM pets;
+
+-- The IDs of the purged pets are not given again, their history is kept
+INSERT INTO sqlite_sequence (name, seq) SELECT 'pets_hardened', 0 WHERE NOT EXISTS (SELECT 1 FROM sqlite_sequence WHERE name = 'pets_hardened');
+UPDATE sqlite_sequence SET seq = (SELECT MAX(seq) FROM sqlite_sequence WHERE name IN ('pets', 'pets_hardened')) WHERE name = 'pets_hardened';
+
+DROP TABLE pets;
+ALTER TABLE pets_hardened RENAME TO pets;
+
+CREATE INDEX idx_pets_deleted_at ON pets (deleted_at);
+-- The pets in the trash may share their species and name, see the live column of MySQL
+CREATE UNIQUE INDEX uq_pets_species_name_live ON pets (species, name) WHERE deleted_at IS NULL;
+CREATE INDEX idx_pets_species_weights ON pets (species, average_male_adult_weight, average_female_adult_weight);
+CREATE INDEX idx_pets_weights ON pets (average_male_adult_weight, average_female_adult_weight);
@@ -0,0 +1,47 @@
+-- The species and the sizes of the pets become reference data, renamed along with the pets
+CREATE TABLE species (
+    name VARCHAR(255) NOT NULL PRIMARY KEY CHECK (name <> ''),
+    min_weight INTEGER NOT NULL,
+    max_weight INTEGER NOT NULL,
+    CHECK (min_weight <= max_weight)
+);
+
+CREATE TABLE pet_sizes (
+    name VARCHAR(255) NOT NULL PRIMARY KEY CHECK (name <> ''),
+    position INTEGER NOT NULL
+);
+
+INSERT INTO species (name, min_weight, max_weight) VALUES ('cat', 1000, 15000), ('dog', 1000, 100000);
+
+INSERT INTO pet_sizes (name, position) VALUES ('small', 1), ('medium', 2), ('tall', 3);
+
+-- The checks of the species and sizes give way to the foreign keys, in a copy of the table.
+-- The deletions of the species and sizes in use are refused by the default action, RESTRICT
+-- would report them as a trigger failure rather than a foreign key one
+CREATE TABLE pets_referencing (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    species VARCHAR(255) NOT NULL REFERENCES species (name) ON UPDATE CASCADE,
+    pet_size VARCHAR(255) NOT NULL REFERENCES pet_sizes (name) ON UPDATE CASCADE,
+    name VARCHAR(255) NOT NULL CHECK (name <> ''),
+    average_male_adult_weight INTEGER NOT NULL DEFAULT 0,
+    average_female_adult_weight INTEGER NOT NULL DEFAULT 0,
+    version INTEGER NOT NULL DEFAULT 1,
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    deleted_at DATETIME NULL DEFAULT NULL
+);
+
+INSERT INTO pets_referencing SELECT * FROM pets;
+
+-- The IDs of the purged pets are not given again, their history is kept
+INSERT INTO sqlite_sequence (name, seq) SELECT 'pets_referencing', 0 WHERE NOT EXISTS (SELECT 1 FROM sqlite_sequence WHERE name = 'pets_referencing');
+UPDATE sqlite_sequence SET seq = (SELECT MAX(seq) FROM sqlite_sequence WHERE name IN ('pets', 'pets_referencing')) WHERE name = 'pets_referencing';
+
+DROP TABLE pets;
+ALTER TABLE pets_referencing RENAME TO pets;
+
+CREATE INDEX idx_pets_deleted_at ON pets (deleted_at);
+CREATE UNIQUE INDEX uq_pets_species_name_live ON pets (species, name) WHERE deleted_at IS NULL;
+CREATE INDEX idx_pets_species_weights ON pets (species, average_male_adult_weight, average_female_adult_weight);
+CREATE INDEX idx_pets_weights ON pets (average_male_adult_weight, average_female_adult_weight);
+CREATE INDEX idx_pets_pet_size ON pets (pet_size);
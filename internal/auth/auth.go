@@ -0,0 +1,197 @@
+package auth
+
+import (
+	"context"
+	"crypto/rsa"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/japhy-tech/backend-test/internal/config"
+)
+
+// The roles, each one granting the rights of the previous ones.
+const (
+	RoleViewer = "viewer"
+	RoleEditor = "editor"
+	RoleAdmin  = "admin"
+)
+
+// The methods a principal is authenticated with.
+const (
+	MethodNone   = "none"
+	MethodAPIKey = "api_key"
+	MethodJWT    = "jwt"
+)
+
+// APIKeyHeader carries the API keys, the tokens are sent in the Authorization header.
+const APIKeyHeader = "X-API-Key"
+
+var (
+	ErrMissingCredentials = errors.New("missing credentials")
+	ErrInvalidCredentials = errors.New("invalid credentials")
+)
+
+// roleRanks orders the roles by increasing privilege.
+var roleRanks = map[string]int{
+	RoleViewer: 1,
+	RoleEditor: 2,
+	RoleAdmin:  3,
+}
+
+// Principal is who makes a request. Subject is the name of the API key or the
+// sub claim of the token, it is empty when the authentication is disabled.
+type Principal struct {
+	Subject string
+	Role    string
+	Method  string
+}
+
+// HasRole tells whether the role of the principal grants the rights of role.
+func (p *Principal) HasRole(role string) bool {
+	return roleRanks[p.Role] >= roleRanks[role]
+}
+
+type principalKey struct{}
+
+func WithPrincipal(ctx context.Context, principal *Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, principal)
+}
+
+// PrincipalFromContext returns the principal set by WithPrincipal, or nil.
+func PrincipalFromContext(ctx context.Context) *Principal {
+	principal, _ := ctx.Value(principalKey{}).(*Principal)
+	return principal
+}
+
+// claims are the claims of the tokens, on top of the registered ones.
+type claims struct {
+	Role string `json:"role"`
+	jwt.RegisteredClaims
+}
+
+// Authenticator checks the credentials of the requests.
+type Authenticator struct {
+	enabled bool
+	// apiKeys are indexed by the SHA-256 of the keys, so that the lookup time
+	// does not depend on how much of a key matches
+	apiKeys   map[[sha256.Size]byte]*Principal
+	secret    []byte
+	publicKey *rsa.PublicKey
+	parser    *jwt.Parser
+}
+
+// NewAuthenticator returns the authenticator of the configuration, which has been validated.
+func NewAuthenticator(cfg *config.AuthConfig) (*Authenticator, error) {
+	a := &Authenticator{
+		enabled: cfg.Enabled,
+		apiKeys: make(map[[sha256.Size]byte]*Principal, len(cfg.APIKeys)),
+	}
+
+	for _, apiKey := range cfg.APIKeys {
+		a.apiKeys[sha256.Sum256([]byte(apiKey.Key))] = &Principal{
+			Subject: apiKey.Name,
+			Role:    apiKey.Role,
+			Method:  MethodAPIKey,
+		}
+	}
+
+	// Only the algorithms of the configured keys are accepted, so that a token
+	// can't be signed with the RSA public key as an HMAC secret
+	var methods []string
+	if cfg.JWT.HS256Key != "" {
+		a.secret = []byte(cfg.JWT.HS256Key)
+		methods = append(methods, jwt.SigningMethodHS256.Alg())
+	}
+	if cfg.JWT.RS256PublicKeyFile != "" {
+		content, err := os.ReadFile(cfg.JWT.RS256PublicKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read the JWT public key: %w", err)
+		}
+
+		a.publicKey, err = jwt.ParseRSAPublicKeyFromPEM(content)
+		if err != nil {
+			return nil, fmt.Errorf("invalid JWT public key: %w", err)
+		}
+		methods = append(methods, jwt.SigningMethodRS256.Alg())
+	}
+
+	options := []jwt.ParserOption{jwt.WithValidMethods(methods), jwt.WithExpirationRequired()}
+	if cfg.JWT.Issuer != "" {
+		options = append(options, jwt.WithIssuer(cfg.JWT.Issuer))
+	}
+	if cfg.JWT.Audience != "" {
+		options = append(options, jwt.WithAudience(cfg.JWT.Audience))
+	}
+	a.parser = jwt.NewParser(options...)
+
+	return a, nil
+}
+
+// Authenticate returns the principal of the API key or of the bearer token of
+// the request. Every request is made by an admin when the authentication is disabled.
+func (a *Authenticator) Authenticate(r *http.Request) (*Principal, error) {
+	if !a.enabled {
+		return &Principal{Role: RoleAdmin, Method: MethodNone}, nil
+	}
+
+	if apiKey := r.Header.Get(APIKeyHeader); apiKey != "" {
+		principal, ok := a.apiKeys[sha256.Sum256([]byte(apiKey))]
+		if !ok {
+			return nil, fmt.Errorf("%w: unknown API key", ErrInvalidCredentials)
+		}
+
+		return principal, nil
+	}
+
+	authorization := r.Header.Get("Authorization")
+	if authorization == "" {
+		return nil, ErrMissingCredentials
+	}
+
+	scheme, token, found := strings.Cut(authorization, " ")
+	if !found || !strings.EqualFold(scheme, "Bearer") {
+		return nil, fmt.Errorf("%w: expected a bearer token", ErrInvalidCredentials)
+	}
+
+	return a.parseToken(strings.TrimSpace(token))
+}
+
+func (a *Authenticator) parseToken(token string) (*Principal, error) {
+	var tokenClaims claims
+
+	_, err := a.parser.ParseWithClaims(token, &tokenClaims, func(token *jwt.Token) (interface{}, error) {
+		switch token.Method.(type) {
+		case *jwt.SigningMethodHMAC:
+			if a.secret != nil {
+				return a.secret, nil
+			}
+		case *jwt.SigningMethodRSA:
+			if a.publicKey != nil {
+				return a.publicKey, nil
+			}
+		}
+
+		return nil, fmt.Errorf("unexpected signing method %s", token.Method.Alg())
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidCredentials, err)
+	}
+
+	if tokenClaims.Subject == "" {
+		return nil, fmt.Errorf("%w: the token has no subject", ErrInvalidCredentials)
+	}
+	if _, ok := roleRanks[tokenClaims.Role]; !ok {
+		return nil, fmt.Errorf("%w: unknown role %q", ErrInvalidCredentials, tokenClaims.Role)
+	}
+
+	return &Principal{
+		Subject: tokenClaims.Subject,
+		Role:    tokenClaims.Role,
+		Method:  MethodJWT,
+	}, nil
+}
@@ -0,0 +1,739 @@
+package config
+
+import (
+	"flag"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	charmLog "github.com/charmbracelet/log"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	LogFormatText   = "text"
+	LogFormatJSON   = "json"
+	LogFormatLogfmt = "logfmt"
+
+	DatabaseBackendMySQL  = "mysql"
+	DatabaseBackendSQLite = "sqlite"
+	DatabaseBackendMemory = "memory"
+
+	TracingExporterNone   = "none"
+	TracingExporterStdout = "stdout"
+	TracingExporterOTLP   = "otlp"
+
+	// redacted replaces the secrets when the configuration is printed.
+	redacted = "********"
+)
+
+// Config is the configuration of the service.
+//
+// The settings are read, by increasing priority, from the defaults, the optional
+// configuration file, the environment variables and the command line flags.
+type Config struct {
+	Server    ServerConfig    `yaml:"server" toml:"server"`
+	Database  DatabaseConfig  `yaml:"database" toml:"database"`
+	Log       LogConfig       `yaml:"log" toml:"log"`
+	Tracing   TracingConfig   `yaml:"tracing" toml:"tracing"`
+	Auth      AuthConfig      `yaml:"auth" toml:"auth"`
+	RateLimit RateLimitConfig `yaml:"rate_limit" toml:"rate_limit"`
+	CORS      CORSConfig      `yaml:"cors" toml:"cors"`
+	Security  SecurityConfig  `yaml:"security" toml:"security"`
+}
+
+// ServerConfig holds the port and the timeouts of the HTTP server.
+//
+// On SIGINT or SIGTERM, the in-flight requests have ShutdownTimeout to complete.
+// The API requests are canceled after RequestTimeout, with a 504.
+// Every readiness check is canceled after ReadinessTimeout.
+type ServerConfig struct {
+	Port              int           `yaml:"port" toml:"port"`
+	ReadHeaderTimeout time.Duration `yaml:"read_header_timeout" toml:"read_header_timeout"`
+	ReadTimeout       time.Duration `yaml:"read_timeout" toml:"read_timeout"`
+	WriteTimeout      time.Duration `yaml:"write_timeout" toml:"write_timeout"`
+	IdleTimeout       time.Duration `yaml:"idle_timeout" toml:"idle_timeout"`
+	ShutdownTimeout   time.Duration `yaml:"shutdown_timeout" toml:"shutdown_timeout"`
+	RequestTimeout    time.Duration `yaml:"request_timeout" toml:"request_timeout"`
+	ReadinessTimeout  time.Duration `yaml:"readiness_timeout" toml:"readiness_timeout"`
+}
+
+// DatabaseConfig holds the DSN parts, the pool sizes and the files loaded in the database.
+//
+// Backend stores the pets in MySQL, in the SQLite file of SQLitePath, or in memory.
+// The DSN parts, the pool sizes and the migrations only apply to MySQL.
+// The migrations built into the binary are run on start unless AutoMigrate is off,
+// MigrationsPath reads them from a directory instead.
+//
+// A MaxOpenConns of 0 means unlimited, a ConnMaxLifetime of 0 means forever.
+// Every query is canceled after QueryTimeout.
+type DatabaseConfig struct {
+	Backend         string        `yaml:"backend" toml:"backend"`
+	SQLitePath      string        `yaml:"sqlite_path" toml:"sqlite_path"`
+	Host            string        `yaml:"host" toml:"host"`
+	Port            int           `yaml:"port" toml:"port"`
+	Name            string        `yaml:"name" toml:"name"`
+	User            string        `yaml:"user" toml:"user"`
+	Password        string        `yaml:"password" toml:"password"`
+	MaxOpenConns    int           `yaml:"max_open_conns" toml:"max_open_conns"`
+	MaxIdleConns    int           `yaml:"max_idle_conns" toml:"max_idle_conns"`
+	ConnMaxLifetime time.Duration `yaml:"conn_max_lifetime" toml:"conn_max_lifetime"`
+	QueryTimeout    time.Duration `yaml:"query_timeout" toml:"query_timeout"`
+	AutoMigrate     bool          `yaml:"auto_migrate" toml:"auto_migrate"`
+	MigrationsPath  string        `yaml:"migrations_path" toml:"migrations_path"`
+	SeedPath        string        `yaml:"seed_path" toml:"seed_path"`
+}
+
+type LogConfig struct {
+	Level  string `yaml:"level" toml:"level"`
+	Format string `yaml:"format" toml:"format"`
+}
+
+// TracingConfig selects where the spans are exported.
+//
+// The stdout exporter writes the spans as JSON to File, or to the standard output
+// when empty. The otlp exporter sends them over HTTP to the Endpoint collector.
+type TracingConfig struct {
+	Exporter string `yaml:"exporter" toml:"exporter"`
+	File     string `yaml:"file" toml:"file"`
+	Endpoint string `yaml:"endpoint" toml:"endpoint"`
+}
+
+// AuthConfig holds the credentials accepted by the API: the API keys, and the
+// keys checking the signature of the JWT bearer tokens, HS256 with HS256Key and
+// RS256 with the PEM public key of RS256PublicKeyFile.
+//
+// When disabled, every request is accepted with the admin role.
+type AuthConfig struct {
+	Enabled bool      `yaml:"enabled" toml:"enabled"`
+	APIKeys []APIKey  `yaml:"api_keys" toml:"api_keys"`
+	JWT     JWTConfig `yaml:"jwt" toml:"jwt"`
+}
+
+// APIKey is given in the X-API-Key header, its Name is the actor of the changes.
+type APIKey struct {
+	Name string `yaml:"name" toml:"name"`
+	Role string `yaml:"role" toml:"role"`
+	Key  string `yaml:"key" toml:"key"`
+}
+
+// JWTConfig checks the bearer tokens, whose iss and aud claims must match
+// Issuer and Audience when set.
+type JWTConfig struct {
+	HS256Key           string `yaml:"hs256_key" toml:"hs256_key"`
+	RS256PublicKeyFile string `yaml:"rs256_public_key_file" toml:"rs256_public_key_file"`
+	Issuer             string `yaml:"issuer" toml:"issuer"`
+	Audience           string `yaml:"audience" toml:"audience"`
+}
+
+// RateLimitConfig holds the budgets of the clients, identified by their API key or
+// token subject, or by their IP address.
+//
+// Every client has a budget per route: the one of Routes for the route, or Default.
+// Every IP address also has the Unauthenticated budget of requests failing the authentication.
+type RateLimitConfig struct {
+	Enabled         bool          `yaml:"enabled" toml:"enabled"`
+	Default         RateBudget    `yaml:"default" toml:"default"`
+	Unauthenticated RateBudget    `yaml:"unauthenticated" toml:"unauthenticated"`
+	Routes          []RouteBudget `yaml:"routes" toml:"routes"`
+}
+
+// RateBudget allows Requests per Period, which can all be made at once.
+type RateBudget struct {
+	Requests int           `yaml:"requests" toml:"requests"`
+	Period   time.Duration `yaml:"period" toml:"period"`
+}
+
+// RouteBudget is the budget of a route, given by its method and template (e.g. POST /v1/pets/search).
+type RouteBudget struct {
+	Method     string `yaml:"method" toml:"method"`
+	Route      string `yaml:"route" toml:"route"`
+	RateBudget `yaml:",inline" toml:",inline"`
+}
+
+// CORSConfig lets the browsers call the API from the AllowedOrigins, "*" for any.
+// The browsers are told to cache the preflight responses for MaxAge.
+//
+// CORS is disabled without allowed origins.
+type CORSConfig struct {
+	AllowedOrigins   []string      `yaml:"allowed_origins" toml:"allowed_origins"`
+	AllowedMethods   []string      `yaml:"allowed_methods" toml:"allowed_methods"`
+	AllowedHeaders   []string      `yaml:"allowed_headers" toml:"allowed_headers"`
+	ExposedHeaders   []string      `yaml:"exposed_headers" toml:"exposed_headers"`
+	AllowCredentials bool          `yaml:"allow_credentials" toml:"allow_credentials"`
+	MaxAge           time.Duration `yaml:"max_age" toml:"max_age"`
+}
+
+// SecurityConfig holds the security headers settings, the HSTS header is only
+// sent over TLS and a HSTSMaxAge of 0 disables it.
+type SecurityConfig struct {
+	HSTSMaxAge time.Duration `yaml:"hsts_max_age" toml:"hsts_max_age"`
+}
+
+// Roles are the roles of the API keys and the tokens, by increasing privilege.
+var Roles = []string{"viewer", "editor", "admin"}
+
+// Command is the parsed command line.
+//
+// Args are the arguments left after the flags, starting with the subcommand if any.
+type Command struct {
+	Config      *Config
+	PrintConfig bool
+	Args        []string
+}
+
+// Default returns the configuration of the docker-compose environment.
+func Default() *Config {
+	return &Config{
+		Server: ServerConfig{
+			Port:              5000,
+			ReadHeaderTimeout: 5 * time.Second,
+			ReadTimeout:       30 * time.Second,
+			WriteTimeout:      60 * time.Second,
+			IdleTimeout:       2 * time.Minute,
+			ShutdownTimeout:   20 * time.Second,
+			RequestTimeout:    30 * time.Second,
+			ReadinessTimeout:  2 * time.Second,
+		},
+		Database: DatabaseConfig{
+			Backend:      DatabaseBackendMySQL,
+			SQLitePath:   "backend-test.db",
+			Host:         "mysql-test",
+			Port:         3306,
+			Name:         "core",
+			User:         "root",
+			QueryTimeout: 10 * time.Second,
+			AutoMigrate:  true,
+			SeedPath:     "database_actions/seeds/breeds.csv",
+		},
+		Log: LogConfig{
+			Level:  "debug",
+			Format: LogFormatText,
+		},
+		Tracing: TracingConfig{
+			Exporter: TracingExporterNone,
+			Endpoint: "http://localhost:4318",
+		},
+		Auth: AuthConfig{
+			Enabled: true,
+		},
+		CORS: CORSConfig{
+			AllowedMethods: []string{"GET", "POST", "PUT", "PATCH", "DELETE"},
+			AllowedHeaders: []string{"Authorization", "Content-Type", "If-Match", "X-API-Key", "X-Actor", "X-Request-ID"},
+			ExposedHeaders: []string{"ETag", "X-Request-ID", "RateLimit-Limit", "RateLimit-Remaining", "RateLimit-Reset", "Retry-After"},
+			MaxAge:         10 * time.Minute,
+		},
+		Security: SecurityConfig{
+			HSTSMaxAge: 365 * 24 * time.Hour,
+		},
+		RateLimit: RateLimitConfig{
+			Enabled:         true,
+			Default:         RateBudget{Requests: 300, Period: time.Minute},
+			Unauthenticated: RateBudget{Requests: 30, Period: time.Minute},
+			Routes: []RouteBudget{
+				{Method: "POST", Route: "/v1/pets/search", RateBudget: RateBudget{Requests: 60, Period: time.Minute}},
+			},
+		},
+	}
+}
+
+// Parse loads the configuration for the command line arguments (without the program name).
+//
+// The configuration file is given by the -config flag or the CONFIG_FILE variable,
+// getenv is os.Getenv outside of the tests.
+//
+// The configuration is not validated, so that an invalid one can still be printed.
+func Parse(args []string, getenv func(string) string) (*Command, error) {
+	// The flags are parsed first to find the configuration file, and only the
+	// flags explicitly set are applied on top of the file and the environment.
+	parsed := Default()
+	flags := parsed.flagSet()
+	configFile := flags.String("config", getenv("CONFIG_FILE"), "configuration file (.yaml, .yml or .toml)")
+	printConfig := flags.Bool("print-config", false, "print the configuration, without the secrets, and exit")
+
+	err := flags.Parse(args)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg := Default()
+	if *configFile != "" {
+		err = cfg.loadFile(*configFile)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	err = cfg.loadEnv(getenv)
+	if err != nil {
+		return nil, err
+	}
+
+	target := cfg.flagSet()
+	flags.Visit(func(f *flag.Flag) {
+		if target.Lookup(f.Name) != nil {
+			target.Set(f.Name, f.Value.String())
+		}
+	})
+
+	return &Command{
+		Config:      cfg,
+		PrintConfig: *printConfig,
+		Args:        flags.Args(),
+	}, nil
+}
+
+// flagSet returns the flags of the settings, bound to the configuration.
+func (c *Config) flagSet() *flag.FlagSet {
+	flags := flag.NewFlagSet("backend-test", flag.ContinueOnError)
+
+	flags.IntVar(&c.Server.Port, "port", c.Server.Port, "port of the API")
+	flags.DurationVar(&c.Server.ReadHeaderTimeout, "read-header-timeout", c.Server.ReadHeaderTimeout, "maximum duration to read the headers of a request")
+	flags.DurationVar(&c.Server.ReadTimeout, "read-timeout", c.Server.ReadTimeout, "maximum duration to read a request")
+	flags.DurationVar(&c.Server.WriteTimeout, "write-timeout", c.Server.WriteTimeout, "maximum duration to write a response")
+	flags.DurationVar(&c.Server.IdleTimeout, "idle-timeout", c.Server.IdleTimeout, "maximum duration of an idle keep-alive connection")
+	flags.DurationVar(&c.Server.ShutdownTimeout, "shutdown-timeout", c.Server.ShutdownTimeout, "maximum duration to drain the in-flight requests on shutdown")
+	flags.DurationVar(&c.Server.RequestTimeout, "request-timeout", c.Server.RequestTimeout, "maximum duration of an API request")
+	flags.DurationVar(&c.Server.ReadinessTimeout, "readiness-timeout", c.Server.ReadinessTimeout, "maximum duration of a readiness check")
+	flags.StringVar(&c.Database.Backend, "db-backend", c.Database.Backend, "storage backend of the pets (mysql, sqlite, memory)")
+	flags.StringVar(&c.Database.SQLitePath, "db-sqlite-path", c.Database.SQLitePath, "database file of the sqlite backend")
+	flags.StringVar(&c.Database.Host, "db-host", c.Database.Host, "database host")
+	flags.IntVar(&c.Database.Port, "db-port", c.Database.Port, "database port")
+	flags.StringVar(&c.Database.Name, "db-name", c.Database.Name, "database name")
+	flags.StringVar(&c.Database.User, "db-user", c.Database.User, "database user")
+	flags.StringVar(&c.Database.Password, "db-password", c.Database.Password, "database password, prefer the DB_PASSWORD variable")
+	flags.IntVar(&c.Database.MaxOpenConns, "db-max-open-conns", c.Database.MaxOpenConns, "maximum number of open connections (0 for unlimited)")
+	flags.IntVar(&c.Database.MaxIdleConns, "db-max-idle-conns", c.Database.MaxIdleConns, "maximum number of idle connections")
+	flags.DurationVar(&c.Database.ConnMaxLifetime, "db-conn-max-lifetime", c.Database.ConnMaxLifetime, "maximum lifetime of a connection (0 for forever)")
+	flags.DurationVar(&c.Database.QueryTimeout, "db-query-timeout", c.Database.QueryTimeout, "maximum duration of a query")
+	flags.BoolVar(&c.Database.AutoMigrate, "auto-migrate", c.Database.AutoMigrate, "run the up migrations on start")
+	flags.StringVar(&c.Database.MigrationsPath, "migrations-path", c.Database.MigrationsPath, "directory of the migrations, the ones of the binary when empty")
+	flags.StringVar(&c.Database.SeedPath, "seed-path", c.Database.SeedPath, "CSV file upserted into the pets table on startup")
+	flags.StringVar(&c.Log.Level, "log-level", c.Log.Level, "log level (debug, info, warn, error)")
+	flags.StringVar(&c.Log.Format, "log-format", c.Log.Format, "log format (text, json, logfmt)")
+	flags.StringVar(&c.Tracing.Exporter, "tracing-exporter", c.Tracing.Exporter, "spans exporter (none, stdout, otlp)")
+	flags.StringVar(&c.Tracing.File, "tracing-file", c.Tracing.File, "file the stdout exporter writes to, the standard output when empty")
+	flags.StringVar(&c.Tracing.Endpoint, "tracing-endpoint", c.Tracing.Endpoint, "URL of the OTLP/HTTP collector")
+	flags.BoolVar(&c.Auth.Enabled, "auth-enabled", c.Auth.Enabled, "require an API key or a JWT on the API")
+	flags.StringVar(&c.Auth.JWT.RS256PublicKeyFile, "auth-jwt-rs256-public-key-file", c.Auth.JWT.RS256PublicKeyFile, "PEM public key of the RS256 tokens")
+	flags.StringVar(&c.Auth.JWT.Issuer, "auth-jwt-issuer", c.Auth.JWT.Issuer, "expected issuer of the tokens")
+	flags.StringVar(&c.Auth.JWT.Audience, "auth-jwt-audience", c.Auth.JWT.Audience, "expected audience of the tokens")
+	flags.BoolVar(&c.RateLimit.Enabled, "rate-limit-enabled", c.RateLimit.Enabled, "limit the rate of the API requests of every client")
+	flags.IntVar(&c.RateLimit.Default.Requests, "rate-limit-requests", c.RateLimit.Default.Requests, "requests allowed per period on every route without a budget of its own")
+	flags.DurationVar(&c.RateLimit.Default.Period, "rate-limit-period", c.RateLimit.Default.Period, "period of the default rate limit")
+	flags.IntVar(&c.RateLimit.Unauthenticated.Requests, "rate-limit-unauthenticated-requests", c.RateLimit.Unauthenticated.Requests, "requests failing the authentication allowed per period from every IP address")
+	flags.DurationVar(&c.RateLimit.Unauthenticated.Period, "rate-limit-unauthenticated-period", c.RateLimit.Unauthenticated.Period, "period of the rate limit of the failed authentications")
+	flags.Var((*stringList)(&c.CORS.AllowedOrigins), "cors-allowed-origins", "comma-separated origins allowed to call the API from a browser, * for any")
+	flags.Var((*stringList)(&c.CORS.AllowedMethods), "cors-allowed-methods", "comma-separated methods allowed from a browser")
+	flags.Var((*stringList)(&c.CORS.AllowedHeaders), "cors-allowed-headers", "comma-separated request headers allowed from a browser")
+	flags.Var((*stringList)(&c.CORS.ExposedHeaders), "cors-exposed-headers", "comma-separated response headers readable from a browser")
+	flags.BoolVar(&c.CORS.AllowCredentials, "cors-allow-credentials", c.CORS.AllowCredentials, "allow the browsers to send their credentials")
+	flags.DurationVar(&c.CORS.MaxAge, "cors-max-age", c.CORS.MaxAge, "duration the browsers cache the preflight responses")
+	flags.DurationVar(&c.Security.HSTSMaxAge, "hsts-max-age", c.Security.HSTSMaxAge, "max-age of the HSTS header, 0 to disable it")
+
+	return flags
+}
+
+// loadFile reads the configuration file, its format is given by its extension.
+func (c *Config) loadFile(path string) error {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("unable to read the configuration file: %w", err)
+	}
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(content, c)
+	case ".toml":
+		err = toml.Unmarshal(content, c)
+	default:
+		return fmt.Errorf("unknown configuration file format: %s", path)
+	}
+	if err != nil {
+		return fmt.Errorf("invalid configuration file %s: %w", path, err)
+	}
+
+	return nil
+}
+
+// loadEnv reads the environment variables that are set.
+//
+// MYSQL_ROOT_PASSWORD, shared with the MySQL container, is used when DB_PASSWORD is not set.
+func (c *Config) loadEnv(getenv func(string) string) error {
+	env := &envLoader{getenv: getenv}
+
+	env.int(&c.Server.Port, "API_PORT")
+	env.duration(&c.Server.ReadHeaderTimeout, "API_READ_HEADER_TIMEOUT")
+	env.duration(&c.Server.ReadTimeout, "API_READ_TIMEOUT")
+	env.duration(&c.Server.WriteTimeout, "API_WRITE_TIMEOUT")
+	env.duration(&c.Server.IdleTimeout, "API_IDLE_TIMEOUT")
+	env.duration(&c.Server.ShutdownTimeout, "API_SHUTDOWN_TIMEOUT")
+	env.duration(&c.Server.RequestTimeout, "API_REQUEST_TIMEOUT")
+	env.duration(&c.Server.ReadinessTimeout, "API_READINESS_TIMEOUT")
+	env.string(&c.Database.Backend, "DB_BACKEND")
+	env.string(&c.Database.SQLitePath, "DB_SQLITE_PATH")
+	env.string(&c.Database.Host, "DB_HOST")
+	env.int(&c.Database.Port, "DB_PORT")
+	env.string(&c.Database.Name, "DB_NAME")
+	env.string(&c.Database.User, "DB_USER")
+	env.string(&c.Database.Password, "MYSQL_ROOT_PASSWORD")
+	env.string(&c.Database.Password, "DB_PASSWORD")
+	env.int(&c.Database.MaxOpenConns, "DB_MAX_OPEN_CONNS")
+	env.int(&c.Database.MaxIdleConns, "DB_MAX_IDLE_CONNS")
+	env.duration(&c.Database.ConnMaxLifetime, "DB_CONN_MAX_LIFETIME")
+	env.duration(&c.Database.QueryTimeout, "DB_QUERY_TIMEOUT")
+	env.bool(&c.Database.AutoMigrate, "DB_AUTO_MIGRATE")
+	env.string(&c.Database.MigrationsPath, "MIGRATIONS_PATH")
+	env.string(&c.Database.SeedPath, "SEED_PATH")
+	env.string(&c.Log.Level, "LOG_LEVEL")
+	env.string(&c.Log.Format, "LOG_FORMAT")
+	env.string(&c.Tracing.Exporter, "TRACING_EXPORTER")
+	env.string(&c.Tracing.File, "TRACING_FILE")
+	env.string(&c.Tracing.Endpoint, "TRACING_ENDPOINT")
+	env.bool(&c.Auth.Enabled, "AUTH_ENABLED")
+	env.apiKeys(&c.Auth.APIKeys, "AUTH_API_KEYS")
+	env.string(&c.Auth.JWT.HS256Key, "AUTH_JWT_HS256_KEY")
+	env.string(&c.Auth.JWT.RS256PublicKeyFile, "AUTH_JWT_RS256_PUBLIC_KEY_FILE")
+	env.string(&c.Auth.JWT.Issuer, "AUTH_JWT_ISSUER")
+	env.string(&c.Auth.JWT.Audience, "AUTH_JWT_AUDIENCE")
+	env.bool(&c.RateLimit.Enabled, "RATE_LIMIT_ENABLED")
+	env.int(&c.RateLimit.Default.Requests, "RATE_LIMIT_REQUESTS")
+	env.duration(&c.RateLimit.Default.Period, "RATE_LIMIT_PERIOD")
+	env.int(&c.RateLimit.Unauthenticated.Requests, "RATE_LIMIT_UNAUTHENTICATED_REQUESTS")
+	env.duration(&c.RateLimit.Unauthenticated.Period, "RATE_LIMIT_UNAUTHENTICATED_PERIOD")
+	env.routeBudgets(&c.RateLimit.Routes, "RATE_LIMIT_ROUTES")
+	env.list(&c.CORS.AllowedOrigins, "CORS_ALLOWED_ORIGINS")
+	env.list(&c.CORS.AllowedMethods, "CORS_ALLOWED_METHODS")
+	env.list(&c.CORS.AllowedHeaders, "CORS_ALLOWED_HEADERS")
+	env.list(&c.CORS.ExposedHeaders, "CORS_EXPOSED_HEADERS")
+	env.bool(&c.CORS.AllowCredentials, "CORS_ALLOW_CREDENTIALS")
+	env.duration(&c.CORS.MaxAge, "CORS_MAX_AGE")
+	env.duration(&c.Security.HSTSMaxAge, "HSTS_MAX_AGE")
+
+	return env.err
+}
+
+// Validate checks the whole configuration and reports every invalid setting.
+func (c *Config) Validate() error {
+	var problems []string
+
+	if c.Server.Port < 1 || c.Server.Port > 65535 {
+		problems = append(problems, "the port must be between 1 and 65535")
+	}
+	if c.Server.ReadHeaderTimeout <= 0 || c.Server.ReadTimeout <= 0 || c.Server.WriteTimeout <= 0 || c.Server.IdleTimeout <= 0 || c.Server.RequestTimeout <= 0 || c.Server.ReadinessTimeout <= 0 {
+		problems = append(problems, "the server timeouts must be positive")
+	}
+	if c.Server.ShutdownTimeout < 0 {
+		problems = append(problems, "the shutdown timeout can't be negative")
+	}
+
+	switch c.Database.Backend {
+	case DatabaseBackendMySQL:
+		problems = append(problems, c.Database.validateMySQL()...)
+	case DatabaseBackendSQLite:
+		if c.Database.SQLitePath == "" {
+			problems = append(problems, "the SQLite path is required by the sqlite backend")
+		}
+	case DatabaseBackendMemory:
+	default:
+		problems = append(problems, "unknown database backend: "+c.Database.Backend)
+	}
+	if c.Database.QueryTimeout <= 0 {
+		problems = append(problems, "the database query timeout must be positive")
+	}
+	if c.Database.SeedPath == "" {
+		problems = append(problems, "the seed path is required")
+	}
+
+	_, err := charmLog.ParseLevel(c.Log.Level)
+	if err != nil {
+		problems = append(problems, "unknown log level: "+c.Log.Level)
+	}
+	if c.Log.Format != LogFormatText && c.Log.Format != LogFormatJSON && c.Log.Format != LogFormatLogfmt {
+		problems = append(problems, "unknown log format: "+c.Log.Format)
+	}
+
+	switch c.Tracing.Exporter {
+	case TracingExporterNone, TracingExporterStdout:
+	case TracingExporterOTLP:
+		if c.Tracing.Endpoint == "" {
+			problems = append(problems, "the tracing endpoint is required by the otlp exporter")
+		}
+	default:
+		problems = append(problems, "unknown tracing exporter: "+c.Tracing.Exporter)
+	}
+
+	if c.Auth.Enabled && len(c.Auth.APIKeys) == 0 && c.Auth.JWT.HS256Key == "" && c.Auth.JWT.RS256PublicKeyFile == "" {
+		problems = append(problems, "the authentication requires an API key or a JWT key (AUTH_API_KEYS, AUTH_JWT_HS256_KEY or AUTH_JWT_RS256_PUBLIC_KEY_FILE)")
+	}
+	for _, origin := range c.CORS.AllowedOrigins {
+		if origin == "*" && c.CORS.AllowCredentials {
+			problems = append(problems, "the CORS credentials can't be allowed from any origin")
+		}
+	}
+	if c.CORS.MaxAge < 0 || c.Security.HSTSMaxAge < 0 {
+		problems = append(problems, "the CORS and HSTS max ages can't be negative")
+	}
+
+	if c.RateLimit.Enabled {
+		if !c.RateLimit.Default.valid() {
+			problems = append(problems, "the default rate limit requires positive requests and period")
+		}
+		if !c.RateLimit.Unauthenticated.valid() {
+			problems = append(problems, "the rate limit of the failed authentications requires positive requests and period")
+		}
+		for _, budget := range c.RateLimit.Routes {
+			if budget.Method == "" || !strings.HasPrefix(budget.Route, "/") || !budget.valid() {
+				problems = append(problems, fmt.Sprintf("invalid rate limit of %s %s", budget.Method, budget.Route))
+			}
+		}
+	}
+
+	for _, apiKey := range c.Auth.APIKeys {
+		if apiKey.Name == "" || apiKey.Key == "" {
+			problems = append(problems, "the API keys require a name and a key")
+		}
+		if !isRole(apiKey.Role) {
+			problems = append(problems, "unknown role of the API key "+apiKey.Name+": "+apiKey.Role)
+		}
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("invalid configuration: %s", strings.Join(problems, "; "))
+	}
+
+	return nil
+}
+
+// validateMySQL checks the settings only used by the mysql backend.
+func (c *DatabaseConfig) validateMySQL() []string {
+	var problems []string
+
+	if c.Host == "" {
+		problems = append(problems, "the database host is required")
+	}
+	if c.Port < 1 || c.Port > 65535 {
+		problems = append(problems, "the database port must be between 1 and 65535")
+	}
+	if c.Name == "" {
+		problems = append(problems, "the database name is required")
+	}
+	if c.User == "" {
+		problems = append(problems, "the database user is required")
+	}
+	if c.Password == "" {
+		problems = append(problems, "the database password is required (DB_PASSWORD or MYSQL_ROOT_PASSWORD)")
+	}
+	if c.MaxOpenConns < 0 || c.MaxIdleConns < 0 || c.ConnMaxLifetime < 0 {
+		problems = append(problems, "the database pool settings can't be negative")
+	}
+	if c.MaxOpenConns > 0 && c.MaxIdleConns > c.MaxOpenConns {
+		problems = append(problems, "the database max idle connections can't exceed the max open connections")
+	}
+
+	return problems
+}
+
+// Redacted returns a copy of the configuration without the secrets.
+func (c *Config) Redacted() *Config {
+	redactedConfig := *c
+	if redactedConfig.Database.Password != "" {
+		redactedConfig.Database.Password = redacted
+	}
+	if redactedConfig.Auth.JWT.HS256Key != "" {
+		redactedConfig.Auth.JWT.HS256Key = redacted
+	}
+
+	redactedConfig.Auth.APIKeys = make([]APIKey, len(c.Auth.APIKeys))
+	for i, apiKey := range c.Auth.APIKeys {
+		apiKey.Key = redacted
+		redactedConfig.Auth.APIKeys[i] = apiKey
+	}
+
+	return &redactedConfig
+}
+
+// String returns the configuration without the secrets, in YAML.
+func (c *Config) String() string {
+	content, err := yaml.Marshal(c.Redacted())
+	if err != nil {
+		return err.Error()
+	}
+
+	return string(content)
+}
+
+// stringList is a comma-separated list flag.
+type stringList []string
+
+func (l *stringList) String() string {
+	if l == nil {
+		return ""
+	}
+
+	return strings.Join(*l, ",")
+}
+
+func (l *stringList) Set(value string) error {
+	*l = splitList(value)
+	return nil
+}
+
+// splitList splits a comma-separated list, without the blank items.
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+
+	return items
+}
+
+func (b RateBudget) valid() bool {
+	return b.Requests > 0 && b.Period > 0
+}
+
+func isRole(role string) bool {
+	for _, r := range Roles {
+		if r == role {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Addr is the address the API listens on.
+func (s *ServerConfig) Addr() string {
+	return net.JoinHostPort("", strconv.Itoa(s.Port))
+}
+
+// Addr is the address of the database server.
+func (d *DatabaseConfig) Addr() string {
+	return net.JoinHostPort(d.Host, strconv.Itoa(d.Port))
+}
+
+// envLoader keeps the first invalid value so that the settings are read in a row.
+type envLoader struct {
+	getenv func(string) string
+	err    error
+}
+
+func (e *envLoader) string(value *string, name string) {
+	if v := e.getenv(name); v != "" {
+		*value = v
+	}
+}
+
+func (e *envLoader) int(value *int, name string) {
+	v := e.getenv(name)
+	if v == "" || e.err != nil {
+		return
+	}
+
+	parsed, err := strconv.Atoi(v)
+	if err != nil {
+		e.err = fmt.Errorf("invalid %s: %s", name, v)
+		return
+	}
+	*value = parsed
+}
+
+func (e *envLoader) bool(value *bool, name string) {
+	v := e.getenv(name)
+	if v == "" || e.err != nil {
+		return
+	}
+
+	parsed, err := strconv.ParseBool(v)
+	if err != nil {
+		e.err = fmt.Errorf("invalid %s: %s", name, v)
+		return
+	}
+	*value = parsed
+}
+
+// apiKeys reads a comma-separated list of name:role:key.
+func (e *envLoader) apiKeys(value *[]APIKey, name string) {
+	v := e.getenv(name)
+	if v == "" || e.err != nil {
+		return
+	}
+
+	var apiKeys []APIKey
+	for _, entry := range strings.Split(v, ",") {
+		parts := strings.SplitN(strings.TrimSpace(entry), ":", 3)
+		if len(parts) != 3 {
+			e.err = fmt.Errorf("invalid %s: expected name:role:key entries", name)
+			return
+		}
+		apiKeys = append(apiKeys, APIKey{Name: parts[0], Role: parts[1], Key: parts[2]})
+	}
+	*value = apiKeys
+}
+
+// list reads a comma-separated list.
+func (e *envLoader) list(value *[]string, name string) {
+	if v := e.getenv(name); v != "" {
+		*value = splitList(v)
+	}
+}
+
+// routeBudgets reads a comma-separated list of METHOD /route=requests/period,
+// e.g. POST /v1/pets/search=60/1m.
+func (e *envLoader) routeBudgets(value *[]RouteBudget, name string) {
+	v := e.getenv(name)
+	if v == "" || e.err != nil {
+		return
+	}
+
+	var budgets []RouteBudget
+	for _, entry := range strings.Split(v, ",") {
+		route, budget, found := strings.Cut(strings.TrimSpace(entry), "=")
+		method, path, routeFound := strings.Cut(route, " ")
+		requests, period, budgetFound := strings.Cut(budget, "/")
+		if !found || !routeFound || !budgetFound {
+			e.err = fmt.Errorf("invalid %s: expected METHOD /route=requests/period entries", name)
+			return
+		}
+
+		parsedRequests, err := strconv.Atoi(requests)
+		if err != nil {
+			e.err = fmt.Errorf("invalid %s: %s", name, entry)
+			return
+		}
+		parsedPeriod, err := time.ParseDuration(period)
+		if err != nil {
+			e.err = fmt.Errorf("invalid %s: %s", name, entry)
+			return
+		}
+
+		budgets = append(budgets, RouteBudget{
+			Method:     strings.ToUpper(method),
+			Route:      strings.TrimSpace(path),
+			RateBudget: RateBudget{Requests: parsedRequests, Period: parsedPeriod},
+		})
+	}
+	*value = budgets
+}
+
+func (e *envLoader) duration(value *time.Duration, name string) {
+	v := e.getenv(name)
+	if v == "" || e.err != nil {
+		return
+	}
+
+	parsed, err := time.ParseDuration(v)
+	if err != nil {
+		e.err = fmt.Errorf("invalid %s: %s", name, v)
+		return
+	}
+	*value = parsed
+}
@@ -2,11 +2,11 @@ package database
 
 import (
 	"database/sql"
-	"fmt"
-	"os"
 	"sync"
 
 	charmLog "github.com/charmbracelet/log"
+	"github.com/go-sql-driver/mysql"
+	"github.com/japhy-tech/backend-test/internal/config"
 )
 
 var (
@@ -14,21 +14,57 @@ var (
 	dbInstance *sql.DB
 )
 
-func NewMysqlDB(logger *charmLog.Logger) *sql.DB {
+// NewMysqlDB opens the database once, with the pool settings of the configuration.
+func NewMysqlDB(logger *charmLog.Logger, cfg *config.DatabaseConfig) *sql.DB {
 	once.Do(func() {
-		mysqlDSN := fmt.Sprintf("root:%s@(mysql-test:3306)/core?parseTime=true", os.Getenv("MYSQL_ROOT_PASSWORD"))
-
-		db, err := sql.Open("mysql", mysqlDSN)
+		db, err := sql.Open("mysql", DSN(cfg))
 		if err != nil {
 			logger.Fatal(err.Error())
 		}
 
+		db.SetMaxOpenConns(cfg.MaxOpenConns)
+		db.SetMaxIdleConns(cfg.MaxIdleConns)
+		db.SetConnMaxLifetime(cfg.ConnMaxLifetime)
+
 		dbInstance = db
 	})
 
 	return dbInstance
 }
 
+// NewMigrationDB opens a connection for the migrations only, since they may hold
+// several statements, which the connections of the API don't accept.
+func NewMigrationDB(cfg *config.DatabaseConfig) (*sql.DB, error) {
+	mysqlConfig := mysqlConfig(cfg)
+	mysqlConfig.MultiStatements = true
+
+	db, err := sql.Open("mysql", mysqlConfig.FormatDSN())
+	if err != nil {
+		return nil, err
+	}
+
+	db.SetMaxOpenConns(1)
+
+	return db, nil
+}
+
+// DSN returns the data source name of the database.
+func DSN(cfg *config.DatabaseConfig) string {
+	return mysqlConfig(cfg).FormatDSN()
+}
+
+func mysqlConfig(cfg *config.DatabaseConfig) *mysql.Config {
+	mysqlConfig := mysql.NewConfig()
+	mysqlConfig.User = cfg.User
+	mysqlConfig.Passwd = cfg.Password
+	mysqlConfig.Net = "tcp"
+	mysqlConfig.Addr = cfg.Addr()
+	mysqlConfig.DBName = cfg.Name
+	mysqlConfig.ParseTime = true
+
+	return mysqlConfig
+}
+
 func GetDb() *sql.DB {
 	return dbInstance
 }
@@ -0,0 +1,31 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"net/url"
+
+	_ "modernc.org/sqlite"
+)
+
+// NewSQLiteDB opens the SQLite database of the file, created if needed.
+//
+// SQLite allows a single writer at a time: the pool has a single connection so that
+// the transactions wait for each other instead of failing as busy.
+func NewSQLiteDB(path string) (*sql.DB, error) {
+	params := url.Values{}
+	params.Add("_pragma", "foreign_keys(1)")
+	params.Add("_pragma", "busy_timeout(5000)")
+	params.Add("_pragma", "journal_mode(WAL)")
+	// Written as text, the times keep their order when compared in the queries
+	params.Set("_time_format", "sqlite")
+
+	db, err := sql.Open("sqlite", "file:"+path+"?"+params.Encode())
+	if err != nil {
+		return nil, fmt.Errorf("unable to open the SQLite database: %w", err)
+	}
+
+	db.SetMaxOpenConns(1)
+
+	return db, nil
+}
@@ -0,0 +1,149 @@
+package http
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	charmLog "github.com/charmbracelet/log"
+	"github.com/gorilla/mux"
+	"github.com/japhy-tech/backend-test/internal/auth"
+	"github.com/japhy-tech/backend-test/internal/entity"
+	"github.com/japhy-tech/backend-test/internal/usecase"
+)
+
+// ActorHeader names who makes a change, it is recorded in the audit log when the
+// authentication is disabled.
+const ActorHeader = "X-Actor"
+
+type AuditHandler struct {
+	AuditUsecase usecase.AuditUsecase
+	logger       *charmLog.Logger
+}
+
+func NewAuditHandler(router *mux.Router, au usecase.AuditUsecase, logger *charmLog.Logger) {
+	handler := &AuditHandler{
+		AuditUsecase: au,
+		logger:       logger,
+	}
+
+	router.HandleFunc("/audit", requireRole(auth.RoleViewer, handler.GetAuditEntries)).Methods("GET")
+}
+
+// GetAuditEntries godoc
+// @Summary Get the audit log
+// @Description Get a page of the changes made through the API, sorted by ID
+// @Tags Audit
+// @Accept json
+// @Produce json
+// @Param entity_type query string false "Type of the changed entity" Enums(pet)
+// @Param entity_id query int false "ID of the changed entity"
+// @Param action query string false "Action" Enums(create, update, delete, restore, purge)
+// @Param actor query string false "Actor of the change"
+// @Param from query string false "Changes made at or after this time (RFC 3339)"
+// @Param to query string false "Changes made before this time (RFC 3339)"
+// @Param limit query int false "Page size (1-1000)"
+// @Param cursor query int false "next_cursor of the previous page"
+// @Param order query string false "Sort order" Enums(asc, desc)
+// @Success 200 {object} SuccessResponse{data=[]entity.AuditEntry}
+// @Failure 400 {object} ErrorResponse "Invalid input"
+// @Failure 401 {object} ErrorResponse "Authentication required"
+// @Failure 403 {object} ErrorResponse "Role not allowed"
+// @Failure 429 {object} ErrorResponse "Too many requests"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Failure 503 {object} ErrorResponse "Database unavailable"
+// @Failure 504 {object} ErrorResponse "Request timed out"
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /v1/audit [get]
+func (h *AuditHandler) GetAuditEntries(w http.ResponseWriter, r *http.Request) {
+	filter, err := parseAuditFilter(r)
+	if err != nil {
+		SendError(w, http.StatusBadRequest, err.Error())
+		logError(h.logger, r, err)
+		return
+	}
+
+	page, err := parseAuditPageRequest(r)
+	if err != nil {
+		SendError(w, http.StatusBadRequest, err.Error())
+		logError(h.logger, r, err)
+		return
+	}
+
+	entries, err := h.AuditUsecase.GetAuditEntries(r.Context(), filter, page)
+	if err != nil {
+		SendUsecaseError(w, err)
+		logError(h.logger, r, err)
+		return
+	}
+
+	SendAuditPage(w, http.StatusOK, entries)
+}
+
+// actorContext returns the request context carrying the actor: the authenticated
+// subject, or the ActorHeader when the authentication is disabled.
+func actorContext(r *http.Request) context.Context {
+	if principal := auth.PrincipalFromContext(r.Context()); principal != nil && principal.Subject != "" {
+		return usecase.WithActor(r.Context(), principal.Subject)
+	}
+
+	return usecase.WithActor(r.Context(), r.Header.Get(ActorHeader))
+}
+
+// parseAuditFilter reads the entity_type, entity_id, action, actor, from and to query parameters.
+func parseAuditFilter(r *http.Request) (*entity.AuditFilter, error) {
+	query := r.URL.Query()
+	filter := &entity.AuditFilter{
+		EntityType: query.Get("entity_type"),
+		Action:     query.Get("action"),
+		Actor:      query.Get("actor"),
+	}
+
+	if entityID := query.Get("entity_id"); entityID != "" {
+		value, err := strconv.Atoi(entityID)
+		if err != nil {
+			return nil, fmt.Errorf("invalid entity_id: %s", entityID)
+		}
+		filter.EntityID = value
+	}
+
+	if from := query.Get("from"); from != "" {
+		value, err := time.Parse(time.RFC3339, from)
+		if err != nil {
+			return nil, fmt.Errorf("invalid from: %s", from)
+		}
+		filter.From = value
+	}
+
+	if to := query.Get("to"); to != "" {
+		value, err := time.Parse(time.RFC3339, to)
+		if err != nil {
+			return nil, fmt.Errorf("invalid to: %s", to)
+		}
+		filter.To = value
+	}
+
+	err := filter.Validate()
+	if err != nil {
+		return nil, err
+	}
+
+	return filter, nil
+}
+
+// parseAuditPageRequest reads the page of an audit list, which can only be sorted by ID.
+func parseAuditPageRequest(r *http.Request) (*entity.PageRequest, error) {
+	page, err := parsePageRequest(r)
+	if err != nil {
+		return nil, err
+	}
+
+	if page.Sort != "id" {
+		return nil, fmt.Errorf("the audit log can only be sorted by id")
+	}
+
+	return page, nil
+}
@@ -0,0 +1,53 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/japhy-tech/backend-test/internal/usecase"
+)
+
+// SendUsecaseError answers with the status matching the kind of the usecase error.
+//
+// Only the messages of the domain errors are sent to clients: any other error, such as a
+// raw database error, is answered with a generic 500 and must be logged by the caller.
+func SendUsecaseError(w http.ResponseWriter, err error) {
+	var validationErr *usecase.ValidationError
+	if errors.As(err, &validationErr) {
+		SendValidationError(w, validationErr)
+		return
+	}
+
+	statusCode := errorStatusCode(err)
+	if statusCode == http.StatusInternalServerError {
+		SendError(w, statusCode, "Internal server error")
+		return
+	}
+
+	message := http.StatusText(statusCode)
+	var domainErr *usecase.Error
+	if errors.As(err, &domainErr) {
+		message = domainErr.Message
+	}
+
+	SendError(w, statusCode, message)
+}
+
+func errorStatusCode(err error) int {
+	switch {
+	case errors.Is(err, usecase.ErrNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, usecase.ErrConflict):
+		return http.StatusConflict
+	case errors.Is(err, usecase.ErrPreconditionFailed):
+		return http.StatusPreconditionFailed
+	case errors.Is(err, usecase.ErrValidation):
+		return http.StatusUnprocessableEntity
+	case errors.Is(err, usecase.ErrUnavailable):
+		return http.StatusServiceUnavailable
+	case errors.Is(err, usecase.ErrTimeout):
+		return http.StatusGatewayTimeout
+	}
+
+	return http.StatusInternalServerError
+}
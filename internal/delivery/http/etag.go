@@ -0,0 +1,36 @@
+package http
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// setETag sets the ETag header from the version of the pet.
+func setETag(w http.ResponseWriter, version int) {
+	w.Header().Set("ETag", strconv.Quote(strconv.Itoa(version)))
+}
+
+// parseIfMatch returns the version expected by the If-Match header,
+// 0 when the header is missing or is "*".
+//
+// ok is false when the header does not hold a single strong ETag of ours:
+// such a header can't match the current ETag of a pet.
+func parseIfMatch(r *http.Request) (version int, ok bool) {
+	ifMatch := strings.TrimSpace(r.Header.Get("If-Match"))
+	if ifMatch == "" || ifMatch == "*" {
+		return 0, true
+	}
+
+	tag, err := strconv.Unquote(ifMatch)
+	if err != nil {
+		return 0, false
+	}
+
+	version, err = strconv.Atoi(tag)
+	if err != nil || version < 1 {
+		return 0, false
+	}
+
+	return version, true
+}
@@ -0,0 +1,43 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/japhy-tech/backend-test/internal/health"
+)
+
+type HealthHandler struct {
+	checker *health.Checker
+}
+
+func NewHealthHandler(router *mux.Router, checker *health.Checker) {
+	handler := &HealthHandler{
+		checker: checker,
+	}
+
+	router.HandleFunc("/livez", handler.Livez).Methods("GET")
+	router.HandleFunc("/readyz", handler.Readyz).Methods("GET")
+}
+
+// Livez tells that the process is alive, whatever the state of its dependencies.
+func (h *HealthHandler) Livez(w http.ResponseWriter, r *http.Request) {
+	w.WriteHeader(http.StatusOK)
+}
+
+// Readyz runs the readiness checks and answers with their report, with a 503
+// when one of them is down.
+func (h *HealthHandler) Readyz(w http.ResponseWriter, r *http.Request) {
+	report := h.checker.Check(r.Context())
+
+	status := http.StatusOK
+	if report.Status != health.StatusUp {
+		status = http.StatusServiceUnavailable
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(report)
+}
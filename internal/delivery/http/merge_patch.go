@@ -0,0 +1,71 @@
+package http
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"sort"
+
+	"github.com/japhy-tech/backend-test/internal/entity"
+)
+
+const MergePatchContentType = "application/merge-patch+json"
+
+// MaxMergePatchSize is the largest document accepted by PatchPet.
+const MaxMergePatchSize = 64 << 10
+
+// isMergePatchContentType tells whether a PATCH request body can be read as a JSON Merge Patch.
+//
+// Plain JSON is accepted as well for clients that can't set the content type.
+func isMergePatchContentType(contentType string) bool {
+	if contentType == "" {
+		return true
+	}
+
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+
+	return mediaType == MergePatchContentType || mediaType == "application/json"
+}
+
+// decodeMergePatch reads a JSON Merge Patch (RFC 7396) document.
+//
+// Every pet field is required, so removing a member with null is rejected
+// like unknown members are.
+func decodeMergePatch(body io.Reader) (*entity.PatchPet, error) {
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return nil, err
+	}
+
+	var members map[string]json.RawMessage
+	err = json.Unmarshal(data, &members)
+	if err != nil {
+		return nil, fmt.Errorf("the merge patch must be a JSON object")
+	}
+
+	var nullMembers []string
+	for name, value := range members {
+		if string(bytes.TrimSpace(value)) == "null" {
+			nullMembers = append(nullMembers, name)
+		}
+	}
+	if len(nullMembers) > 0 {
+		sort.Strings(nullMembers)
+		return nil, fmt.Errorf("the following fields can't be removed: %v", nullMembers)
+	}
+
+	var patch entity.PatchPet
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	err = decoder.Decode(&patch)
+	if err != nil {
+		return nil, err
+	}
+
+	return &patch, nil
+}
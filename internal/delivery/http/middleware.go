@@ -0,0 +1,390 @@
+package http
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"regexp"
+	"runtime/debug"
+	"strconv"
+	"time"
+
+	charmLog "github.com/charmbracelet/log"
+	"github.com/gorilla/mux"
+	"github.com/japhy-tech/backend-test/internal/auth"
+	"github.com/japhy-tech/backend-test/internal/ratelimit"
+	"github.com/japhy-tech/backend-test/internal/tracing"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// RequestIDHeader carries the ID of a request, it is propagated when sent by the client.
+const RequestIDHeader = "X-Request-ID"
+
+// maxRequestIDLength bounds the request IDs sent by the clients, which end up in the logs.
+const maxRequestIDLength = 128
+
+type requestIDKey struct{}
+
+// RequestID sets the ID of the request in its context and in the response headers.
+// A valid X-Request-ID header is kept, otherwise a random ID is generated.
+func RequestID(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestID := r.Header.Get(RequestIDHeader)
+		if !isValidRequestID(requestID) {
+			requestID = newRequestID()
+		}
+
+		w.Header().Set(RequestIDHeader, requestID)
+		ctx := context.WithValue(r.Context(), requestIDKey{}, requestID)
+
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// RequestIDFromContext returns the ID set by RequestID, or an empty string.
+func RequestIDFromContext(ctx context.Context) string {
+	requestID, _ := ctx.Value(requestIDKey{}).(string)
+	return requestID
+}
+
+// AccessLog logs every request once answered.
+func AccessLog(logger *charmLog.Logger) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+			recorder := newResponseRecorder(w)
+
+			next.ServeHTTP(recorder, r)
+
+			logger.Info("request",
+				"method", r.Method,
+				"route", routeTemplate(r),
+				"status", recorder.status,
+				"duration", time.Since(start),
+				"bytes", recorder.bytes,
+				"request_id", RequestIDFromContext(r.Context()),
+			)
+		})
+	}
+}
+
+// RequestObserver records the answered requests, e.g. in metrics.
+type RequestObserver interface {
+	ObserveRequest(method string, route string, status int, duration time.Duration)
+}
+
+// Metrics records every request once answered, by route template.
+func Metrics(observer RequestObserver) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+			recorder := newResponseRecorder(w)
+
+			next.ServeHTTP(recorder, r)
+
+			observer.ObserveRequest(r.Method, routeTemplate(r), recorder.status, time.Since(start))
+		})
+	}
+}
+
+// Tracing starts the span of every request, child of the span of the W3C
+// traceparent header when sent, and returns the traceparent of the span.
+func Tracing(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		propagator := otel.GetTextMapPropagator()
+		ctx := propagator.Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+
+		route := routeTemplate(r)
+		ctx, span := tracing.Tracer().Start(ctx, r.Method+" "+route,
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				attribute.String("http.request.method", r.Method),
+				attribute.String("http.route", route),
+				attribute.String("request_id", RequestIDFromContext(r.Context())),
+			),
+		)
+		defer span.End()
+
+		propagator.Inject(ctx, propagation.HeaderCarrier(w.Header()))
+		recorder := newResponseRecorder(w)
+
+		next.ServeHTTP(recorder, r.WithContext(ctx))
+
+		span.SetAttributes(attribute.Int("http.response.status_code", recorder.status))
+		if recorder.status >= http.StatusInternalServerError {
+			span.SetStatus(codes.Error, http.StatusText(recorder.status))
+		}
+	})
+}
+
+// Recover answers with a 500 when a handler panics, and logs the stack.
+func Recover(logger *charmLog.Logger) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			recorder := newResponseRecorder(w)
+
+			defer func() {
+				recovered := recover()
+				if recovered == nil {
+					return
+				}
+				if recovered == http.ErrAbortHandler {
+					panic(recovered)
+				}
+
+				logger.Error("panic",
+					"method", r.Method,
+					"route", routeTemplate(r),
+					"request_id", RequestIDFromContext(r.Context()),
+					"err", fmt.Sprint(recovered),
+					"stack", string(debug.Stack()),
+				)
+
+				// Nothing can be sent once the response has started
+				if !recorder.wroteHeader {
+					SendError(recorder, http.StatusInternalServerError, "Internal server error")
+				}
+			}()
+
+			next.ServeHTTP(recorder, r)
+		})
+	}
+}
+
+// Authenticate sets the principal of the request in its context, and answers
+// with a 401 when its credentials are missing or invalid.
+func Authenticate(authenticator *auth.Authenticator, logger *charmLog.Logger) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			principal, err := authenticator.Authenticate(r)
+			if err != nil {
+				w.Header().Set("WWW-Authenticate", `Bearer realm="backend-test"`)
+				if errors.Is(err, auth.ErrMissingCredentials) {
+					SendError(w, http.StatusUnauthorized, "Authentication required")
+				} else {
+					SendError(w, http.StatusUnauthorized, "Invalid credentials")
+				}
+				logError(logger, r, err)
+				return
+			}
+
+			next.ServeHTTP(w, r.WithContext(auth.WithPrincipal(r.Context(), principal)))
+		})
+	}
+}
+
+// requireRole answers with a 403 when the principal of the request lacks the
+// role, and with a 401 when the request has not been authenticated.
+func requireRole(role string, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		principal := auth.PrincipalFromContext(r.Context())
+		if principal == nil {
+			SendError(w, http.StatusUnauthorized, "Authentication required")
+			return
+		}
+		if !principal.HasRole(role) {
+			SendError(w, http.StatusForbidden, fmt.Sprintf("The %s role is required", role))
+			return
+		}
+
+		next(w, r)
+	}
+}
+
+// RateLimit answers with a 429 once the client has exhausted its budget for the
+// route, and tells the state of the budget in the RateLimit-* headers.
+// The requests are allowed when the store fails.
+func RateLimit(limiter *ratelimit.Limiter, logger *charmLog.Logger) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			result, err := limiter.Allow(r.Context(), rateLimitClient(r), r.Method, routeTemplate(r))
+			if err != nil {
+				logError(logger, r, err)
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			if !allowRate(w, result) {
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// RateLimitAuthentication answers with a 429 once the IP address has exhausted its
+// budget of failed authentications, before the credentials are checked so that they
+// can't be guessed faster. Registered before Authenticate, it takes a token from the
+// budget for every 401. The requests are allowed when the store fails.
+func RateLimitAuthentication(limiter *ratelimit.Limiter, logger *charmLog.Logger) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			client := ipClient(r)
+
+			result, err := limiter.CheckUnauthenticated(r.Context(), client)
+			if err != nil {
+				logError(logger, r, err)
+			} else if !result.Allowed {
+				allowRate(w, result)
+				return
+			}
+
+			recorder := newResponseRecorder(w)
+			next.ServeHTTP(recorder, r)
+
+			if recorder.status == http.StatusUnauthorized {
+				_, err = limiter.TakeUnauthenticated(r.Context(), client)
+				if err != nil {
+					logError(logger, r, err)
+				}
+			}
+		})
+	}
+}
+
+// allowRate tells the state of the budget in the RateLimit-* headers, and answers
+// with a 429 when the request is not allowed.
+func allowRate(w http.ResponseWriter, result ratelimit.Result) bool {
+	header := w.Header()
+	header.Set("RateLimit-Limit", strconv.Itoa(result.Limit))
+	header.Set("RateLimit-Remaining", strconv.Itoa(result.Remaining))
+	header.Set("RateLimit-Reset", strconv.Itoa(ceilSeconds(result.Reset)))
+
+	if !result.Allowed {
+		retryAfter := ceilSeconds(result.RetryAfter)
+		header.Set("Retry-After", strconv.Itoa(retryAfter))
+		SendError(w, http.StatusTooManyRequests, fmt.Sprintf("Too many requests, retry in %d seconds", retryAfter))
+	}
+
+	return result.Allowed
+}
+
+// rateLimitClient identifies the client by its API key or token subject, or by
+// its IP address without authentication.
+func rateLimitClient(r *http.Request) string {
+	if principal := auth.PrincipalFromContext(r.Context()); principal != nil && principal.Subject != "" {
+		return principal.Method + ":" + principal.Subject
+	}
+
+	return ipClient(r)
+}
+
+// ipClient identifies the client by its IP address.
+func ipClient(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return "ip:" + r.RemoteAddr
+	}
+
+	return "ip:" + host
+}
+
+// ceilSeconds rounds up to the second, so that a client waiting for it is allowed.
+func ceilSeconds(d time.Duration) int {
+	return int((d + time.Second - 1) / time.Second)
+}
+
+// RequestTimeout cancels the context of the requests after the timeout, the
+// queries in progress then fail and the handlers answer with a 504.
+func RequestTimeout(timeout time.Duration) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx, cancel := context.WithTimeout(r.Context(), timeout)
+			defer cancel()
+
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// logError logs the error a request failed with, the access log already tells its status.
+func logError(logger *charmLog.Logger, r *http.Request, err error) {
+	logger.Error("request failed",
+		"method", r.Method,
+		"route", routeTemplate(r),
+		"request_id", RequestIDFromContext(r.Context()),
+		"err", err,
+	)
+}
+
+// routeVariablePattern matches the variables of a route template along with their pattern.
+var routeVariablePattern = regexp.MustCompile(`\{(\w+):[^}]*\}`)
+
+// routeTemplate returns the template of the matched route (e.g. /v1/pets/{id}),
+// which, unlike the path, does not vary with the IDs.
+func routeTemplate(r *http.Request) string {
+	route := mux.CurrentRoute(r)
+	if route == nil {
+		return r.URL.Path
+	}
+
+	template, err := route.GetPathTemplate()
+	if err != nil {
+		return r.URL.Path
+	}
+
+	return routeVariablePattern.ReplaceAllString(template, "{$1}")
+}
+
+func isValidRequestID(requestID string) bool {
+	if requestID == "" || len(requestID) > maxRequestIDLength {
+		return false
+	}
+
+	for _, c := range requestID {
+		if c < '!' || c > '~' {
+			return false
+		}
+	}
+
+	return true
+}
+
+func newRequestID() string {
+	id := make([]byte, 16)
+	rand.Read(id)
+
+	return hex.EncodeToString(id)
+}
+
+// responseRecorder records the status and the size of a response.
+type responseRecorder struct {
+	http.ResponseWriter
+	status      int
+	bytes       int
+	wroteHeader bool
+}
+
+func newResponseRecorder(w http.ResponseWriter) *responseRecorder {
+	return &responseRecorder{ResponseWriter: w, status: http.StatusOK}
+}
+
+func (r *responseRecorder) WriteHeader(status int) {
+	if !r.wroteHeader {
+		r.status = status
+		r.wroteHeader = true
+	}
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *responseRecorder) Write(b []byte) (int, error) {
+	r.wroteHeader = true
+	n, err := r.ResponseWriter.Write(b)
+	r.bytes += n
+
+	return n, err
+}
+
+// Unwrap gives http.ResponseController access to the underlying writer.
+func (r *responseRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
@@ -0,0 +1,46 @@
+package http
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/japhy-tech/backend-test/internal/entity"
+)
+
+// parsePageRequest reads the limit, cursor, sort and order query parameters.
+func parsePageRequest(r *http.Request) (*entity.PageRequest, error) {
+	page := entity.NewPageRequest()
+	query := r.URL.Query()
+
+	if limit := query.Get("limit"); limit != "" {
+		value, err := strconv.Atoi(limit)
+		if err != nil {
+			return nil, fmt.Errorf("invalid limit: %s", limit)
+		}
+		page.Limit = value
+	}
+
+	if cursor := query.Get("cursor"); cursor != "" {
+		value, err := strconv.Atoi(cursor)
+		if err != nil {
+			return nil, fmt.Errorf("invalid cursor: %s", cursor)
+		}
+		page.Cursor = value
+	}
+
+	if sort := query.Get("sort"); sort != "" {
+		page.Sort = sort
+	}
+
+	if order := query.Get("order"); order != "" {
+		page.Order = order
+	}
+
+	err := page.Validate()
+	if err != nil {
+		return nil, err
+	}
+
+	return page, nil
+}
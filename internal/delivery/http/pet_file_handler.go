@@ -0,0 +1,231 @@
+package http
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/japhy-tech/backend-test/internal/entity"
+	"github.com/japhy-tech/backend-test/internal/petfile"
+	"github.com/japhy-tech/backend-test/internal/usecase"
+)
+
+// MaxImportSize is the largest file accepted by ImportPets.
+const MaxImportSize = 10 << 20
+
+// ExportPets godoc
+// @Summary Export all pets
+// @Description Downloads every pet in the column layout of breeds.csv, as CSV or NDJSON.
+// @Description The file is streamed: a failure once it has started aborts the connection.
+// @Tags Pet
+// @Produce text/csv
+// @Produce application/x-ndjson
+// @Param format query string false "File format" Enums(csv, ndjson) default(csv)
+// @Success 200 {file} file
+// @Failure 400 {object} ErrorResponse "Invalid input"
+// @Failure 401 {object} ErrorResponse "Authentication required"
+// @Failure 403 {object} ErrorResponse "Role not allowed"
+// @Failure 429 {object} ErrorResponse "Too many requests"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Failure 503 {object} ErrorResponse "Database unavailable"
+// @Failure 504 {object} ErrorResponse "Request timed out"
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /v1/pets/export [get]
+func (h *PetHandler) ExportPets(w http.ResponseWriter, r *http.Request) {
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = petfile.FormatCSV
+	}
+	if !petfile.IsFormat(format) {
+		SendError(w, http.StatusBadRequest, "format must be csv or ndjson")
+		logError(h.logger, r, fmt.Errorf("unknown format %s", format))
+		return
+	}
+
+	var writer *petfile.Writer
+	err := h.PetUsecase.ExportPets(r.Context(), func(pets []entity.Pet) error {
+		// The headers are only sent once the first page has been read, so that
+		// a failure on it can still be answered with an error
+		if writer == nil {
+			w.Header().Set("Content-Type", petfile.ContentType(format))
+			w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="breeds.%s"`, format))
+			w.WriteHeader(http.StatusOK)
+
+			var err error
+			writer, err = petfile.NewWriter(w, format)
+			if err != nil {
+				return err
+			}
+		}
+
+		for i := range pets {
+			err := writer.Write(&pets[i])
+			if err != nil {
+				return err
+			}
+		}
+
+		return writer.Flush()
+	})
+	if err == nil {
+		return
+	}
+
+	logError(h.logger, r, err)
+	if writer == nil {
+		SendUsecaseError(w, err)
+		return
+	}
+
+	// The 200 has been sent: the connection is aborted so that the client doesn't
+	// take the truncated file for a complete one
+	panic(http.ErrAbortHandler)
+}
+
+// ImportPets godoc
+// @Summary Import pets from a file
+// @Description Makes the pets match a file in the column layout of breeds.csv, as CSV or NDJSON:
+// @Description pets are matched on their species and name, the new ones are created, the changed ones updated
+// @Description and the ones missing from the file deleted, in a single transaction.
+// @Description A file without any pet is rejected rather than deleting every pet.
+// @Description The file is either the request body or the "file" field of a multipart form.
+// @Description With dry_run, only the difference is returned.
+// @Tags Pet
+// @Accept text/csv
+// @Accept application/x-ndjson
+// @Accept multipart/form-data
+// @Produce json
+// @Param format query string false "File format, guessed from the content type or the file name by default" Enums(csv, ndjson)
+// @Param dry_run query bool false "Only return the difference"
+// @Param file formData file false "File to import"
+// @Param X-Actor header string false "Who makes the change, recorded in the audit log when the authentication is disabled"
+// @Success 200 {object} SuccessResponse{data=usecase.ImportReport}
+// @Failure 400 {object} ErrorResponse "Invalid input"
+// @Failure 401 {object} ErrorResponse "Authentication required"
+// @Failure 403 {object} ErrorResponse "Role not allowed"
+// @Failure 409 {object} ErrorResponse "Pet already exists"
+// @Failure 412 {object} ErrorResponse "Pet modified during the import"
+// @Failure 413 {object} ErrorResponse "File too large"
+// @Failure 422 {object} ErrorResponse "Invalid lines, or no pet in the file"
+// @Failure 429 {object} ErrorResponse "Too many requests"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Failure 503 {object} ErrorResponse "Database unavailable"
+// @Failure 504 {object} ErrorResponse "Request timed out"
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /v1/pets/import [post]
+func (h *PetHandler) ImportPets(w http.ResponseWriter, r *http.Request) {
+	dryRun := false
+	if value := r.URL.Query().Get("dry_run"); value != "" {
+		var err error
+		dryRun, err = strconv.ParseBool(value)
+		if err != nil {
+			SendError(w, http.StatusBadRequest, "dry_run must be a boolean")
+			logError(h.logger, r, err)
+			return
+		}
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, MaxImportSize)
+
+	file, format, err := importFile(r)
+	if err != nil {
+		SendError(w, bodyErrorStatusCode(err), err.Error())
+		logError(h.logger, r, err)
+		return
+	}
+	defer file.Close()
+
+	records, err := petfile.Read(file, format)
+	var parseErrors *petfile.ParseErrors
+	if errors.As(err, &parseErrors) {
+		SendValidationError(w, lineErrors(parseErrors))
+		logError(h.logger, r, err)
+		return
+	}
+	if err != nil {
+		SendError(w, bodyErrorStatusCode(err), err.Error())
+		logError(h.logger, r, err)
+		return
+	}
+
+	report, err := h.PetUsecase.ImportPets(actorContext(r), records, dryRun)
+	if err != nil {
+		SendUsecaseError(w, err)
+		logError(h.logger, r, err)
+		return
+	}
+
+	SendSuccess(w, http.StatusOK, report)
+}
+
+// bodyErrorStatusCode returns 413 when the request body is too large, 400 otherwise.
+func bodyErrorStatusCode(err error) int {
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) {
+		return http.StatusRequestEntityTooLarge
+	}
+
+	return http.StatusBadRequest
+}
+
+// importFile returns the uploaded file and its format.
+func importFile(r *http.Request) (io.ReadCloser, string, error) {
+	format := r.URL.Query().Get("format")
+	if format != "" && !petfile.IsFormat(format) {
+		return nil, "", fmt.Errorf("format must be csv or ndjson")
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+
+	if mediaType == "multipart/form-data" {
+		file, header, err := r.FormFile("file")
+		if err != nil {
+			return nil, "", fmt.Errorf("the file field is required: %w", err)
+		}
+
+		if format == "" {
+			format = formatFromName(header.Filename)
+		}
+
+		return file, format, nil
+	}
+
+	if format == "" {
+		format = petfile.FormatCSV
+		if mediaType == petfile.ContentType(petfile.FormatNDJSON) {
+			format = petfile.FormatNDJSON
+		}
+	}
+
+	return r.Body, format, nil
+}
+
+func formatFromName(name string) string {
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".ndjson", ".jsonl":
+		return petfile.FormatNDJSON
+	}
+
+	return petfile.FormatCSV
+}
+
+// lineErrors converts the lines that could not be read into a validation error.
+func lineErrors(parseErrors *petfile.ParseErrors) *usecase.ValidationError {
+	validationErr := &usecase.ValidationError{}
+	for _, lineErr := range parseErrors.Errors {
+		validationErr.Errors = append(validationErr.Errors, usecase.FieldError{
+			Line:    lineErr.Line,
+			Field:   lineErr.Field,
+			Message: lineErr.Message,
+		})
+	}
+
+	return validationErr
+}
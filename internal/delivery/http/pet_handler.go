@@ -2,12 +2,15 @@ package http
 
 import (
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"strconv"
 
 	charmLog "github.com/charmbracelet/log"
 	"github.com/gorilla/mux"
+	"github.com/japhy-tech/backend-test/internal/auth"
 	"github.com/japhy-tech/backend-test/internal/entity"
+	"github.com/japhy-tech/backend-test/internal/tracing"
 	"github.com/japhy-tech/backend-test/internal/usecase"
 )
 
@@ -22,12 +25,20 @@ func NewPetHandler(router *mux.Router, pu usecase.PetUsecase, logger *charmLog.L
 		logger:     logger,
 	}
 
-	router.HandleFunc("/pets", handler.CreatePet).Methods("POST")
-	router.HandleFunc("/pets", handler.GetPets).Methods("GET")
-	router.HandleFunc("/pets/{id}", handler.GetPet).Methods("GET")
-	router.HandleFunc("/pets/{id}", handler.UpdatePet).Methods("PUT")
-	router.HandleFunc("/pets/{id}", handler.DeletePet).Methods("DELETE")
-	router.HandleFunc("/pets/search", handler.SearchPets).Methods("POST")
+	// Viewers read, editors create and update, admins delete and import
+	router.HandleFunc("/pets", requireRole(auth.RoleEditor, handler.CreatePet)).Methods("POST")
+	router.HandleFunc("/pets", requireRole(auth.RoleViewer, handler.GetPets)).Methods("GET")
+	router.HandleFunc("/pets/{id:[0-9]+}", requireRole(auth.RoleViewer, handler.GetPet)).Methods("GET")
+	router.HandleFunc("/pets/{id:[0-9]+}", requireRole(auth.RoleEditor, handler.UpdatePet)).Methods("PUT")
+	router.HandleFunc("/pets/{id:[0-9]+}", requireRole(auth.RoleEditor, handler.PatchPet)).Methods("PATCH")
+	router.HandleFunc("/pets/{id:[0-9]+}", requireRole(auth.RoleAdmin, handler.DeletePet)).Methods("DELETE")
+	router.HandleFunc("/pets/search", requireRole(auth.RoleViewer, handler.SearchPets)).Methods("POST")
+	router.HandleFunc("/pets/batch", requireRole(auth.RoleAdmin, handler.BatchPets)).Methods("POST")
+	router.HandleFunc("/pets/export", requireRole(auth.RoleViewer, handler.ExportPets)).Methods("GET")
+	router.HandleFunc("/pets/import", requireRole(auth.RoleAdmin, handler.ImportPets)).Methods("POST")
+	router.HandleFunc("/pets/trash", requireRole(auth.RoleViewer, handler.GetDeletedPets)).Methods("GET")
+	router.HandleFunc("/pets/{id:[0-9]+}/restore", requireRole(auth.RoleEditor, handler.RestorePet)).Methods("POST")
+	router.HandleFunc("/pets/{id:[0-9]+}/history", requireRole(auth.RoleViewer, handler.GetPetHistory)).Methods("GET")
 }
 
 // CreatePet godoc
@@ -37,26 +48,34 @@ func NewPetHandler(router *mux.Router, pu usecase.PetUsecase, logger *charmLog.L
 // @Accept json
 // @Produce json
 // @Param CreatePet body entity.CreatePet true "Pet object"
+// @Param X-Actor header string false "Who makes the change, recorded in the audit log when the authentication is disabled"
 // @Success 201 {object} SuccessResponse{data=entity.Pet}
 // @Failure 400 {object} ErrorResponse "Invalid input"
+// @Failure 401 {object} ErrorResponse "Authentication required"
+// @Failure 403 {object} ErrorResponse "Role not allowed"
+// @Failure 409 {object} ErrorResponse "Pet already exists"
+// @Failure 422 {object} ErrorResponse "Invalid fields"
+// @Failure 429 {object} ErrorResponse "Too many requests"
 // @Failure 500 {object} ErrorResponse "Internal server error"
+// @Failure 503 {object} ErrorResponse "Database unavailable"
+// @Failure 504 {object} ErrorResponse "Request timed out"
+// @Security ApiKeyAuth
+// @Security BearerAuth
 // @Router /v1/pets [post]
 func (h *PetHandler) CreatePet(w http.ResponseWriter, r *http.Request) {
-	h.logger.Info("[POST]	/v1/pets")
-
 	var pet entity.CreatePet
 
-	err := json.NewDecoder(r.Body).Decode(&pet)
+	err := decodeJSON(r, &pet)
 	if err != nil {
 		SendError(w, http.StatusBadRequest, err.Error())
-		h.logger.Error("[POST]	/v1/pets; error:", err.Error())
+		logError(h.logger, r, err)
 		return
 	}
 
-	createdPet, err := h.PetUsecase.CreatePet(&pet)
+	createdPet, err := h.PetUsecase.CreatePet(actorContext(r), &pet)
 	if err != nil {
-		SendError(w, http.StatusInternalServerError, err.Error())
-		h.logger.Error("[POST]	/v1/pets; error:", err.Error())
+		SendUsecaseError(w, err)
+		logError(h.logger, r, err)
 		return
 	}
 
@@ -65,24 +84,42 @@ func (h *PetHandler) CreatePet(w http.ResponseWriter, r *http.Request) {
 
 // GetPets godoc
 // @Summary Get all pets
-// @Description Get all pets from the database
+// @Description Get a page of pets from the database, sorted by ID unless specified.
+// @Description Without limit, up to 1000 pets are returned.
 // @Tags Pet
 // @Accept json
 // @Produce json
+// @Param limit query int false "Page size (1-1000)"
+// @Param cursor query int false "next_cursor of the previous page"
+// @Param sort query string false "Sort field" Enums(id, species, pet_size, name, average_male_adult_weight, average_female_adult_weight)
+// @Param order query string false "Sort order" Enums(asc, desc)
 // @Success 200 {object} SuccessResponse{data=[]entity.Pet}
+// @Failure 400 {object} ErrorResponse "Invalid input"
+// @Failure 401 {object} ErrorResponse "Authentication required"
+// @Failure 403 {object} ErrorResponse "Role not allowed"
+// @Failure 429 {object} ErrorResponse "Too many requests"
 // @Failure 500 {object} ErrorResponse "Internal server error"
+// @Failure 503 {object} ErrorResponse "Database unavailable"
+// @Failure 504 {object} ErrorResponse "Request timed out"
+// @Security ApiKeyAuth
+// @Security BearerAuth
 // @Router /v1/pets [get]
 func (h *PetHandler) GetPets(w http.ResponseWriter, r *http.Request) {
-	h.logger.Info("[GET]	/v1/pets")
-
-	pets, err := h.PetUsecase.GetPets()
+	page, err := parsePageRequest(r)
 	if err != nil {
 		SendError(w, http.StatusBadRequest, err.Error())
-		h.logger.Error("[GET]	/v1/pets; error:", err.Error())
+		logError(h.logger, r, err)
 		return
 	}
 
-	SendSuccess(w, http.StatusOK, pets)
+	pets, err := h.PetUsecase.GetPets(r.Context(), page)
+	if err != nil {
+		SendUsecaseError(w, err)
+		logError(h.logger, r, err)
+		return
+	}
+
+	SendPage(w, http.StatusOK, pets)
 }
 
 // GetPet godoc
@@ -93,27 +130,35 @@ func (h *PetHandler) GetPets(w http.ResponseWriter, r *http.Request) {
 // @Produce json
 // @Param id path int true "Pet ID"
 // @Success 200 {object} SuccessResponse{data=entity.Pet}
+// @Header 200 {string} ETag "Version of the pet, to send in If-Match"
 // @Failure 400 {object} ErrorResponse "Invalid input"
+// @Failure 401 {object} ErrorResponse "Authentication required"
+// @Failure 403 {object} ErrorResponse "Role not allowed"
+// @Failure 404 {object} ErrorResponse "Pet not found"
+// @Failure 429 {object} ErrorResponse "Too many requests"
 // @Failure 500 {object} ErrorResponse "Internal server error"
+// @Failure 503 {object} ErrorResponse "Database unavailable"
+// @Failure 504 {object} ErrorResponse "Request timed out"
+// @Security ApiKeyAuth
+// @Security BearerAuth
 // @Router /v1/pets/{id} [get]
 func (h *PetHandler) GetPet(w http.ResponseWriter, r *http.Request) {
-	h.logger.Info("[GET]	/v1/pets/{id}")
-
 	vars := mux.Vars(r)
 	id, err := strconv.Atoi(vars["id"])
 	if err != nil {
 		SendError(w, http.StatusBadRequest, "Invalid ID")
-		h.logger.Error("[GET]	/v1/pets/{id}; error:", err.Error())
+		logError(h.logger, r, err)
 		return
 	}
 
-	pet, err := h.PetUsecase.GetPetByID(id)
+	pet, err := h.PetUsecase.GetPetByID(r.Context(), id)
 	if err != nil {
-		SendError(w, http.StatusInternalServerError, err.Error())
-		h.logger.Error("[GET]	/v1/pets/{id}; error:", err.Error())
+		SendUsecaseError(w, err)
+		logError(h.logger, r, err)
 		return
 	}
 
+	setETag(w, pet.Version)
 	SendSuccess(w, http.StatusOK, pet)
 }
 
@@ -124,66 +169,172 @@ func (h *PetHandler) GetPet(w http.ResponseWriter, r *http.Request) {
 // @Accept json
 // @Produce json
 // @Param id path int true "Pet ID"
+// @Param If-Match header string false "ETag of the pet, the request fails if it has been modified since"
 // @Param UpdatePet body entity.UpdatePet true "Pet object"
+// @Param X-Actor header string false "Who makes the change, recorded in the audit log when the authentication is disabled"
 // @Success 200 {object} SuccessResponse{data=entity.Pet}
+// @Header 200 {string} ETag "New version of the pet"
 // @Failure 400 {object} ErrorResponse "Invalid input"
+// @Failure 401 {object} ErrorResponse "Authentication required"
+// @Failure 403 {object} ErrorResponse "Role not allowed"
+// @Failure 404 {object} ErrorResponse "Pet not found"
+// @Failure 409 {object} ErrorResponse "Pet already exists"
+// @Failure 422 {object} ErrorResponse "Invalid fields"
+// @Failure 412 {object} ErrorResponse "Pet modified since it was fetched"
+// @Failure 429 {object} ErrorResponse "Too many requests"
 // @Failure 500 {object} ErrorResponse "Internal server error"
+// @Failure 503 {object} ErrorResponse "Database unavailable"
+// @Failure 504 {object} ErrorResponse "Request timed out"
+// @Security ApiKeyAuth
+// @Security BearerAuth
 // @Router /v1/pets/{id} [put]
 func (h *PetHandler) UpdatePet(w http.ResponseWriter, r *http.Request) {
-	h.logger.Info("[PUT]	/v1/pets/{id}")
-
 	vars := mux.Vars(r)
 	id, err := strconv.Atoi(vars["id"])
 	if err != nil {
 		SendError(w, http.StatusBadRequest, "Invalid ID")
-		h.logger.Error("[PUT]	/v1/pets/{id}; error:", err.Error())
+		logError(h.logger, r, err)
 		return
 	}
 
 	var pet entity.UpdatePet
-	err = json.NewDecoder(r.Body).Decode(&pet)
+	err = decodeJSON(r, &pet)
 	if err != nil {
 		SendError(w, http.StatusBadRequest, err.Error())
-		h.logger.Error("[PUT]	/v1/pets/{id}; error:", err.Error())
+		logError(h.logger, r, err)
 		return
 	}
 
-	updatedPet, err := h.PetUsecase.UpdatePet(id, &pet)
+	version, ok := parseIfMatch(r)
+	if !ok {
+		SendError(w, http.StatusPreconditionFailed, "If-Match does not match the current ETag")
+		logError(h.logger, r, fmt.Errorf("unknown If-Match %s", r.Header.Get("If-Match")))
+		return
+	}
+
+	updatedPet, err := h.PetUsecase.UpdatePet(actorContext(r), id, &pet, version)
 	if err != nil {
-		SendError(w, http.StatusInternalServerError, err.Error())
-		h.logger.Error("[PUT]	/v1/pets/{id}; error:", err.Error())
+		SendUsecaseError(w, err)
+		logError(h.logger, r, err)
 		return
 	}
 
+	setETag(w, updatedPet.Version)
 	SendSuccess(w, http.StatusOK, updatedPet)
 }
 
+// PatchPet godoc
+// @Summary Partially update an existing pet
+// @Description Updates only the fields given in a JSON Merge Patch (RFC 7396) document
+// @Tags Pet
+// @Accept application/merge-patch+json
+// @Produce json
+// @Param id path int true "Pet ID"
+// @Param If-Match header string false "ETag of the pet, the request fails if it has been modified since"
+// @Param PatchPet body entity.PatchPet true "Fields to update"
+// @Param X-Actor header string false "Who makes the change, recorded in the audit log when the authentication is disabled"
+// @Success 200 {object} SuccessResponse{data=entity.Pet}
+// @Header 200 {string} ETag "New version of the pet"
+// @Failure 400 {object} ErrorResponse "Invalid input"
+// @Failure 401 {object} ErrorResponse "Authentication required"
+// @Failure 403 {object} ErrorResponse "Role not allowed"
+// @Failure 404 {object} ErrorResponse "Pet not found"
+// @Failure 409 {object} ErrorResponse "Pet already exists"
+// @Failure 413 {object} ErrorResponse "Document too large"
+// @Failure 415 {object} ErrorResponse "Unsupported media type"
+// @Failure 422 {object} ErrorResponse "Invalid fields"
+// @Failure 412 {object} ErrorResponse "Pet modified since it was fetched"
+// @Failure 429 {object} ErrorResponse "Too many requests"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Failure 503 {object} ErrorResponse "Database unavailable"
+// @Failure 504 {object} ErrorResponse "Request timed out"
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /v1/pets/{id} [patch]
+func (h *PetHandler) PatchPet(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		SendError(w, http.StatusBadRequest, "Invalid ID")
+		logError(h.logger, r, err)
+		return
+	}
+
+	if !isMergePatchContentType(r.Header.Get("Content-Type")) {
+		SendError(w, http.StatusUnsupportedMediaType, "Content-Type must be "+MergePatchContentType)
+		logError(h.logger, r, fmt.Errorf("unsupported content type %s", r.Header.Get("Content-Type")))
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, MaxMergePatchSize)
+
+	pet, err := decodeMergePatch(r.Body)
+	if err != nil {
+		SendError(w, bodyErrorStatusCode(err), err.Error())
+		logError(h.logger, r, err)
+		return
+	}
+
+	version, ok := parseIfMatch(r)
+	if !ok {
+		SendError(w, http.StatusPreconditionFailed, "If-Match does not match the current ETag")
+		logError(h.logger, r, fmt.Errorf("unknown If-Match %s", r.Header.Get("If-Match")))
+		return
+	}
+
+	patchedPet, err := h.PetUsecase.PatchPet(actorContext(r), id, pet, version)
+	if err != nil {
+		SendUsecaseError(w, err)
+		logError(h.logger, r, err)
+		return
+	}
+
+	setETag(w, patchedPet.Version)
+	SendSuccess(w, http.StatusOK, patchedPet)
+}
+
 // DeletePet godoc
 // @Summary Delete a pet
-// @Description Delete a pet by its ID
+// @Description Moves a pet to the trash, from which it can be restored until it is purged
 // @Tags Pet
 // @Accept json
 // @Produce json
 // @Param id path int true "Pet ID"
+// @Param If-Match header string false "ETag of the pet, the request fails if it has been modified since"
+// @Param X-Actor header string false "Who makes the change, recorded in the audit log when the authentication is disabled"
 // @Success 200 {object} SuccessResponse{data=nil}
 // @Failure 400 {object} ErrorResponse "Invalid input"
+// @Failure 401 {object} ErrorResponse "Authentication required"
+// @Failure 403 {object} ErrorResponse "Role not allowed"
+// @Failure 404 {object} ErrorResponse "Pet not found"
+// @Failure 412 {object} ErrorResponse "Pet modified since it was fetched"
+// @Failure 429 {object} ErrorResponse "Too many requests"
 // @Failure 500 {object} ErrorResponse "Internal server error"
+// @Failure 503 {object} ErrorResponse "Database unavailable"
+// @Failure 504 {object} ErrorResponse "Request timed out"
+// @Security ApiKeyAuth
+// @Security BearerAuth
 // @Router /v1/pets/{id} [delete]
 func (h *PetHandler) DeletePet(w http.ResponseWriter, r *http.Request) {
-	h.logger.Info("[DELETE]	/v1/pets/{id}")
-
 	vars := mux.Vars(r)
 	id, err := strconv.Atoi(vars["id"])
 	if err != nil {
 		SendError(w, http.StatusBadRequest, "Invalid ID")
-		h.logger.Error("[DELETE]	/v1/pets/{id}; error:", err.Error())
+		logError(h.logger, r, err)
+		return
+	}
+
+	version, ok := parseIfMatch(r)
+	if !ok {
+		SendError(w, http.StatusPreconditionFailed, "If-Match does not match the current ETag")
+		logError(h.logger, r, fmt.Errorf("unknown If-Match %s", r.Header.Get("If-Match")))
 		return
 	}
 
-	err = h.PetUsecase.DeletePet(id)
+	err = h.PetUsecase.DeletePet(actorContext(r), id, version)
 	if err != nil {
-		SendError(w, http.StatusInternalServerError, err.Error())
-		h.logger.Error("[DELETE]	/v1/pets/{id}; error:", err.Error())
+		SendUsecaseError(w, err)
+		logError(h.logger, r, err)
 		return
 	}
 
@@ -192,32 +343,240 @@ func (h *PetHandler) DeletePet(w http.ResponseWriter, r *http.Request) {
 
 // SearchPets godoc
 // @Summary Search pets
-// @Description Search for pets by species and weight
+// @Description Search for pets by species, size, name and weight
 // @Tags Pet
 // @Accept json
 // @Produce json
 // @Param SearchPets body entity.SearchPets true "Search options"
+// @Param limit query int false "Page size (1-1000)"
+// @Param cursor query int false "next_cursor of the previous page"
+// @Param sort query string false "Sort field" Enums(id, species, pet_size, name, average_male_adult_weight, average_female_adult_weight)
+// @Param order query string false "Sort order" Enums(asc, desc)
 // @Success 200 {object} SuccessResponse{data=[]entity.Pet}
 // @Failure 400 {object} ErrorResponse "Invalid input"
+// @Failure 401 {object} ErrorResponse "Authentication required"
+// @Failure 403 {object} ErrorResponse "Role not allowed"
+// @Failure 429 {object} ErrorResponse "Too many requests"
 // @Failure 500 {object} ErrorResponse "Internal server error"
+// @Failure 503 {object} ErrorResponse "Database unavailable"
+// @Failure 504 {object} ErrorResponse "Request timed out"
+// @Security ApiKeyAuth
+// @Security BearerAuth
 // @Router /v1/pets/search [post]
 func (h *PetHandler) SearchPets(w http.ResponseWriter, r *http.Request) {
-	h.logger.Info("[POST]	/v1/pets/search")
+	page, err := parsePageRequest(r)
+	if err != nil {
+		SendError(w, http.StatusBadRequest, err.Error())
+		logError(h.logger, r, err)
+		return
+	}
 
 	var searchPets entity.SearchPets
-	err := json.NewDecoder(r.Body).Decode(&searchPets)
+	err = decodeJSON(r, &searchPets)
+	if err != nil {
+		SendError(w, http.StatusBadRequest, err.Error())
+		logError(h.logger, r, err)
+		return
+	}
+
+	err = searchPets.Validate()
+	if err != nil {
+		SendError(w, http.StatusBadRequest, err.Error())
+		logError(h.logger, r, err)
+		return
+	}
+
+	pets, err := h.PetUsecase.SearchPets(r.Context(), &searchPets, page)
+	if err != nil {
+		SendUsecaseError(w, err)
+		logError(h.logger, r, err)
+		return
+	}
+
+	SendPage(w, http.StatusOK, pets)
+}
+
+// BatchPets godoc
+// @Summary Create, update and delete pets in a single transaction
+// @Description Applies mixed operations in a single transaction and reports the outcome of each one.
+// @Description In atomic mode (default) a failed operation rolls back the whole batch,
+// @Description in best_effort mode the operations that succeed are committed, nothing is kept of the ones that fail.
+// @Tags Pet
+// @Accept json
+// @Produce json
+// @Param BatchPets body entity.BatchPets true "Operations"
+// @Param X-Actor header string false "Who makes the change, recorded in the audit log when the authentication is disabled"
+// @Success 200 {object} SuccessResponse{data=usecase.BatchReport}
+// @Failure 400 {object} ErrorResponse "Invalid input"
+// @Failure 401 {object} ErrorResponse "Authentication required"
+// @Failure 403 {object} ErrorResponse "Role not allowed"
+// @Failure 429 {object} ErrorResponse "Too many requests"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Failure 503 {object} ErrorResponse "Database unavailable"
+// @Failure 504 {object} ErrorResponse "Request timed out"
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /v1/pets/batch [post]
+func (h *PetHandler) BatchPets(w http.ResponseWriter, r *http.Request) {
+	var batch entity.BatchPets
+	err := decodeJSON(r, &batch)
+	if err != nil {
+		SendError(w, http.StatusBadRequest, err.Error())
+		logError(h.logger, r, err)
+		return
+	}
+
+	err = batch.Validate()
+	if err != nil {
+		SendError(w, http.StatusBadRequest, err.Error())
+		logError(h.logger, r, err)
+		return
+	}
+
+	report, err := h.PetUsecase.BatchPets(actorContext(r), &batch)
+	if err != nil {
+		SendUsecaseError(w, err)
+		logError(h.logger, r, err)
+		return
+	}
+
+	SendSuccess(w, http.StatusOK, report)
+}
+
+// GetDeletedPets godoc
+// @Summary Get the deleted pets
+// @Description Get a page of the pets in the trash, sorted by ID unless specified
+// @Tags Pet
+// @Accept json
+// @Produce json
+// @Param limit query int false "Page size (1-1000)"
+// @Param cursor query int false "next_cursor of the previous page"
+// @Param sort query string false "Sort field" Enums(id, species, pet_size, name, average_male_adult_weight, average_female_adult_weight)
+// @Param order query string false "Sort order" Enums(asc, desc)
+// @Success 200 {object} SuccessResponse{data=[]entity.Pet}
+// @Failure 400 {object} ErrorResponse "Invalid input"
+// @Failure 401 {object} ErrorResponse "Authentication required"
+// @Failure 403 {object} ErrorResponse "Role not allowed"
+// @Failure 429 {object} ErrorResponse "Too many requests"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Failure 503 {object} ErrorResponse "Database unavailable"
+// @Failure 504 {object} ErrorResponse "Request timed out"
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /v1/pets/trash [get]
+func (h *PetHandler) GetDeletedPets(w http.ResponseWriter, r *http.Request) {
+	page, err := parsePageRequest(r)
+	if err != nil {
+		SendError(w, http.StatusBadRequest, err.Error())
+		logError(h.logger, r, err)
+		return
+	}
+
+	pets, err := h.PetUsecase.GetDeletedPets(r.Context(), page)
+	if err != nil {
+		SendUsecaseError(w, err)
+		logError(h.logger, r, err)
+		return
+	}
+
+	SendPage(w, http.StatusOK, pets)
+}
+
+// RestorePet godoc
+// @Summary Restore a deleted pet
+// @Description Takes a pet out of the trash
+// @Tags Pet
+// @Accept json
+// @Produce json
+// @Param id path int true "Pet ID"
+// @Param X-Actor header string false "Who makes the change, recorded in the audit log when the authentication is disabled"
+// @Success 200 {object} SuccessResponse{data=entity.Pet}
+// @Header 200 {string} ETag "New version of the pet"
+// @Failure 400 {object} ErrorResponse "Invalid input"
+// @Failure 401 {object} ErrorResponse "Authentication required"
+// @Failure 403 {object} ErrorResponse "Role not allowed"
+// @Failure 404 {object} ErrorResponse "Pet not found in the trash"
+// @Failure 409 {object} ErrorResponse "Pet already exists"
+// @Failure 429 {object} ErrorResponse "Too many requests"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Failure 503 {object} ErrorResponse "Database unavailable"
+// @Failure 504 {object} ErrorResponse "Request timed out"
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /v1/pets/{id}/restore [post]
+func (h *PetHandler) RestorePet(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		SendError(w, http.StatusBadRequest, "Invalid ID")
+		logError(h.logger, r, err)
+		return
+	}
+
+	restoredPet, err := h.PetUsecase.RestorePet(actorContext(r), id)
+	if err != nil {
+		SendUsecaseError(w, err)
+		logError(h.logger, r, err)
+		return
+	}
+
+	setETag(w, restoredPet.Version)
+	SendSuccess(w, http.StatusOK, restoredPet)
+}
+
+// GetPetHistory godoc
+// @Summary Get the history of a pet
+// @Description Get a page of the changes made to a pet, sorted by ID.
+// @Description The history remains once the pet is purged.
+// @Tags Pet
+// @Accept json
+// @Produce json
+// @Param id path int true "Pet ID"
+// @Param limit query int false "Page size (1-1000)"
+// @Param cursor query int false "next_cursor of the previous page"
+// @Param order query string false "Sort order" Enums(asc, desc)
+// @Success 200 {object} SuccessResponse{data=[]entity.AuditEntry}
+// @Failure 400 {object} ErrorResponse "Invalid input"
+// @Failure 401 {object} ErrorResponse "Authentication required"
+// @Failure 403 {object} ErrorResponse "Role not allowed"
+// @Failure 429 {object} ErrorResponse "Too many requests"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Failure 503 {object} ErrorResponse "Database unavailable"
+// @Failure 504 {object} ErrorResponse "Request timed out"
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /v1/pets/{id}/history [get]
+func (h *PetHandler) GetPetHistory(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		SendError(w, http.StatusBadRequest, "Invalid ID")
+		logError(h.logger, r, err)
+		return
+	}
+
+	page, err := parseAuditPageRequest(r)
 	if err != nil {
 		SendError(w, http.StatusBadRequest, err.Error())
-		h.logger.Error("[POST]	/v1/pets/search; error:", err.Error())
+		logError(h.logger, r, err)
 		return
 	}
 
-	pets, err := h.PetUsecase.SearchPets(&searchPets)
+	entries, err := h.PetUsecase.GetPetHistory(r.Context(), id, page)
 	if err != nil {
-		SendError(w, http.StatusInternalServerError, err.Error())
-		h.logger.Error("[POST]	/v1/pets/search; error:", err.Error())
+		SendUsecaseError(w, err)
+		logError(h.logger, r, err)
 		return
 	}
 
-	SendSuccess(w, http.StatusOK, pets)
+	SendAuditPage(w, http.StatusOK, entries)
+}
+
+// decodeJSON decodes the JSON body of the request into v, in a span of its own.
+func decodeJSON(r *http.Request, v interface{}) error {
+	_, span := tracing.Start(r.Context(), "PetHandler.decodeJSON")
+	err := json.NewDecoder(r.Body).Decode(v)
+	tracing.End(span, err)
+
+	return err
 }
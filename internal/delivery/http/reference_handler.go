@@ -0,0 +1,375 @@
+package http
+
+import (
+	"net/http"
+
+	charmLog "github.com/charmbracelet/log"
+	"github.com/gorilla/mux"
+	"github.com/japhy-tech/backend-test/internal/auth"
+	"github.com/japhy-tech/backend-test/internal/entity"
+	"github.com/japhy-tech/backend-test/internal/usecase"
+)
+
+type ReferenceHandler struct {
+	ReferenceUsecase usecase.ReferenceUsecase
+	logger           *charmLog.Logger
+}
+
+func NewReferenceHandler(router *mux.Router, ru usecase.ReferenceUsecase, logger *charmLog.Logger) {
+	handler := &ReferenceHandler{
+		ReferenceUsecase: ru,
+		logger:           logger,
+	}
+
+	// Viewers read, admins change the species and sizes of every pet
+	router.HandleFunc("/species", requireRole(auth.RoleViewer, handler.GetAllSpecies)).Methods("GET")
+	router.HandleFunc("/species", requireRole(auth.RoleAdmin, handler.CreateSpecies)).Methods("POST")
+	router.HandleFunc("/species/{name}", requireRole(auth.RoleViewer, handler.GetSpecies)).Methods("GET")
+	router.HandleFunc("/species/{name}", requireRole(auth.RoleAdmin, handler.UpdateSpecies)).Methods("PUT")
+	router.HandleFunc("/species/{name}", requireRole(auth.RoleAdmin, handler.DeleteSpecies)).Methods("DELETE")
+	router.HandleFunc("/sizes", requireRole(auth.RoleViewer, handler.GetAllPetSizes)).Methods("GET")
+	router.HandleFunc("/sizes", requireRole(auth.RoleAdmin, handler.CreatePetSize)).Methods("POST")
+	router.HandleFunc("/sizes/{name}", requireRole(auth.RoleViewer, handler.GetPetSize)).Methods("GET")
+	router.HandleFunc("/sizes/{name}", requireRole(auth.RoleAdmin, handler.UpdatePetSize)).Methods("PUT")
+	router.HandleFunc("/sizes/{name}", requireRole(auth.RoleAdmin, handler.DeletePetSize)).Methods("DELETE")
+}
+
+// GetAllSpecies godoc
+// @Summary Get all species
+// @Description Get the species the pets can have, sorted by name
+// @Tags Reference
+// @Accept json
+// @Produce json
+// @Success 200 {object} SuccessResponse{data=[]entity.Species}
+// @Failure 401 {object} ErrorResponse "Authentication required"
+// @Failure 403 {object} ErrorResponse "Role not allowed"
+// @Failure 429 {object} ErrorResponse "Too many requests"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Failure 503 {object} ErrorResponse "Database unavailable"
+// @Failure 504 {object} ErrorResponse "Request timed out"
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /v1/species [get]
+func (h *ReferenceHandler) GetAllSpecies(w http.ResponseWriter, r *http.Request) {
+	species, err := h.ReferenceUsecase.GetAllSpecies(r.Context())
+	if err != nil {
+		SendUsecaseError(w, err)
+		logError(h.logger, r, err)
+		return
+	}
+
+	SendSuccess(w, http.StatusOK, species)
+}
+
+// GetSpecies godoc
+// @Summary Get a species
+// @Description Get a species by its name
+// @Tags Reference
+// @Accept json
+// @Produce json
+// @Param name path string true "Species name"
+// @Success 200 {object} SuccessResponse{data=entity.Species}
+// @Failure 401 {object} ErrorResponse "Authentication required"
+// @Failure 403 {object} ErrorResponse "Role not allowed"
+// @Failure 404 {object} ErrorResponse "Species not found"
+// @Failure 429 {object} ErrorResponse "Too many requests"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Failure 503 {object} ErrorResponse "Database unavailable"
+// @Failure 504 {object} ErrorResponse "Request timed out"
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /v1/species/{name} [get]
+func (h *ReferenceHandler) GetSpecies(w http.ResponseWriter, r *http.Request) {
+	species, err := h.ReferenceUsecase.GetSpecies(r.Context(), mux.Vars(r)["name"])
+	if err != nil {
+		SendUsecaseError(w, err)
+		logError(h.logger, r, err)
+		return
+	}
+
+	SendSuccess(w, http.StatusOK, species)
+}
+
+// CreateSpecies godoc
+// @Summary Create a species
+// @Description Adds a species the pets can have, with the bounds of their average adult weights
+// @Tags Reference
+// @Accept json
+// @Produce json
+// @Param Species body entity.Species true "Species object"
+// @Success 200 {object} SuccessResponse{data=entity.Species}
+// @Failure 400 {object} ErrorResponse "Invalid input"
+// @Failure 401 {object} ErrorResponse "Authentication required"
+// @Failure 403 {object} ErrorResponse "Role not allowed"
+// @Failure 409 {object} ErrorResponse "Species already exists"
+// @Failure 422 {object} ErrorResponse "Invalid fields"
+// @Failure 429 {object} ErrorResponse "Too many requests"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Failure 503 {object} ErrorResponse "Database unavailable"
+// @Failure 504 {object} ErrorResponse "Request timed out"
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /v1/species [post]
+func (h *ReferenceHandler) CreateSpecies(w http.ResponseWriter, r *http.Request) {
+	var species entity.Species
+
+	err := decodeJSON(r, &species)
+	if err != nil {
+		SendError(w, http.StatusBadRequest, err.Error())
+		logError(h.logger, r, err)
+		return
+	}
+
+	createdSpecies, err := h.ReferenceUsecase.CreateSpecies(r.Context(), &species)
+	if err != nil {
+		SendUsecaseError(w, err)
+		logError(h.logger, r, err)
+		return
+	}
+
+	SendSuccess(w, http.StatusOK, createdSpecies)
+}
+
+// UpdateSpecies godoc
+// @Summary Update a species
+// @Description Replaces a species, a new name renames the species of its pets, in the trash or not,
+// @Description as an update of each pet recorded in the audit log. The weight bounds apply to the next changes of the pets.
+// @Tags Reference
+// @Accept json
+// @Produce json
+// @Param name path string true "Species name"
+// @Param Species body entity.Species true "Species object"
+// @Param X-Actor header string false "Who makes the change, recorded in the audit log when the authentication is disabled"
+// @Success 200 {object} SuccessResponse{data=entity.Species}
+// @Failure 400 {object} ErrorResponse "Invalid input"
+// @Failure 401 {object} ErrorResponse "Authentication required"
+// @Failure 403 {object} ErrorResponse "Role not allowed"
+// @Failure 404 {object} ErrorResponse "Species not found"
+// @Failure 409 {object} ErrorResponse "Species already exists"
+// @Failure 422 {object} ErrorResponse "Invalid fields"
+// @Failure 429 {object} ErrorResponse "Too many requests"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Failure 503 {object} ErrorResponse "Database unavailable"
+// @Failure 504 {object} ErrorResponse "Request timed out"
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /v1/species/{name} [put]
+func (h *ReferenceHandler) UpdateSpecies(w http.ResponseWriter, r *http.Request) {
+	var species entity.Species
+
+	err := decodeJSON(r, &species)
+	if err != nil {
+		SendError(w, http.StatusBadRequest, err.Error())
+		logError(h.logger, r, err)
+		return
+	}
+
+	updatedSpecies, err := h.ReferenceUsecase.UpdateSpecies(actorContext(r), mux.Vars(r)["name"], &species)
+	if err != nil {
+		SendUsecaseError(w, err)
+		logError(h.logger, r, err)
+		return
+	}
+
+	SendSuccess(w, http.StatusOK, updatedSpecies)
+}
+
+// DeleteSpecies godoc
+// @Summary Delete a species
+// @Description Deletes a species no pet has, in the trash or not
+// @Tags Reference
+// @Accept json
+// @Produce json
+// @Param name path string true "Species name"
+// @Success 200 {object} SuccessResponse{data=nil}
+// @Failure 401 {object} ErrorResponse "Authentication required"
+// @Failure 403 {object} ErrorResponse "Role not allowed"
+// @Failure 404 {object} ErrorResponse "Species not found"
+// @Failure 409 {object} ErrorResponse "Species used by pets"
+// @Failure 429 {object} ErrorResponse "Too many requests"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Failure 503 {object} ErrorResponse "Database unavailable"
+// @Failure 504 {object} ErrorResponse "Request timed out"
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /v1/species/{name} [delete]
+func (h *ReferenceHandler) DeleteSpecies(w http.ResponseWriter, r *http.Request) {
+	err := h.ReferenceUsecase.DeleteSpecies(r.Context(), mux.Vars(r)["name"])
+	if err != nil {
+		SendUsecaseError(w, err)
+		logError(h.logger, r, err)
+		return
+	}
+
+	SendSuccess(w, http.StatusOK, nil)
+}
+
+// GetAllPetSizes godoc
+// @Summary Get all sizes
+// @Description Get the sizes the pets can have, from the smallest
+// @Tags Reference
+// @Accept json
+// @Produce json
+// @Success 200 {object} SuccessResponse{data=[]entity.PetSize}
+// @Failure 401 {object} ErrorResponse "Authentication required"
+// @Failure 403 {object} ErrorResponse "Role not allowed"
+// @Failure 429 {object} ErrorResponse "Too many requests"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Failure 503 {object} ErrorResponse "Database unavailable"
+// @Failure 504 {object} ErrorResponse "Request timed out"
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /v1/sizes [get]
+func (h *ReferenceHandler) GetAllPetSizes(w http.ResponseWriter, r *http.Request) {
+	sizes, err := h.ReferenceUsecase.GetAllPetSizes(r.Context())
+	if err != nil {
+		SendUsecaseError(w, err)
+		logError(h.logger, r, err)
+		return
+	}
+
+	SendSuccess(w, http.StatusOK, sizes)
+}
+
+// GetPetSize godoc
+// @Summary Get a size
+// @Description Get a size by its name
+// @Tags Reference
+// @Accept json
+// @Produce json
+// @Param name path string true "Size name"
+// @Success 200 {object} SuccessResponse{data=entity.PetSize}
+// @Failure 401 {object} ErrorResponse "Authentication required"
+// @Failure 403 {object} ErrorResponse "Role not allowed"
+// @Failure 404 {object} ErrorResponse "Size not found"
+// @Failure 429 {object} ErrorResponse "Too many requests"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Failure 503 {object} ErrorResponse "Database unavailable"
+// @Failure 504 {object} ErrorResponse "Request timed out"
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /v1/sizes/{name} [get]
+func (h *ReferenceHandler) GetPetSize(w http.ResponseWriter, r *http.Request) {
+	size, err := h.ReferenceUsecase.GetPetSize(r.Context(), mux.Vars(r)["name"])
+	if err != nil {
+		SendUsecaseError(w, err)
+		logError(h.logger, r, err)
+		return
+	}
+
+	SendSuccess(w, http.StatusOK, size)
+}
+
+// CreatePetSize godoc
+// @Summary Create a size
+// @Description Adds a size the pets can have, the position orders the sizes from the smallest
+// @Tags Reference
+// @Accept json
+// @Produce json
+// @Param PetSize body entity.PetSize true "Size object"
+// @Success 200 {object} SuccessResponse{data=entity.PetSize}
+// @Failure 400 {object} ErrorResponse "Invalid input"
+// @Failure 401 {object} ErrorResponse "Authentication required"
+// @Failure 403 {object} ErrorResponse "Role not allowed"
+// @Failure 409 {object} ErrorResponse "Size already exists"
+// @Failure 422 {object} ErrorResponse "Invalid fields"
+// @Failure 429 {object} ErrorResponse "Too many requests"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Failure 503 {object} ErrorResponse "Database unavailable"
+// @Failure 504 {object} ErrorResponse "Request timed out"
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /v1/sizes [post]
+func (h *ReferenceHandler) CreatePetSize(w http.ResponseWriter, r *http.Request) {
+	var size entity.PetSize
+
+	err := decodeJSON(r, &size)
+	if err != nil {
+		SendError(w, http.StatusBadRequest, err.Error())
+		logError(h.logger, r, err)
+		return
+	}
+
+	createdSize, err := h.ReferenceUsecase.CreatePetSize(r.Context(), &size)
+	if err != nil {
+		SendUsecaseError(w, err)
+		logError(h.logger, r, err)
+		return
+	}
+
+	SendSuccess(w, http.StatusOK, createdSize)
+}
+
+// UpdatePetSize godoc
+// @Summary Update a size
+// @Description Replaces a size, a new name renames the size of its pets, in the trash or not,
+// @Description as an update of each pet recorded in the audit log
+// @Tags Reference
+// @Accept json
+// @Produce json
+// @Param name path string true "Size name"
+// @Param PetSize body entity.PetSize true "Size object"
+// @Param X-Actor header string false "Who makes the change, recorded in the audit log when the authentication is disabled"
+// @Success 200 {object} SuccessResponse{data=entity.PetSize}
+// @Failure 400 {object} ErrorResponse "Invalid input"
+// @Failure 401 {object} ErrorResponse "Authentication required"
+// @Failure 403 {object} ErrorResponse "Role not allowed"
+// @Failure 404 {object} ErrorResponse "Size not found"
+// @Failure 409 {object} ErrorResponse "Size already exists"
+// @Failure 422 {object} ErrorResponse "Invalid fields"
+// @Failure 429 {object} ErrorResponse "Too many requests"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Failure 503 {object} ErrorResponse "Database unavailable"
+// @Failure 504 {object} ErrorResponse "Request timed out"
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /v1/sizes/{name} [put]
+func (h *ReferenceHandler) UpdatePetSize(w http.ResponseWriter, r *http.Request) {
+	var size entity.PetSize
+
+	err := decodeJSON(r, &size)
+	if err != nil {
+		SendError(w, http.StatusBadRequest, err.Error())
+		logError(h.logger, r, err)
+		return
+	}
+
+	updatedSize, err := h.ReferenceUsecase.UpdatePetSize(actorContext(r), mux.Vars(r)["name"], &size)
+	if err != nil {
+		SendUsecaseError(w, err)
+		logError(h.logger, r, err)
+		return
+	}
+
+	SendSuccess(w, http.StatusOK, updatedSize)
+}
+
+// DeletePetSize godoc
+// @Summary Delete a size
+// @Description Deletes a size no pet has, in the trash or not
+// @Tags Reference
+// @Accept json
+// @Produce json
+// @Param name path string true "Size name"
+// @Success 200 {object} SuccessResponse{data=nil}
+// @Failure 401 {object} ErrorResponse "Authentication required"
+// @Failure 403 {object} ErrorResponse "Role not allowed"
+// @Failure 404 {object} ErrorResponse "Size not found"
+// @Failure 409 {object} ErrorResponse "Size used by pets"
+// @Failure 429 {object} ErrorResponse "Too many requests"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Failure 503 {object} ErrorResponse "Database unavailable"
+// @Failure 504 {object} ErrorResponse "Request timed out"
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /v1/sizes/{name} [delete]
+func (h *ReferenceHandler) DeletePetSize(w http.ResponseWriter, r *http.Request) {
+	err := h.ReferenceUsecase.DeletePetSize(r.Context(), mux.Vars(r)["name"])
+	if err != nil {
+		SendUsecaseError(w, err)
+		logError(h.logger, r, err)
+		return
+	}
+
+	SendSuccess(w, http.StatusOK, nil)
+}
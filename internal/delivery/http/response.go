@@ -3,16 +3,30 @@ package http
 import (
 	"encoding/json"
 	"net/http"
+
+	"github.com/japhy-tech/backend-test/internal/entity"
+	"github.com/japhy-tech/backend-test/internal/usecase"
 )
 
 type SuccessResponse struct {
-	Status string      `json:"status"`
-	Data   interface{} `json:"data"`
+	Status     string      `json:"status"`
+	Data       interface{} `json:"data"`
+	Pagination *Pagination `json:"pagination,omitempty"`
+}
+
+// Pagination is only set on paginated lists.
+//
+// NextCursor is null on the last page.
+type Pagination struct {
+	Limit      int  `json:"limit"`
+	NextCursor *int `json:"next_cursor"`
+	Total      int  `json:"total"`
 }
 
 type ErrorResponse struct {
-	Status  string `json:"status"`
-	Message string `json:"message"`
+	Status  string               `json:"status"`
+	Message string               `json:"message"`
+	Errors  []usecase.FieldError `json:"errors,omitempty"`
 }
 
 func SendSuccess(w http.ResponseWriter, statusCode int, data interface{}) {
@@ -24,6 +38,32 @@ func SendSuccess(w http.ResponseWriter, statusCode int, data interface{}) {
 	})
 }
 
+func SendPage(w http.ResponseWriter, statusCode int, page *entity.PetPage) {
+	sendPage(w, statusCode, page.Pets, page.Limit, page.NextCursor, page.Total)
+}
+
+func SendAuditPage(w http.ResponseWriter, statusCode int, page *entity.AuditPage) {
+	sendPage(w, statusCode, page.Entries, page.Limit, page.NextCursor, page.Total)
+}
+
+func sendPage(w http.ResponseWriter, statusCode int, data interface{}, limit int, nextCursor int, total int) {
+	pagination := &Pagination{
+		Limit: limit,
+		Total: total,
+	}
+	if nextCursor > 0 {
+		pagination.NextCursor = &nextCursor
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	json.NewEncoder(w).Encode(SuccessResponse{
+		Status:     "success",
+		Data:       data,
+		Pagination: pagination,
+	})
+}
+
 func SendError(w http.ResponseWriter, statusCode int, message string) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(statusCode)
@@ -32,3 +72,14 @@ func SendError(w http.ResponseWriter, statusCode int, message string) {
 		Message: message,
 	})
 }
+
+// SendValidationError sends a 422 listing the invalid fields.
+func SendValidationError(w http.ResponseWriter, validationErr *usecase.ValidationError) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusUnprocessableEntity)
+	json.NewEncoder(w).Encode(ErrorResponse{
+		Status:  "error",
+		Message: "Validation failed",
+		Errors:  validationErr.Errors,
+	})
+}
@@ -0,0 +1,89 @@
+package entity
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+const (
+	AuditEntityPet = "pet"
+
+	AuditActionCreate  = "create"
+	AuditActionUpdate  = "update"
+	AuditActionDelete  = "delete"
+	AuditActionRestore = "restore"
+	AuditActionPurge   = "purge"
+)
+
+// AuditActions lists the actions recorded in the audit log.
+var AuditActions = []string{
+	AuditActionCreate,
+	AuditActionUpdate,
+	AuditActionDelete,
+	AuditActionRestore,
+	AuditActionPurge,
+}
+
+// AuditEntry records a change made to an entity.
+//
+// Before and After hold the JSON state of the entity around the change, they are
+// null when the entity did not exist before (create) or after (delete).
+// A purge concerns many pets: its EntityID is 0 and After holds its summary.
+type AuditEntry struct {
+	ID         int             `json:"id"`
+	EntityType string          `json:"entity_type"`
+	EntityID   int             `json:"entity_id"`
+	Action     string          `json:"action" enums:"create,update,delete,restore,purge"`
+	Actor      string          `json:"actor"`
+	CreatedAt  time.Time       `json:"created_at"`
+	Before     json.RawMessage `json:"before" swaggertype:"object"`
+	After      json.RawMessage `json:"after" swaggertype:"object"`
+}
+
+// AuditFilter selects audit entries, the zero values match everything.
+//
+// From is inclusive and To is exclusive.
+type AuditFilter struct {
+	EntityType string
+	EntityID   int
+	Action     string
+	Actor      string
+	From       time.Time
+	To         time.Time
+}
+
+// AuditPage is a page of audit entries, see PetPage.
+type AuditPage struct {
+	Entries    []AuditEntry
+	Limit      int
+	NextCursor int
+	Total      int
+}
+
+// Validate checks that the filter values are supported.
+func (f *AuditFilter) Validate() error {
+	if f.EntityID < 0 {
+		return fmt.Errorf("entity_id must be a positive ID")
+	}
+
+	if f.Action != "" && !isAuditAction(f.Action) {
+		return fmt.Errorf("unknown action: %s", f.Action)
+	}
+
+	if !f.From.IsZero() && !f.To.IsZero() && !f.From.Before(f.To) {
+		return fmt.Errorf("from must be before to")
+	}
+
+	return nil
+}
+
+func isAuditAction(action string) bool {
+	for _, a := range AuditActions {
+		if a == action {
+			return true
+		}
+	}
+
+	return false
+}
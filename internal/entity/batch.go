@@ -0,0 +1,64 @@
+package entity
+
+import "fmt"
+
+const (
+	BatchOpCreate = "create"
+	BatchOpUpdate = "update"
+	BatchOpDelete = "delete"
+
+	// BatchModeAtomic applies all the operations or none of them
+	BatchModeAtomic = "atomic"
+	// BatchModeBestEffort applies the operations that succeed and reports the others
+	BatchModeBestEffort = "best_effort"
+
+	MaxBatchOperations = 1000
+)
+
+// BatchOperation is one operation of a batch.
+//
+// ID is required to update and delete, Pet to create and update.
+// Version is the expected version of the pet, as in If-Match.
+type BatchOperation struct {
+	Op      string     `json:"op" enums:"create,update,delete"`
+	ID      int        `json:"id,omitempty"`
+	Version int        `json:"version,omitempty"`
+	Pet     *UpdatePet `json:"pet,omitempty"`
+}
+
+type BatchPets struct {
+	Mode       string           `json:"mode" enums:"atomic,best_effort"`
+	Operations []BatchOperation `json:"operations"`
+}
+
+// Validate checks that the batch is well formed, the pets themselves are validated when applied.
+func (b *BatchPets) Validate() error {
+	if b.Mode != "" && b.Mode != BatchModeAtomic && b.Mode != BatchModeBestEffort {
+		return fmt.Errorf("mode must be %s or %s", BatchModeAtomic, BatchModeBestEffort)
+	}
+
+	if len(b.Operations) == 0 || len(b.Operations) > MaxBatchOperations {
+		return fmt.Errorf("a batch must have between 1 and %d operations", MaxBatchOperations)
+	}
+
+	for i, operation := range b.Operations {
+		switch operation.Op {
+		case BatchOpCreate:
+			if operation.Pet == nil {
+				return fmt.Errorf("operation %d: pet is required to %s", i, operation.Op)
+			}
+		case BatchOpUpdate:
+			if operation.ID < 1 || operation.Pet == nil {
+				return fmt.Errorf("operation %d: id and pet are required to %s", i, operation.Op)
+			}
+		case BatchOpDelete:
+			if operation.ID < 1 {
+				return fmt.Errorf("operation %d: id is required to %s", i, operation.Op)
+			}
+		default:
+			return fmt.Errorf("operation %d: op must be %s, %s or %s", i, BatchOpCreate, BatchOpUpdate, BatchOpDelete)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,83 @@
+package entity
+
+import "fmt"
+
+const (
+	// MaxPageLimit is the largest page size a client can request. It is also used
+	// when no limit is given, so that clients unaware of pagination still receive
+	// the whole breed list in a single (bounded) page.
+	MaxPageLimit = 1000
+
+	SortAsc  = "asc"
+	SortDesc = "desc"
+)
+
+// PetSortFields lists the fields a list of pets can be sorted by.
+var PetSortFields = []string{
+	"id",
+	"species",
+	"pet_size",
+	"name",
+	"average_male_adult_weight",
+	"average_female_adult_weight",
+}
+
+// PageRequest describes a page of a keyset paginated list.
+//
+// Cursor is the ID of the last item of the previous page (0 for the first page).
+type PageRequest struct {
+	Limit  int
+	Cursor int
+	Sort   string
+	Order  string
+}
+
+// PetPage is a page of pets along with what is needed to fetch the next one.
+//
+// NextCursor is 0 when there is no next page.
+type PetPage struct {
+	Pets       []Pet
+	Limit      int
+	NextCursor int
+	Total      int
+}
+
+// NewPageRequest returns a PageRequest with default values.
+func NewPageRequest() *PageRequest {
+	return &PageRequest{
+		Limit: MaxPageLimit,
+		Sort:  "id",
+		Order: SortAsc,
+	}
+}
+
+// Validate checks that the page request values are supported.
+func (p *PageRequest) Validate() error {
+	if p.Limit < 1 || p.Limit > MaxPageLimit {
+		return fmt.Errorf("limit must be between 1 and %d", MaxPageLimit)
+	}
+
+	if p.Cursor < 0 {
+		return fmt.Errorf("cursor must be a positive ID")
+	}
+
+	if !isPetSortField(p.Sort) {
+		return fmt.Errorf("unknown sort field: %s", p.Sort)
+	}
+
+	if p.Order != SortAsc && p.Order != SortDesc {
+		return fmt.Errorf("order must be %s or %s", SortAsc, SortDesc)
+	}
+
+	return nil
+}
+
+func isPetSortField(field string) bool {
+	for _, f := range PetSortFields {
+		if f == field {
+			return true
+		}
+	}
+
+	return false
+}
@@ -1,5 +1,14 @@
 package entity
 
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// InitialVersion is the version of a newly created pet, each update increments it.
+const InitialVersion = 1
+
 type Pet struct {
 	ID                       int    `json:"id"`
 	Species                  string `json:"species"`
@@ -7,6 +16,12 @@ type Pet struct {
 	Name                     string `json:"name"`
 	AverageMaleAdultWeight   uint   `json:"average_male_adult_weight"`
 	AverageFemaleAdultWeight uint   `json:"average_female_adult_weight"`
+	Version                  int    `json:"version"`
+	// UpdatedAt changes along with the version
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+	// DeletedAt is only set on the pets in the trash
+	DeletedAt *time.Time `json:"deleted_at,omitempty"`
 }
 
 type CreatePet struct {
@@ -25,8 +40,102 @@ type UpdatePet struct {
 	AverageFemaleAdultWeight uint   `json:"average_female_adult_weight"`
 }
 
+// PatchPet holds the members of a JSON Merge Patch (RFC 7396) document,
+// nil fields are left unchanged.
+type PatchPet struct {
+	Species                  *string `json:"species"`
+	PetSize                  *string `json:"pet_size"`
+	Name                     *string `json:"name"`
+	AverageMaleAdultWeight   *uint   `json:"average_male_adult_weight"`
+	AverageFemaleAdultWeight *uint   `json:"average_female_adult_weight"`
+}
+
+// IsEmpty tells whether the patch changes nothing.
+func (p *PatchPet) IsEmpty() bool {
+	return p.Species == nil && p.PetSize == nil && p.Name == nil &&
+		p.AverageMaleAdultWeight == nil && p.AverageFemaleAdultWeight == nil
+}
+
+// ApplyTo sets the patched fields on pet.
+func (p *PatchPet) ApplyTo(pet *Pet) {
+	if p.Species != nil {
+		pet.Species = *p.Species
+	}
+	if p.PetSize != nil {
+		pet.PetSize = *p.PetSize
+	}
+	if p.Name != nil {
+		pet.Name = *p.Name
+	}
+	if p.AverageMaleAdultWeight != nil {
+		pet.AverageMaleAdultWeight = *p.AverageMaleAdultWeight
+	}
+	if p.AverageFemaleAdultWeight != nil {
+		pet.AverageFemaleAdultWeight = *p.AverageFemaleAdultWeight
+	}
+}
+
+// SearchPets holds the search criteria, all of them must match.
+//
+// MinWeight and MaxWeight apply to both sexes according to WeightMode:
+//   - "contains" (default): the male and female weights are both within the range
+//   - "overlaps": the range between the male and female weights overlaps the searched range
+//
+// The sex specific weights always apply to their own sex only.
 type SearchPets struct {
-	Species   string `json:"species"`
-	MinWeight uint   `json:"min_weight"`
-	MaxWeight uint   `json:"max_weight"`
+	Species         StringList `json:"species" swaggertype:"array,string"`
+	PetSize         StringList `json:"pet_size" swaggertype:"array,string"`
+	Name            string     `json:"name"`
+	NameMatch       string     `json:"name_match" enums:"contains,prefix"`
+	MinWeight       uint       `json:"min_weight"`
+	MaxWeight       uint       `json:"max_weight"`
+	WeightMode      string     `json:"weight_mode" enums:"contains,overlaps"`
+	MinMaleWeight   uint       `json:"min_male_weight"`
+	MaxMaleWeight   uint       `json:"max_male_weight"`
+	MinFemaleWeight uint       `json:"min_female_weight"`
+	MaxFemaleWeight uint       `json:"max_female_weight"`
+}
+
+const (
+	NameMatchContains = "contains"
+	NameMatchPrefix   = "prefix"
+
+	WeightModeContains = "contains"
+	WeightModeOverlaps = "overlaps"
+)
+
+// Validate checks that the search modes are supported.
+func (s *SearchPets) Validate() error {
+	if s.NameMatch != "" && s.NameMatch != NameMatchContains && s.NameMatch != NameMatchPrefix {
+		return fmt.Errorf("name_match must be %s or %s", NameMatchContains, NameMatchPrefix)
+	}
+
+	if s.WeightMode != "" && s.WeightMode != WeightModeContains && s.WeightMode != WeightModeOverlaps {
+		return fmt.Errorf("weight_mode must be %s or %s", WeightModeContains, WeightModeOverlaps)
+	}
+
+	return nil
+}
+
+// StringList is a list of strings that can also be unmarshalled from a single JSON string,
+// so that filters on one value keep their original format.
+type StringList []string
+
+func (l *StringList) UnmarshalJSON(data []byte) error {
+	var value string
+	if err := json.Unmarshal(data, &value); err == nil {
+		*l = nil
+		if value != "" {
+			*l = StringList{value}
+		}
+		return nil
+	}
+
+	var values []string
+	if err := json.Unmarshal(data, &values); err != nil {
+		return fmt.Errorf("expected a string or a list of strings")
+	}
+	*l = values
+
+	return nil
 }
@@ -0,0 +1,20 @@
+package entity
+
+// PetRecord is a pet read from a file, Line being its line number in the file.
+//
+// ID is 0 when the file has no id column.
+type PetRecord struct {
+	Line int
+	ID   int
+	Pet  CreatePet
+}
+
+// Key identifies a breed regardless of its ID.
+func (p *CreatePet) Key() string {
+	return p.Species + "/" + p.Name
+}
+
+// Key identifies a breed regardless of its ID.
+func (p *Pet) Key() string {
+	return p.Species + "/" + p.Name
+}
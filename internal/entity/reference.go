@@ -0,0 +1,29 @@
+package entity
+
+// Species is a species of pets, the average adult weights of its breeds must be
+// between MinWeight and MaxWeight grams.
+type Species struct {
+	Name      string `json:"name"`
+	MinWeight uint   `json:"min_weight"`
+	MaxWeight uint   `json:"max_weight"`
+}
+
+// PetSize is a size of pets, Position orders the sizes from the smallest.
+type PetSize struct {
+	Name     string `json:"name"`
+	Position int    `json:"position"`
+}
+
+// DefaultSpecies and DefaultPetSizes are the ones of the breeds file, created along
+// with their table.
+var (
+	DefaultSpecies = []Species{
+		{Name: "cat", MinWeight: 1000, MaxWeight: 15000},
+		{Name: "dog", MinWeight: 1000, MaxWeight: 100000},
+	}
+	DefaultPetSizes = []PetSize{
+		{Name: "small", Position: 1},
+		{Name: "medium", Position: 2},
+		{Name: "tall", Position: 3},
+	}
+)
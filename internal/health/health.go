@@ -0,0 +1,144 @@
+package health
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	StatusUp   = "up"
+	StatusDown = "down"
+)
+
+// Check is a dependency the service needs to answer, it is ready when Run returns nil.
+type Check struct {
+	Name string
+	Run  func(ctx context.Context) error
+}
+
+// CheckResult is the outcome of a check, Error is only set when down.
+type CheckResult struct {
+	Status    string  `json:"status"`
+	LatencyMs float64 `json:"latency_ms"`
+	Error     string  `json:"error,omitempty"`
+}
+
+// Report is up when every check is.
+type Report struct {
+	Status string                 `json:"status"`
+	Checks map[string]CheckResult `json:"checks"`
+}
+
+// Checker runs the checks concurrently, each one canceled after the timeout.
+type Checker struct {
+	timeout time.Duration
+	checks  []Check
+}
+
+func NewChecker(timeout time.Duration, checks ...Check) *Checker {
+	return &Checker{
+		timeout: timeout,
+		checks:  checks,
+	}
+}
+
+func (c *Checker) Check(ctx context.Context) *Report {
+	report := &Report{
+		Status: StatusUp,
+		Checks: make(map[string]CheckResult, len(c.checks)),
+	}
+
+	var mutex sync.Mutex
+	var wg sync.WaitGroup
+
+	for _, check := range c.checks {
+		wg.Add(1)
+		go func(check Check) {
+			defer wg.Done()
+			result := c.run(ctx, check)
+
+			mutex.Lock()
+			defer mutex.Unlock()
+			report.Checks[check.Name] = result
+			if result.Status == StatusDown {
+				report.Status = StatusDown
+			}
+		}(check)
+	}
+
+	wg.Wait()
+
+	return report
+}
+
+func (c *Checker) run(ctx context.Context, check Check) CheckResult {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	start := time.Now()
+	err := check.Run(ctx)
+	result := CheckResult{
+		Status:    StatusUp,
+		LatencyMs: float64(time.Since(start).Microseconds()) / 1000,
+	}
+
+	// A check ignoring its context still fails after the timeout
+	if err == nil && ctx.Err() != nil {
+		err = ctx.Err()
+	}
+	if err != nil {
+		result.Status = StatusDown
+		result.Error = err.Error()
+	}
+
+	return result
+}
+
+// Database checks that the database answers a ping.
+func Database(db *sql.DB) Check {
+	return Check{
+		Name: "database",
+		Run:  db.PingContext,
+	}
+}
+
+// Migrations checks that the database is migrated to the expected version, and
+// that no migration failed midway.
+func Migrations(version func() (int, bool, error), expected int) Check {
+	return Check{
+		Name: "migrations",
+		Run: func(ctx context.Context) error {
+			current, dirty, err := version()
+			if err != nil {
+				return err
+			}
+			if dirty {
+				return fmt.Errorf("the migration %d failed midway", current)
+			}
+			if current != expected {
+				return fmt.Errorf("the database is at version %d instead of %d", current, expected)
+			}
+
+			return nil
+		},
+	}
+}
+
+// Done checks that a task, such as loading the seed, has completed.
+func Done(name string, done *atomic.Bool) Check {
+	return Check{
+		Name: name,
+		Run: func(ctx context.Context) error {
+			if !done.Load() {
+				return errors.New("not completed yet")
+			}
+
+			return nil
+		},
+	}
+}
@@ -0,0 +1,153 @@
+package metrics
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"strconv"
+	"time"
+
+	charmLog "github.com/charmbracelet/log"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "backend_test"
+
+// scrapeTimeout bounds the queries made when the metrics are collected.
+const scrapeTimeout = 5 * time.Second
+
+// Sources gives the values read at every scrape. DBName labels the pool statistics.
+type Sources struct {
+	DB                 *sql.DB
+	DBName             string
+	MigrationVersion   func() (version int, dirty bool, err error)
+	CountPetsBySpecies func(ctx context.Context) (map[string]int, error)
+}
+
+// Metrics holds the metrics of the service in its own registry, so that they can
+// be collected in the tests without any Prometheus server.
+type Metrics struct {
+	registry *prometheus.Registry
+	requests *prometheus.CounterVec
+	duration *prometheus.HistogramVec
+}
+
+func New(sources *Sources, logger *charmLog.Logger) *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "http_requests_total",
+			Help:      "Number of HTTP requests answered, by route template and status.",
+		}, []string{"method", "route", "status"}),
+		duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "http_request_duration_seconds",
+			Help:      "Duration of the HTTP requests, by route template.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"method", "route"}),
+	}
+
+	m.registry.MustRegister(
+		m.requests,
+		m.duration,
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+	if sources.DB != nil {
+		m.registry.MustRegister(collectors.NewDBStatsCollector(sources.DB, sources.DBName))
+	}
+	if sources.MigrationVersion != nil {
+		m.registry.MustRegister(&migrationCollector{version: sources.MigrationVersion, logger: logger})
+	}
+	if sources.CountPetsBySpecies != nil {
+		m.registry.MustRegister(&petsCollector{count: sources.CountPetsBySpecies, logger: logger})
+	}
+
+	return m
+}
+
+// ObserveRequest records an answered request.
+func (m *Metrics) ObserveRequest(method string, route string, status int, duration time.Duration) {
+	m.requests.WithLabelValues(method, route, strconv.Itoa(status)).Inc()
+	m.duration.WithLabelValues(method, route).Observe(duration.Seconds())
+}
+
+// Handler serves the metrics in the Prometheus text format.
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{})
+}
+
+var (
+	migrationVersionDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "", "migration_version"),
+		"Version of the last applied migration, -1 when none.",
+		nil, nil,
+	)
+	migrationDirtyDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "", "migration_dirty"),
+		"1 when the last migration failed midway.",
+		nil, nil,
+	)
+	petsDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "", "pets"),
+		"Number of breeds, out of the trash, by species.",
+		[]string{"species"}, nil,
+	)
+)
+
+// migrationCollector reads the migration version at every scrape.
+type migrationCollector struct {
+	version func() (int, bool, error)
+	logger  *charmLog.Logger
+}
+
+func (c *migrationCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- migrationVersionDesc
+	ch <- migrationDirtyDesc
+}
+
+func (c *migrationCollector) Collect(ch chan<- prometheus.Metric) {
+	version, dirty, err := c.version()
+	if err != nil {
+		c.logger.Error("unable to read the migration version", "err", err)
+		ch <- prometheus.NewInvalidMetric(migrationVersionDesc, err)
+		return
+	}
+
+	dirtyValue := 0.0
+	if dirty {
+		dirtyValue = 1
+	}
+
+	ch <- prometheus.MustNewConstMetric(migrationVersionDesc, prometheus.GaugeValue, float64(version))
+	ch <- prometheus.MustNewConstMetric(migrationDirtyDesc, prometheus.GaugeValue, dirtyValue)
+}
+
+// petsCollector counts the pets by species at every scrape.
+type petsCollector struct {
+	count  func(ctx context.Context) (map[string]int, error)
+	logger *charmLog.Logger
+}
+
+func (c *petsCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- petsDesc
+}
+
+func (c *petsCollector) Collect(ch chan<- prometheus.Metric) {
+	ctx, cancel := context.WithTimeout(context.Background(), scrapeTimeout)
+	defer cancel()
+
+	counts, err := c.count(ctx)
+	if err != nil {
+		c.logger.Error("unable to count the pets by species", "err", err)
+		ch <- prometheus.NewInvalidMetric(petsDesc, err)
+		return
+	}
+
+	for species, count := range counts {
+		ch <- prometheus.MustNewConstMetric(petsDesc, prometheus.GaugeValue, float64(count), species)
+	}
+}
@@ -0,0 +1,308 @@
+// Package petfile reads and writes pets in the column layout of breeds.csv, as CSV or NDJSON.
+package petfile
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/japhy-tech/backend-test/internal/entity"
+)
+
+const (
+	FormatCSV    = "csv"
+	FormatNDJSON = "ndjson"
+)
+
+// Columns is the header of breeds.csv.
+var Columns = []string{
+	"id",
+	"species",
+	"pet_size",
+	"name",
+	"average_male_adult_weight",
+	"average_female_adult_weight",
+}
+
+// ContentType returns the media type of the format.
+func ContentType(format string) string {
+	if format == FormatNDJSON {
+		return "application/x-ndjson"
+	}
+
+	return "text/csv"
+}
+
+// IsFormat tells whether the format is supported.
+func IsFormat(format string) bool {
+	return format == FormatCSV || format == FormatNDJSON
+}
+
+// LineError tells why a line could not be read.
+type LineError struct {
+	Line    int
+	Field   string
+	Message string
+}
+
+func (e *LineError) Error() string {
+	if e.Field == "" {
+		return fmt.Sprintf("line %d: %s", e.Line, e.Message)
+	}
+
+	return fmt.Sprintf("line %d: %s %s", e.Line, e.Field, e.Message)
+}
+
+// ParseErrors lists the lines that could not be read.
+type ParseErrors struct {
+	Errors []*LineError
+}
+
+func (e *ParseErrors) Error() string {
+	messages := make([]string, len(e.Errors))
+	for i, lineErr := range e.Errors {
+		messages[i] = lineErr.Error()
+	}
+
+	return strings.Join(messages, "; ")
+}
+
+// Read reads every pet of the file.
+//
+// The CSV columns are mapped through the header, so their order does not matter and
+// the id column is optional. When some lines are invalid, the valid records are returned
+// along with a *ParseErrors; any other error means the file could not be read at all.
+func Read(r io.Reader, format string) ([]entity.PetRecord, error) {
+	switch format {
+	case FormatCSV:
+		return readCSV(r)
+	case FormatNDJSON:
+		return readNDJSON(r)
+	}
+
+	return nil, fmt.Errorf("unknown format: %s", format)
+}
+
+func readCSV(r io.Reader) ([]entity.PetRecord, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, fmt.Errorf("the file is empty")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	columns := make(map[string]int, len(header))
+	for i, column := range header {
+		columns[strings.ToLower(strings.TrimSpace(column))] = i
+	}
+
+	var missing []string
+	for _, column := range Columns[1:] {
+		if _, ok := columns[column]; !ok {
+			missing = append(missing, column)
+		}
+	}
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("missing columns: %s", strings.Join(missing, ", "))
+	}
+
+	var records []entity.PetRecord
+	var lineErrors []*LineError
+	for {
+		fields, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+
+		var parseErr *csv.ParseError
+		if errors.As(err, &parseErr) {
+			lineErrors = append(lineErrors, &LineError{Line: parseErr.StartLine, Message: parseErr.Err.Error()})
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		line, _ := reader.FieldPos(0)
+
+		values := make(map[string]string, len(Columns))
+		for _, column := range Columns {
+			index, ok := columns[column]
+			if ok && index < len(fields) {
+				values[column] = strings.TrimSpace(fields[index])
+			}
+		}
+
+		record, lineErr := newRecord(line, values)
+		if lineErr != nil {
+			lineErrors = append(lineErrors, lineErr)
+			continue
+		}
+		records = append(records, *record)
+	}
+
+	return records, parseErrors(lineErrors)
+}
+
+func readNDJSON(r io.Reader) ([]entity.PetRecord, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+
+	var records []entity.PetRecord
+	var lineErrors []*LineError
+	line := 0
+	for scanner.Scan() {
+		line++
+		if strings.TrimSpace(scanner.Text()) == "" {
+			continue
+		}
+
+		var object map[string]interface{}
+		err := json.Unmarshal(scanner.Bytes(), &object)
+		if err != nil {
+			lineErrors = append(lineErrors, &LineError{Line: line, Message: "is not a JSON object"})
+			continue
+		}
+
+		values := make(map[string]string, len(Columns))
+		for _, column := range Columns {
+			switch value := object[column].(type) {
+			case string:
+				values[column] = value
+			case float64:
+				values[column] = strconv.FormatFloat(value, 'f', -1, 64)
+			}
+		}
+
+		record, lineErr := newRecord(line, values)
+		if lineErr != nil {
+			lineErrors = append(lineErrors, lineErr)
+			continue
+		}
+		records = append(records, *record)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return records, parseErrors(lineErrors)
+}
+
+// newRecord converts the values of a line, only their format is checked.
+func newRecord(line int, values map[string]string) (*entity.PetRecord, *LineError) {
+	record := &entity.PetRecord{
+		Line: line,
+		Pet: entity.CreatePet{
+			Species: values["species"],
+			PetSize: values["pet_size"],
+			Name:    values["name"],
+		},
+	}
+
+	if values["id"] != "" {
+		id, err := strconv.Atoi(values["id"])
+		if err != nil || id < 1 {
+			return nil, &LineError{Line: line, Field: "id", Message: "must be a positive integer"}
+		}
+		record.ID = id
+	}
+
+	weights := []struct {
+		column string
+		weight *uint
+	}{
+		{"average_male_adult_weight", &record.Pet.AverageMaleAdultWeight},
+		{"average_female_adult_weight", &record.Pet.AverageFemaleAdultWeight},
+	}
+	for _, w := range weights {
+		weight, err := strconv.ParseUint(values[w.column], 10, 32)
+		if err != nil {
+			return nil, &LineError{Line: line, Field: w.column, Message: "must be a positive integer"}
+		}
+		*w.weight = uint(weight)
+	}
+
+	return record, nil
+}
+
+func parseErrors(lineErrors []*LineError) error {
+	if len(lineErrors) == 0 {
+		return nil
+	}
+
+	sort.SliceStable(lineErrors, func(i, j int) bool {
+		return lineErrors[i].Line < lineErrors[j].Line
+	})
+
+	return &ParseErrors{Errors: lineErrors}
+}
+
+// Writer writes pets in the layout of breeds.csv.
+type Writer struct {
+	format string
+	csv    *csv.Writer
+	json   *json.Encoder
+}
+
+// NewWriter returns a writer of the format, the CSV header is written right away.
+func NewWriter(w io.Writer, format string) (*Writer, error) {
+	writer := &Writer{format: format}
+
+	switch format {
+	case FormatCSV:
+		writer.csv = csv.NewWriter(w)
+		err := writer.csv.Write(Columns)
+		if err != nil {
+			return nil, err
+		}
+	case FormatNDJSON:
+		writer.json = json.NewEncoder(w)
+	default:
+		return nil, fmt.Errorf("unknown format: %s", format)
+	}
+
+	return writer, nil
+}
+
+func (w *Writer) Write(pet *entity.Pet) error {
+	if w.json != nil {
+		return w.json.Encode(map[string]interface{}{
+			"id":                          pet.ID,
+			"species":                     pet.Species,
+			"pet_size":                    pet.PetSize,
+			"name":                        pet.Name,
+			"average_male_adult_weight":   pet.AverageMaleAdultWeight,
+			"average_female_adult_weight": pet.AverageFemaleAdultWeight,
+		})
+	}
+
+	return w.csv.Write([]string{
+		strconv.Itoa(pet.ID),
+		pet.Species,
+		pet.PetSize,
+		pet.Name,
+		strconv.FormatUint(uint64(pet.AverageMaleAdultWeight), 10),
+		strconv.FormatUint(uint64(pet.AverageFemaleAdultWeight), 10),
+	})
+}
+
+// Flush writes any buffered data.
+func (w *Writer) Flush() error {
+	if w.csv != nil {
+		w.csv.Flush()
+		return w.csv.Error()
+	}
+
+	return nil
+}
@@ -0,0 +1,195 @@
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/japhy-tech/backend-test/internal/config"
+)
+
+// Result is the state of a bucket once a request has been taken, or refused.
+type Result struct {
+	Allowed bool
+	// Limit is the capacity of the bucket
+	Limit     int
+	Remaining int
+	// RetryAfter is the wait before the next request is allowed, 0 when allowed
+	RetryAfter time.Duration
+	// Reset is the wait before the bucket is full again
+	Reset time.Duration
+}
+
+// Store keeps the token buckets of the clients. The in-process MemoryStore
+// suits a single instance, a shared store lets several instances share the budgets.
+type Store interface {
+	// Take takes a token from the bucket of the key, refilled at the rate of the budget.
+	Take(ctx context.Context, key string, budget config.RateBudget) (Result, error)
+	// Peek returns the state of the bucket of the key as Take would, without taking a token.
+	Peek(ctx context.Context, key string, budget config.RateBudget) (Result, error)
+}
+
+// unauthenticatedRoute stands for the route in the keys of the buckets of the failed
+// authentications, which are shared by every route.
+const unauthenticatedRoute = "unauthenticated"
+
+// Limiter finds the budget of the requests and takes their token from the store.
+type Limiter struct {
+	store                 Store
+	defaultBudget         config.RateBudget
+	unauthenticatedBudget config.RateBudget
+	// routeBudgets are indexed by method and route template, e.g. POST /v1/pets/search
+	routeBudgets map[string]config.RateBudget
+}
+
+func NewLimiter(store Store, cfg *config.RateLimitConfig) *Limiter {
+	l := &Limiter{
+		store:                 store,
+		defaultBudget:         cfg.Default,
+		unauthenticatedBudget: cfg.Unauthenticated,
+		routeBudgets:          make(map[string]config.RateBudget, len(cfg.Routes)),
+	}
+
+	for _, route := range cfg.Routes {
+		l.routeBudgets[route.Method+" "+route.Route] = route.RateBudget
+	}
+
+	return l
+}
+
+// Allow takes a token from the bucket of the client for the route.
+func (l *Limiter) Allow(ctx context.Context, client string, method string, route string) (Result, error) {
+	key := method + " " + route
+
+	budget, ok := l.routeBudgets[key]
+	if !ok {
+		budget = l.defaultBudget
+	}
+
+	return l.store.Take(ctx, client+" "+key, budget)
+}
+
+// CheckUnauthenticated tells whether the client may fail its authentication again,
+// without taking a token from its budget of failed authentications.
+func (l *Limiter) CheckUnauthenticated(ctx context.Context, client string) (Result, error) {
+	return l.store.Peek(ctx, client+" "+unauthenticatedRoute, l.unauthenticatedBudget)
+}
+
+// TakeUnauthenticated takes a token from the budget of failed authentications of the client.
+func (l *Limiter) TakeUnauthenticated(ctx context.Context, client string) (Result, error) {
+	return l.store.Take(ctx, client+" "+unauthenticatedRoute, l.unauthenticatedBudget)
+}
+
+// sweepInterval is how often the full buckets are dropped from the memory.
+const sweepInterval = time.Minute
+
+type bucket struct {
+	tokens   float64
+	capacity float64
+	// rate is the number of tokens added per second
+	rate    float64
+	updated time.Time
+}
+
+// refill adds the tokens earned since the last update.
+func (b *bucket) refill(now time.Time) {
+	b.tokens = math.Min(b.capacity, b.tokens+now.Sub(b.updated).Seconds()*b.rate)
+	b.updated = now
+}
+
+// MemoryStore keeps the buckets in memory, the ones full again are dropped since
+// they are the same as new ones.
+type MemoryStore struct {
+	mutex     sync.Mutex
+	buckets   map[string]*bucket
+	lastSweep time.Time
+	now       func() time.Time
+}
+
+func NewMemoryStore() *MemoryStore {
+	return NewMemoryStoreWithClock(time.Now)
+}
+
+// NewMemoryStoreWithClock returns a store reading the time from now, for the tests.
+func NewMemoryStoreWithClock(now func() time.Time) *MemoryStore {
+	return &MemoryStore{
+		buckets:   make(map[string]*bucket),
+		lastSweep: now(),
+		now:       now,
+	}
+}
+
+func (s *MemoryStore) Take(ctx context.Context, key string, budget config.RateBudget) (Result, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	now := s.now()
+	s.sweep(now)
+
+	b, ok := s.buckets[key]
+	if !ok {
+		b = &bucket{tokens: float64(budget.Requests), updated: now}
+		s.buckets[key] = b
+	}
+
+	return b.take(now, budget, true), nil
+}
+
+func (s *MemoryStore) Peek(ctx context.Context, key string, budget config.RateBudget) (Result, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	now := s.now()
+
+	// A missing bucket is full, the copy leaves the stored one as is
+	b := bucket{tokens: float64(budget.Requests), updated: now}
+	if stored, ok := s.buckets[key]; ok {
+		b = *stored
+	}
+
+	return b.take(now, budget, false), nil
+}
+
+// take refills the bucket at the rate of the budget and takes a token if there is
+// one and taking it is asked.
+func (b *bucket) take(now time.Time, budget config.RateBudget, taking bool) Result {
+	// The budget may have changed since the bucket was created
+	b.capacity = float64(budget.Requests)
+	b.rate = b.capacity / budget.Period.Seconds()
+	b.refill(now)
+
+	result := Result{Limit: budget.Requests}
+	if b.tokens >= 1 {
+		if taking {
+			b.tokens--
+		}
+		result.Allowed = true
+	} else {
+		result.RetryAfter = secondsDuration((1 - b.tokens) / b.rate)
+	}
+
+	result.Remaining = int(b.tokens)
+	result.Reset = secondsDuration((b.capacity - b.tokens) / b.rate)
+
+	return result
+}
+
+// sweep drops the buckets full again, at most once per sweepInterval.
+func (s *MemoryStore) sweep(now time.Time) {
+	if now.Sub(s.lastSweep) < sweepInterval {
+		return
+	}
+	s.lastSweep = now
+
+	for key, b := range s.buckets {
+		b.refill(now)
+		if b.tokens >= b.capacity {
+			delete(s.buckets, key)
+		}
+	}
+}
+
+func secondsDuration(seconds float64) time.Duration {
+	return time.Duration(seconds * float64(time.Second))
+}
@@ -0,0 +1,134 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/japhy-tech/backend-test/internal/entity"
+)
+
+// auditColumns are scanned by scanAuditEntry.
+const auditColumns = "id, entity_type, entity_id, action, actor, created_at, before_state, after_state"
+
+// InsertAuditEntry records the entry, within the transaction of the change it describes.
+func (r *petRepository) InsertAuditEntry(ctx context.Context, entry *entity.AuditEntry) error {
+	ctx, cancel := r.queryContext(ctx)
+	defer cancel()
+
+	_, err := r.DB.ExecContext(ctx, `
+		INSERT INTO audit_log (entity_type, entity_id, action, actor, created_at, before_state, after_state)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+	`, entry.EntityType, entry.EntityID, entry.Action, entry.Actor, entry.CreatedAt.UTC(), nullJSON(entry.Before), nullJSON(entry.After))
+	if err != nil {
+		return translateError(ctx, err)
+	}
+
+	return nil
+}
+
+// GetAuditEntries returns the entries matching the filter, ordered by ID.
+// The audit log can only be sorted by ID, the sort field of the page is ignored.
+func (r *petRepository) GetAuditEntries(ctx context.Context, filter *entity.AuditFilter, page *entity.PageRequest) ([]entity.AuditEntry, error) {
+	ctx, cancel := r.queryContext(ctx)
+	defer cancel()
+
+	where := auditConditions(filter)
+
+	comparator, direction := ">", "ASC"
+	if page.Order == entity.SortDesc {
+		comparator, direction = "<", "DESC"
+	}
+	if page.Cursor > 0 {
+		where.add("id "+comparator+" ?", page.Cursor)
+	}
+
+	query := "SELECT " + auditColumns + " FROM audit_log" + where.String() + " ORDER BY id " + direction + " LIMIT ?"
+	args := append(where.args, page.Limit)
+
+	rows, err := r.DB.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, translateError(ctx, err)
+	}
+	defer rows.Close()
+
+	var entries []entity.AuditEntry
+	for rows.Next() {
+		entry, err := scanAuditEntry(rows)
+		if err != nil {
+			return nil, translateError(ctx, err)
+		}
+		entries = append(entries, *entry)
+	}
+
+	return entries, translateError(ctx, rows.Err())
+}
+
+func (r *petRepository) CountAuditEntries(ctx context.Context, filter *entity.AuditFilter) (int, error) {
+	ctx, cancel := r.queryContext(ctx)
+	defer cancel()
+
+	var count int
+	where := auditConditions(filter)
+
+	err := r.DB.QueryRowContext(ctx, "SELECT COUNT(*) FROM audit_log"+where.String(), where.args...).Scan(&count)
+	if err != nil {
+		return 0, translateError(ctx, err)
+	}
+
+	return count, nil
+}
+
+// auditConditions returns the conditions matching the filter.
+func auditConditions(filter *entity.AuditFilter) *whereClause {
+	where := &whereClause{}
+
+	if filter.EntityType != "" {
+		where.add("entity_type = ?", filter.EntityType)
+	}
+	if filter.EntityID > 0 {
+		where.add("entity_id = ?", filter.EntityID)
+	}
+	if filter.Action != "" {
+		where.add("action = ?", filter.Action)
+	}
+	if filter.Actor != "" {
+		where.add("actor = ?", filter.Actor)
+	}
+	if !filter.From.IsZero() {
+		where.add("created_at >= ?", filter.From.UTC())
+	}
+	if !filter.To.IsZero() {
+		where.add("created_at < ?", filter.To.UTC())
+	}
+
+	return where
+}
+
+// scanAuditEntry scans the auditColumns of a row.
+func scanAuditEntry(row interface{ Scan(dest ...interface{}) error }) (*entity.AuditEntry, error) {
+	var entry entity.AuditEntry
+	var before, after []byte
+
+	err := row.Scan(&entry.ID, &entry.EntityType, &entry.EntityID, &entry.Action, &entry.Actor, &entry.CreatedAt, &before, &after)
+	if err != nil {
+		return nil, err
+	}
+
+	if before != nil {
+		entry.Before = before
+	}
+	if after != nil {
+		entry.After = after
+	}
+
+	return &entry, nil
+}
+
+// nullJSON stores an absent state as NULL rather than as a JSON null.
+func nullJSON(state []byte) interface{} {
+	if state == nil {
+		return sql.NullString{}
+	}
+
+	return string(state)
+}
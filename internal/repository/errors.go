@@ -0,0 +1,100 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"net"
+
+	"github.com/go-sql-driver/mysql"
+	"modernc.org/sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
+)
+
+var (
+	ErrNotFound    = errors.New("record not found")
+	ErrDuplicate   = errors.New("duplicate record")
+	ErrConstraint  = errors.New("record violates a constraint")
+	ErrInUse       = errors.New("record in use")
+	ErrUnavailable = errors.New("database unavailable")
+	ErrTimeout     = errors.New("query timed out")
+)
+
+// MySQL server error numbers, see https://dev.mysql.com/doc/mysql-errors/8.0/en/server-error-reference.html
+const (
+	mysqlErrTooManyConnections      = 1040
+	mysqlErrBadNull                 = 1048
+	mysqlErrDuplicateEntry          = 1062
+	mysqlErrLockWaitTimeout         = 1205
+	mysqlErrDeadlock                = 1213
+	mysqlErrOutOfRange              = 1264
+	mysqlErrDataTooLong             = 1406
+	mysqlErrRowIsReferenced         = 1451
+	mysqlErrNoReferencedRow         = 1452
+	mysqlErrQueryTimeout            = 3024
+	mysqlErrCheckConstraintViolated = 3819
+)
+
+// translateError wraps the database errors into the repository errors, so that the
+// callers don't depend on the driver. The original error is kept in the chain.
+//
+// When ctx is done, the error of the context is used since the drivers don't all return it.
+func translateError(ctx context.Context, err error) error {
+	if err == nil {
+		return nil
+	}
+
+	if ctxErr := ctx.Err(); ctxErr != nil && !errors.Is(err, ctxErr) {
+		err = fmt.Errorf("%w: %w", ctxErr, err)
+	}
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("%w: %w", ErrNotFound, err)
+	}
+
+	// Checked before the net.Error below, which context.DeadlineExceeded implements
+	if errors.Is(err, context.DeadlineExceeded) {
+		return fmt.Errorf("%w: %w", ErrTimeout, err)
+	}
+
+	var mysqlErr *mysql.MySQLError
+	if errors.As(err, &mysqlErr) {
+		switch mysqlErr.Number {
+		case mysqlErrDuplicateEntry:
+			return fmt.Errorf("%w: %w", ErrDuplicate, err)
+		case mysqlErrBadNull, mysqlErrOutOfRange, mysqlErrDataTooLong, mysqlErrCheckConstraintViolated, mysqlErrNoReferencedRow:
+			return fmt.Errorf("%w: %w", ErrConstraint, err)
+		case mysqlErrRowIsReferenced:
+			return fmt.Errorf("%w: %w", ErrInUse, err)
+		case mysqlErrTooManyConnections, mysqlErrLockWaitTimeout, mysqlErrDeadlock:
+			return fmt.Errorf("%w: %w", ErrUnavailable, err)
+		case mysqlErrQueryTimeout:
+			return fmt.Errorf("%w: %w", ErrTimeout, err)
+		}
+	}
+
+	var sqliteErr *sqlite.Error
+	if errors.As(err, &sqliteErr) {
+		switch sqliteErr.Code() {
+		case sqlite3.SQLITE_CONSTRAINT_UNIQUE, sqlite3.SQLITE_CONSTRAINT_PRIMARYKEY:
+			return fmt.Errorf("%w: %w", ErrDuplicate, err)
+		case sqlite3.SQLITE_CONSTRAINT_CHECK, sqlite3.SQLITE_CONSTRAINT_NOTNULL, sqlite3.SQLITE_CONSTRAINT_FOREIGNKEY:
+			// SQLite doesn't tell a missing reference from a reference in use, see deleteReference
+			return fmt.Errorf("%w: %w", ErrConstraint, err)
+		}
+		// The extended codes of the busy and locked errors keep their primary code in the low byte
+		switch sqliteErr.Code() & 0xff {
+		case sqlite3.SQLITE_BUSY, sqlite3.SQLITE_LOCKED:
+			return fmt.Errorf("%w: %w", ErrUnavailable, err)
+		}
+	}
+
+	var netErr net.Error
+	if errors.Is(err, driver.ErrBadConn) || errors.Is(err, mysql.ErrInvalidConn) || errors.Is(err, sql.ErrConnDone) || errors.As(err, &netErr) {
+		return fmt.Errorf("%w: %w", ErrUnavailable, err)
+	}
+
+	return err
+}
@@ -0,0 +1,708 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/japhy-tech/backend-test/internal/entity"
+)
+
+// memoryData holds the pets, their species and sizes and the audit log of the memory repository.
+type memoryData struct {
+	pets        map[int]*entity.Pet
+	lastPetID   int
+	species     map[string]entity.Species
+	sizes       map[string]entity.PetSize
+	audit       []entity.AuditEntry
+	lastAuditID int
+}
+
+// newMemoryData returns the data of an empty repository, with the default species and sizes.
+func newMemoryData() *memoryData {
+	d := &memoryData{
+		pets:    map[int]*entity.Pet{},
+		species: make(map[string]entity.Species, len(entity.DefaultSpecies)),
+		sizes:   make(map[string]entity.PetSize, len(entity.DefaultPetSizes)),
+	}
+
+	for _, species := range entity.DefaultSpecies {
+		d.species[species.Name] = species
+	}
+	for _, size := range entity.DefaultPetSizes {
+		d.sizes[size.Name] = size
+	}
+
+	return d
+}
+
+// clone returns a copy of the data that can be changed without changing d.
+func (d *memoryData) clone() *memoryData {
+	c := &memoryData{
+		pets:        make(map[int]*entity.Pet, len(d.pets)),
+		lastPetID:   d.lastPetID,
+		species:     make(map[string]entity.Species, len(d.species)),
+		sizes:       make(map[string]entity.PetSize, len(d.sizes)),
+		audit:       append([]entity.AuditEntry(nil), d.audit...),
+		lastAuditID: d.lastAuditID,
+	}
+
+	for id, pet := range d.pets {
+		c.pets[id] = copyPet(pet)
+	}
+	for name, species := range d.species {
+		c.species[name] = species
+	}
+	for name, size := range d.sizes {
+		c.sizes[name] = size
+	}
+
+	return c
+}
+
+// memoryDB is the store shared by the repository and its transactions.
+type memoryDB struct {
+	mutex sync.RWMutex
+	data  *memoryData
+}
+
+// memoryPetRepository stores the pets in memory, with the semantics of the SQL repository.
+type memoryPetRepository struct {
+	db *memoryDB
+	// tx is the data of the ongoing transaction, nil outside of a transaction
+	tx *memoryData
+}
+
+// NewMemoryPetRepository returns an empty repository keeping the pets in memory,
+// along with the default species and sizes.
+//
+// The pets are lost when the process stops, the repository suits the local runs and the tests.
+func NewMemoryPetRepository() PetRepository {
+	return &memoryPetRepository{db: &memoryDB{data: newMemoryData()}}
+}
+
+// WithTransaction runs fn on a copy of the data, which replaces the data once fn
+// succeeds. The transactions run one at a time.
+func (r *memoryPetRepository) WithTransaction(ctx context.Context, fn func(repo PetRepository) error) error {
+	if r.tx != nil {
+		return fn(r)
+	}
+
+	r.db.mutex.Lock()
+	defer r.db.mutex.Unlock()
+
+	if err := ctx.Err(); err != nil {
+		return translateError(ctx, err)
+	}
+
+	tx := r.db.data.clone()
+
+	err := fn(&memoryPetRepository{db: r.db, tx: tx})
+	if err != nil {
+		return err
+	}
+
+	// The transaction is rolled back if ctx is done before the commit
+	if err := ctx.Err(); err != nil {
+		return translateError(ctx, err)
+	}
+	r.db.data = tx
+
+	return nil
+}
+
+// WithSavepoint runs fn on a copy of the data of the transaction, which replaces them
+// once fn succeeds.
+func (r *memoryPetRepository) WithSavepoint(ctx context.Context, fn func(repo PetRepository) error) error {
+	if r.tx == nil {
+		return r.WithTransaction(ctx, fn)
+	}
+
+	savepoint := r.tx.clone()
+
+	err := fn(&memoryPetRepository{db: r.db, tx: savepoint})
+	if err != nil {
+		return err
+	}
+	*r.tx = *savepoint
+
+	return nil
+}
+
+// read runs fn on the data, along with the other reads.
+func (r *memoryPetRepository) read(ctx context.Context, fn func(data *memoryData)) error {
+	if err := ctx.Err(); err != nil {
+		return translateError(ctx, err)
+	}
+
+	if r.tx != nil {
+		fn(r.tx)
+		return nil
+	}
+
+	r.db.mutex.RLock()
+	defer r.db.mutex.RUnlock()
+	fn(r.db.data)
+
+	return nil
+}
+
+// write runs fn on the data, alone.
+func (r *memoryPetRepository) write(ctx context.Context, fn func(data *memoryData)) error {
+	if err := ctx.Err(); err != nil {
+		return translateError(ctx, err)
+	}
+
+	if r.tx != nil {
+		fn(r.tx)
+		return nil
+	}
+
+	r.db.mutex.Lock()
+	defer r.db.mutex.Unlock()
+	fn(r.db.data)
+
+	return nil
+}
+
+func (r *memoryPetRepository) Create(ctx context.Context, pet *entity.CreatePet) (int, error) {
+	var id int
+	var checkErr error
+
+	err := r.write(ctx, func(data *memoryData) {
+		checkErr = data.checkPet(0, pet.Species, pet.PetSize, pet.Name)
+		if checkErr != nil {
+			return
+		}
+
+		data.lastPetID++
+		id = data.lastPetID
+		data.pets[id] = newPet(id, pet, now())
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return id, checkErr
+}
+
+func (r *memoryPetRepository) GetAll(ctx context.Context, page *entity.PageRequest) ([]entity.Pet, error) {
+	var pets []entity.Pet
+
+	err := r.read(ctx, func(data *memoryData) {
+		pets = data.page(isNotDeleted, page)
+	})
+
+	return pets, err
+}
+
+func (r *memoryPetRepository) Count(ctx context.Context) (int, error) {
+	var count int
+
+	err := r.read(ctx, func(data *memoryData) {
+		count = data.count(isNotDeleted)
+	})
+
+	return count, err
+}
+
+func (r *memoryPetRepository) CountBySpecies(ctx context.Context) (map[string]int, error) {
+	counts := map[string]int{}
+
+	err := r.read(ctx, func(data *memoryData) {
+		for _, pet := range data.pets {
+			if isNotDeleted(pet) {
+				counts[pet.Species]++
+			}
+		}
+	})
+
+	return counts, err
+}
+
+func (r *memoryPetRepository) GetByID(ctx context.Context, id int) (*entity.Pet, error) {
+	var found *entity.Pet
+
+	err := r.read(ctx, func(data *memoryData) {
+		pet, ok := data.pets[id]
+		if ok && isNotDeleted(pet) {
+			found = copyPet(pet)
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if found == nil {
+		return nil, fmt.Errorf("%w: no pet %d", ErrNotFound, id)
+	}
+
+	return found, nil
+}
+
+func (r *memoryPetRepository) Update(ctx context.Context, id int, pet *entity.UpdatePet, version int) (int, error) {
+	affected := 0
+	var checkErr error
+
+	err := r.write(ctx, func(data *memoryData) {
+		stored := data.versioned(id, version)
+		if stored == nil {
+			return
+		}
+
+		checkErr = data.checkPet(id, pet.Species, pet.PetSize, pet.Name)
+		if checkErr != nil {
+			return
+		}
+
+		stored.Species = pet.Species
+		stored.PetSize = pet.PetSize
+		stored.Name = pet.Name
+		stored.AverageMaleAdultWeight = pet.AverageMaleAdultWeight
+		stored.AverageFemaleAdultWeight = pet.AverageFemaleAdultWeight
+		stored.Version++
+		stored.UpdatedAt = now()
+		affected = 1
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return affected, checkErr
+}
+
+// Patch only updates the fields set in the patch.
+func (r *memoryPetRepository) Patch(ctx context.Context, id int, pet *entity.PatchPet, version int) (int, error) {
+	if pet.IsEmpty() {
+		return 0, nil
+	}
+
+	affected := 0
+	var checkErr error
+
+	err := r.write(ctx, func(data *memoryData) {
+		stored := data.versioned(id, version)
+		if stored == nil {
+			return
+		}
+
+		patched := *stored
+		pet.ApplyTo(&patched)
+		checkErr = data.checkPet(id, patched.Species, patched.PetSize, patched.Name)
+		if checkErr != nil {
+			return
+		}
+
+		*stored = patched
+		stored.Version++
+		stored.UpdatedAt = now()
+		affected = 1
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return affected, checkErr
+}
+
+// Delete moves the pet to the trash.
+func (r *memoryPetRepository) Delete(ctx context.Context, id int, version int) (int, error) {
+	affected := 0
+
+	err := r.write(ctx, func(data *memoryData) {
+		stored := data.versioned(id, version)
+		if stored == nil {
+			return
+		}
+
+		deletedAt := now()
+		stored.DeletedAt = &deletedAt
+		stored.Version++
+		stored.UpdatedAt = deletedAt
+		affected = 1
+	})
+
+	return affected, err
+}
+
+func (r *memoryPetRepository) SearchPets(ctx context.Context, searchPets *entity.SearchPets, page *entity.PageRequest) ([]entity.Pet, error) {
+	var pets []entity.Pet
+
+	err := r.read(ctx, func(data *memoryData) {
+		pets = data.page(searchMatcher(searchPets), page)
+	})
+
+	return pets, err
+}
+
+func (r *memoryPetRepository) CountSearchPets(ctx context.Context, searchPets *entity.SearchPets) (int, error) {
+	var count int
+
+	err := r.read(ctx, func(data *memoryData) {
+		count = data.count(searchMatcher(searchPets))
+	})
+
+	return count, err
+}
+
+func (r *memoryPetRepository) GetDeleted(ctx context.Context, page *entity.PageRequest) ([]entity.Pet, error) {
+	var pets []entity.Pet
+
+	err := r.read(ctx, func(data *memoryData) {
+		pets = data.page(isDeleted, page)
+	})
+
+	return pets, err
+}
+
+func (r *memoryPetRepository) CountDeleted(ctx context.Context) (int, error) {
+	var count int
+
+	err := r.read(ctx, func(data *memoryData) {
+		count = data.count(isDeleted)
+	})
+
+	return count, err
+}
+
+// Restore takes the pet out of the trash.
+func (r *memoryPetRepository) Restore(ctx context.Context, id int) (int, error) {
+	affected := 0
+	var duplicateErr error
+
+	err := r.write(ctx, func(data *memoryData) {
+		pet, ok := data.pets[id]
+		if !ok || !isDeleted(pet) {
+			return
+		}
+
+		duplicateErr = data.checkUnique(id, pet.Species, pet.Name)
+		if duplicateErr != nil {
+			return
+		}
+
+		pet.DeletedAt = nil
+		pet.Version++
+		pet.UpdatedAt = now()
+		affected = 1
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return affected, duplicateErr
+}
+
+// Purge permanently deletes the pets moved to the trash before the given time.
+func (r *memoryPetRepository) Purge(ctx context.Context, deletedBefore time.Time) (int, error) {
+	affected := 0
+
+	err := r.write(ctx, func(data *memoryData) {
+		for id, pet := range data.pets {
+			if isDeleted(pet) && pet.DeletedAt.Before(deletedBefore) {
+				delete(data.pets, id)
+				affected++
+			}
+		}
+	})
+
+	return affected, err
+}
+
+// InsertAuditEntry records the entry, within the transaction of the change it describes.
+func (r *memoryPetRepository) InsertAuditEntry(ctx context.Context, entry *entity.AuditEntry) error {
+	return r.write(ctx, func(data *memoryData) {
+		data.lastAuditID++
+
+		stored := *entry
+		stored.ID = data.lastAuditID
+		stored.CreatedAt = entry.CreatedAt.UTC()
+		data.audit = append(data.audit, stored)
+	})
+}
+
+// GetAuditEntries returns the entries matching the filter, ordered by ID.
+// The audit log can only be sorted by ID, the sort field of the page is ignored.
+func (r *memoryPetRepository) GetAuditEntries(ctx context.Context, filter *entity.AuditFilter, page *entity.PageRequest) ([]entity.AuditEntry, error) {
+	var entries []entity.AuditEntry
+
+	err := r.read(ctx, func(data *memoryData) {
+		descending := page.Order == entity.SortDesc
+
+		// The entries are appended by increasing ID
+		for i := range data.audit {
+			entry := data.audit[i]
+			if descending {
+				entry = data.audit[len(data.audit)-1-i]
+			}
+
+			if page.Cursor > 0 && (descending && entry.ID >= page.Cursor || !descending && entry.ID <= page.Cursor) {
+				continue
+			}
+			if !auditMatches(&entry, filter) {
+				continue
+			}
+
+			entries = append(entries, entry)
+			if len(entries) == page.Limit {
+				break
+			}
+		}
+	})
+
+	return entries, err
+}
+
+func (r *memoryPetRepository) CountAuditEntries(ctx context.Context, filter *entity.AuditFilter) (int, error) {
+	var count int
+
+	err := r.read(ctx, func(data *memoryData) {
+		for i := range data.audit {
+			if auditMatches(&data.audit[i], filter) {
+				count++
+			}
+		}
+	})
+
+	return count, err
+}
+
+// versioned returns the pet if it has the given version, any version matches when 0.
+// Pets in the trash never match, see versionConditions.
+func (d *memoryData) versioned(id int, version int) *entity.Pet {
+	pet, ok := d.pets[id]
+	if !ok || isDeleted(pet) || version > 0 && pet.Version != version {
+		return nil
+	}
+
+	return pet
+}
+
+// checkPet returns ErrConstraint when the species or the size isn't stored, like the
+// foreign keys of the pets table, then checks that the pet is unique.
+func (d *memoryData) checkPet(id int, species string, petSize string, name string) error {
+	if _, ok := d.species[species]; !ok {
+		return fmt.Errorf("%w: no species %q", ErrConstraint, species)
+	}
+	if _, ok := d.sizes[petSize]; !ok {
+		return fmt.Errorf("%w: no pet size %q", ErrConstraint, petSize)
+	}
+
+	return d.checkUnique(id, species, name)
+}
+
+// checkUnique returns ErrDuplicate when a pet out of the trash other than the one of id
+// has the species and the name, like the unique key of the pets table.
+func (d *memoryData) checkUnique(id int, species string, name string) error {
+	for _, pet := range d.pets {
+		if pet.ID != id && isNotDeleted(pet) && pet.Species == species && pet.Name == name {
+			return fmt.Errorf("%w: the %s %s is the pet %d", ErrDuplicate, species, name, pet.ID)
+		}
+	}
+
+	return nil
+}
+
+func (d *memoryData) count(matches func(pet *entity.Pet) bool) int {
+	count := 0
+	for _, pet := range d.pets {
+		if matches(pet) {
+			count++
+		}
+	}
+
+	return count
+}
+
+// page returns the page of the matching pets, see paginate for the ordering and the cursor.
+func (d *memoryData) page(matches func(pet *entity.Pet) bool, page *entity.PageRequest) []entity.Pet {
+	compare := petComparator(page.Sort)
+	descending := page.Order == entity.SortDesc
+
+	// As with the SQL subquery, a cursor whose pet no longer exists matches no pet,
+	// unless the pets are sorted by ID only
+	var cursor *entity.Pet
+	if page.Cursor > 0 {
+		cursor = &entity.Pet{ID: page.Cursor}
+		if column := sortColumns[page.Sort]; column != "" && column != "id" {
+			pet, ok := d.pets[page.Cursor]
+			if !ok {
+				return nil
+			}
+			cursor = pet
+		}
+	}
+
+	var pets []entity.Pet
+	for _, pet := range d.pets {
+		if !matches(pet) {
+			continue
+		}
+		if cursor != nil {
+			order := compare(pet, cursor)
+			if descending && order >= 0 || !descending && order <= 0 {
+				continue
+			}
+		}
+
+		pets = append(pets, *copyPet(pet))
+	}
+
+	sort.Slice(pets, func(i, j int) bool {
+		order := compare(&pets[i], &pets[j])
+		if descending {
+			return order > 0
+		}
+		return order < 0
+	})
+
+	if len(pets) > page.Limit {
+		pets = pets[:page.Limit]
+	}
+
+	return pets
+}
+
+// petComparator orders the pets by the sort field then by ID, unknown sort fields
+// falling back to the ID.
+func petComparator(sortField string) func(a, b *entity.Pet) int {
+	return func(a, b *entity.Pet) int {
+		order := 0
+
+		switch sortColumns[sortField] {
+		case "species":
+			order = strings.Compare(a.Species, b.Species)
+		case "pet_size":
+			order = strings.Compare(a.PetSize, b.PetSize)
+		case "name":
+			order = strings.Compare(a.Name, b.Name)
+		case "average_male_adult_weight":
+			order = compareWeights(a.AverageMaleAdultWeight, b.AverageMaleAdultWeight)
+		case "average_female_adult_weight":
+			order = compareWeights(a.AverageFemaleAdultWeight, b.AverageFemaleAdultWeight)
+		}
+
+		if order != 0 {
+			return order
+		}
+
+		return a.ID - b.ID
+	}
+}
+
+func compareWeights(a, b uint) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+
+	return 0
+}
+
+func isDeleted(pet *entity.Pet) bool {
+	return pet.DeletedAt != nil
+}
+
+func isNotDeleted(pet *entity.Pet) bool {
+	return pet.DeletedAt == nil
+}
+
+// searchMatcher matches the pets of the search criteria, see searchConditions.
+func searchMatcher(searchPets *entity.SearchPets) func(pet *entity.Pet) bool {
+	name := strings.ToLower(searchPets.Name)
+
+	return func(pet *entity.Pet) bool {
+		if isDeleted(pet) {
+			return false
+		}
+
+		if len(searchPets.Species) > 0 && !contains(searchPets.Species, pet.Species) {
+			return false
+		}
+		if len(searchPets.PetSize) > 0 && !contains(searchPets.PetSize, pet.PetSize) {
+			return false
+		}
+
+		if name != "" {
+			petName := strings.ToLower(pet.Name)
+			if searchPets.NameMatch == entity.NameMatchPrefix && !strings.HasPrefix(petName, name) ||
+				searchPets.NameMatch != entity.NameMatchPrefix && !strings.Contains(petName, name) {
+				return false
+			}
+		}
+
+		male, female := pet.AverageMaleAdultWeight, pet.AverageFemaleAdultWeight
+		if searchPets.WeightMode == entity.WeightModeOverlaps {
+			// The range between the male and female weights must overlap the searched range
+			if searchPets.MinWeight > 0 && male < searchPets.MinWeight && female < searchPets.MinWeight {
+				return false
+			}
+			if searchPets.MaxWeight > 0 && male > searchPets.MaxWeight && female > searchPets.MaxWeight {
+				return false
+			}
+		} else {
+			// Both weights must be within the searched range
+			if searchPets.MinWeight > 0 && (male < searchPets.MinWeight || female < searchPets.MinWeight) {
+				return false
+			}
+			if searchPets.MaxWeight > 0 && (male > searchPets.MaxWeight || female > searchPets.MaxWeight) {
+				return false
+			}
+		}
+
+		return (searchPets.MinMaleWeight == 0 || male >= searchPets.MinMaleWeight) &&
+			(searchPets.MaxMaleWeight == 0 || male <= searchPets.MaxMaleWeight) &&
+			(searchPets.MinFemaleWeight == 0 || female >= searchPets.MinFemaleWeight) &&
+			(searchPets.MaxFemaleWeight == 0 || female <= searchPets.MaxFemaleWeight)
+	}
+}
+
+// auditMatches tells whether the entry matches the filter, see auditConditions.
+func auditMatches(entry *entity.AuditEntry, filter *entity.AuditFilter) bool {
+	return (filter.EntityType == "" || entry.EntityType == filter.EntityType) &&
+		(filter.EntityID <= 0 || entry.EntityID == filter.EntityID) &&
+		(filter.Action == "" || entry.Action == filter.Action) &&
+		(filter.Actor == "" || entry.Actor == filter.Actor) &&
+		(filter.From.IsZero() || !entry.CreatedAt.Before(filter.From)) &&
+		(filter.To.IsZero() || entry.CreatedAt.Before(filter.To))
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+
+	return false
+}
+
+func newPet(id int, pet *entity.CreatePet, createdAt time.Time) *entity.Pet {
+	return &entity.Pet{
+		ID:                       id,
+		Species:                  pet.Species,
+		PetSize:                  pet.PetSize,
+		Name:                     pet.Name,
+		AverageMaleAdultWeight:   pet.AverageMaleAdultWeight,
+		AverageFemaleAdultWeight: pet.AverageFemaleAdultWeight,
+		Version:                  entity.InitialVersion,
+		CreatedAt:                createdAt,
+		UpdatedAt:                createdAt,
+	}
+}
+
+// copyPet returns a copy of the pet, so that the stored pets are only changed by the repository.
+func copyPet(pet *entity.Pet) *entity.Pet {
+	c := *pet
+	if pet.DeletedAt != nil {
+		deletedAt := *pet.DeletedAt
+		c.DeletedAt = &deletedAt
+	}
+
+	return &c
+}
@@ -0,0 +1,241 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/japhy-tech/backend-test/internal/entity"
+)
+
+// GetAllSpecies returns the species ordered by name.
+func (r *memoryPetRepository) GetAllSpecies(ctx context.Context) ([]entity.Species, error) {
+	species := []entity.Species{}
+
+	err := r.read(ctx, func(data *memoryData) {
+		for _, s := range data.species {
+			species = append(species, s)
+		}
+	})
+
+	sort.Slice(species, func(i, j int) bool {
+		return species[i].Name < species[j].Name
+	})
+
+	return species, err
+}
+
+func (r *memoryPetRepository) CreateSpecies(ctx context.Context, species *entity.Species) error {
+	var duplicateErr error
+
+	err := r.write(ctx, func(data *memoryData) {
+		if _, ok := data.species[species.Name]; ok {
+			duplicateErr = fmt.Errorf("%w: the species %q exists", ErrDuplicate, species.Name)
+			return
+		}
+
+		data.species[species.Name] = *species
+	})
+	if err != nil {
+		return err
+	}
+
+	return duplicateErr
+}
+
+// UpdateSpecies renames the species and changes its weights, the pets are renamed
+// along with it, in the trash or not, as a change of their version.
+func (r *memoryPetRepository) UpdateSpecies(ctx context.Context, name string, species *entity.Species) (int, error) {
+	affected := 0
+	var duplicateErr error
+
+	err := r.write(ctx, func(data *memoryData) {
+		if _, ok := data.species[name]; !ok {
+			return
+		}
+
+		if species.Name != name {
+			if _, ok := data.species[species.Name]; ok {
+				duplicateErr = fmt.Errorf("%w: the species %q exists", ErrDuplicate, species.Name)
+				return
+			}
+
+			delete(data.species, name)
+			updatedAt := now()
+			for _, pet := range data.pets {
+				if pet.Species == name {
+					pet.Species = species.Name
+					pet.Version++
+					pet.UpdatedAt = updatedAt
+				}
+			}
+		}
+
+		data.species[species.Name] = *species
+		affected = 1
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return affected, duplicateErr
+}
+
+// DeleteSpecies deletes the species, ErrInUse is returned while a pet has it.
+func (r *memoryPetRepository) DeleteSpecies(ctx context.Context, name string) (int, error) {
+	affected := 0
+	var inUseErr error
+
+	err := r.write(ctx, func(data *memoryData) {
+		if _, ok := data.species[name]; !ok {
+			return
+		}
+
+		for _, pet := range data.pets {
+			if pet.Species == name {
+				inUseErr = fmt.Errorf("%w: the pet %d is a %s", ErrInUse, pet.ID, name)
+				return
+			}
+		}
+
+		delete(data.species, name)
+		affected = 1
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return affected, inUseErr
+}
+
+// GetPetsBySpecies returns the pets of the species, in the trash or not, ordered by ID.
+func (r *memoryPetRepository) GetPetsBySpecies(ctx context.Context, name string) ([]entity.Pet, error) {
+	return r.getPetsByReference(ctx, func(pet *entity.Pet) bool { return pet.Species == name })
+}
+
+// GetAllPetSizes returns the sizes ordered by position.
+func (r *memoryPetRepository) GetAllPetSizes(ctx context.Context) ([]entity.PetSize, error) {
+	sizes := []entity.PetSize{}
+
+	err := r.read(ctx, func(data *memoryData) {
+		for _, size := range data.sizes {
+			sizes = append(sizes, size)
+		}
+	})
+
+	sort.Slice(sizes, func(i, j int) bool {
+		if sizes[i].Position != sizes[j].Position {
+			return sizes[i].Position < sizes[j].Position
+		}
+		return sizes[i].Name < sizes[j].Name
+	})
+
+	return sizes, err
+}
+
+func (r *memoryPetRepository) CreatePetSize(ctx context.Context, size *entity.PetSize) error {
+	var duplicateErr error
+
+	err := r.write(ctx, func(data *memoryData) {
+		if _, ok := data.sizes[size.Name]; ok {
+			duplicateErr = fmt.Errorf("%w: the pet size %q exists", ErrDuplicate, size.Name)
+			return
+		}
+
+		data.sizes[size.Name] = *size
+	})
+	if err != nil {
+		return err
+	}
+
+	return duplicateErr
+}
+
+// UpdatePetSize renames the size and changes its position, the pets are renamed
+// along with it, in the trash or not, as a change of their version.
+func (r *memoryPetRepository) UpdatePetSize(ctx context.Context, name string, size *entity.PetSize) (int, error) {
+	affected := 0
+	var duplicateErr error
+
+	err := r.write(ctx, func(data *memoryData) {
+		if _, ok := data.sizes[name]; !ok {
+			return
+		}
+
+		if size.Name != name {
+			if _, ok := data.sizes[size.Name]; ok {
+				duplicateErr = fmt.Errorf("%w: the pet size %q exists", ErrDuplicate, size.Name)
+				return
+			}
+
+			delete(data.sizes, name)
+			updatedAt := now()
+			for _, pet := range data.pets {
+				if pet.PetSize == name {
+					pet.PetSize = size.Name
+					pet.Version++
+					pet.UpdatedAt = updatedAt
+				}
+			}
+		}
+
+		data.sizes[size.Name] = *size
+		affected = 1
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return affected, duplicateErr
+}
+
+// DeletePetSize deletes the size, ErrInUse is returned while a pet has it.
+func (r *memoryPetRepository) DeletePetSize(ctx context.Context, name string) (int, error) {
+	affected := 0
+	var inUseErr error
+
+	err := r.write(ctx, func(data *memoryData) {
+		if _, ok := data.sizes[name]; !ok {
+			return
+		}
+
+		for _, pet := range data.pets {
+			if pet.PetSize == name {
+				inUseErr = fmt.Errorf("%w: the pet %d is %s", ErrInUse, pet.ID, name)
+				return
+			}
+		}
+
+		delete(data.sizes, name)
+		affected = 1
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return affected, inUseErr
+}
+
+// GetPetsByPetSize returns the pets of the size, in the trash or not, ordered by ID.
+func (r *memoryPetRepository) GetPetsByPetSize(ctx context.Context, name string) ([]entity.Pet, error) {
+	return r.getPetsByReference(ctx, func(pet *entity.Pet) bool { return pet.PetSize == name })
+}
+
+// getPetsByReference returns the matching pets, in the trash or not, ordered by ID.
+func (r *memoryPetRepository) getPetsByReference(ctx context.Context, matches func(pet *entity.Pet) bool) ([]entity.Pet, error) {
+	pets := []entity.Pet{}
+
+	err := r.read(ctx, func(data *memoryData) {
+		for _, pet := range data.pets {
+			if matches(pet) {
+				pets = append(pets, *copyPet(pet))
+			}
+		}
+	})
+
+	sort.Slice(pets, func(i, j int) bool {
+		return pets[i].ID < pets[j].ID
+	})
+
+	return pets, err
+}
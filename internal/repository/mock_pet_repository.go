@@ -1,40 +1,161 @@
 package repository
 
 import (
+	"context"
+	"time"
+
 	"github.com/japhy-tech/backend-test/internal/entity"
 	"github.com/stretchr/testify/mock"
 )
 
+// MockPetRepository expects the calls without their context.
 type MockPetRepository struct {
 	mock.Mock
 }
 
-func (m *MockPetRepository) Create(pet *entity.CreatePet) (int, error) {
+func (m *MockPetRepository) Create(ctx context.Context, pet *entity.CreatePet) (int, error) {
 	args := m.Called(pet)
 	return args.Get(0).(int), args.Error(1)
 }
 
-func (m *MockPetRepository) GetAll() ([]entity.Pet, error) {
-	args := m.Called()
+func (m *MockPetRepository) GetAll(ctx context.Context, page *entity.PageRequest) ([]entity.Pet, error) {
+	args := m.Called(page)
 	return args.Get(0).([]entity.Pet), args.Error(1)
 }
 
-func (m *MockPetRepository) GetByID(id int) (*entity.Pet, error) {
+func (m *MockPetRepository) Count(ctx context.Context) (int, error) {
+	args := m.Called()
+	return args.Get(0).(int), args.Error(1)
+}
+
+func (m *MockPetRepository) CountBySpecies(ctx context.Context) (map[string]int, error) {
+	args := m.Called()
+	return args.Get(0).(map[string]int), args.Error(1)
+}
+
+func (m *MockPetRepository) GetByID(ctx context.Context, id int) (*entity.Pet, error) {
 	args := m.Called(id)
 	return args.Get(0).(*entity.Pet), args.Error(1)
 }
 
-func (m *MockPetRepository) Update(id int, pet *entity.UpdatePet) (int, error) {
-	args := m.Called(id, pet)
+func (m *MockPetRepository) Update(ctx context.Context, id int, pet *entity.UpdatePet, version int) (int, error) {
+	args := m.Called(id, pet, version)
 	return args.Get(0).(int), args.Error(1)
 }
 
-func (m *MockPetRepository) Delete(id int) (int, error) {
-	args := m.Called(id)
+func (m *MockPetRepository) Patch(ctx context.Context, id int, pet *entity.PatchPet, version int) (int, error) {
+	args := m.Called(id, pet, version)
 	return args.Get(0).(int), args.Error(1)
 }
 
-func (m *MockPetRepository) SearchPets(searchPets *entity.SearchPets) ([]entity.Pet, error) {
+func (m *MockPetRepository) Delete(ctx context.Context, id int, version int) (int, error) {
+	args := m.Called(id, version)
+	return args.Get(0).(int), args.Error(1)
+}
+
+func (m *MockPetRepository) SearchPets(ctx context.Context, searchPets *entity.SearchPets, page *entity.PageRequest) ([]entity.Pet, error) {
+	args := m.Called(searchPets, page)
+	return args.Get(0).([]entity.Pet), args.Error(1)
+}
+
+func (m *MockPetRepository) CountSearchPets(ctx context.Context, searchPets *entity.SearchPets) (int, error) {
 	args := m.Called(searchPets)
+	return args.Get(0).(int), args.Error(1)
+}
+
+func (m *MockPetRepository) GetDeleted(ctx context.Context, page *entity.PageRequest) ([]entity.Pet, error) {
+	args := m.Called(page)
 	return args.Get(0).([]entity.Pet), args.Error(1)
 }
+
+func (m *MockPetRepository) CountDeleted(ctx context.Context) (int, error) {
+	args := m.Called()
+	return args.Get(0).(int), args.Error(1)
+}
+
+func (m *MockPetRepository) Restore(ctx context.Context, id int) (int, error) {
+	args := m.Called(id)
+	return args.Get(0).(int), args.Error(1)
+}
+
+func (m *MockPetRepository) Purge(ctx context.Context, deletedBefore time.Time) (int, error) {
+	args := m.Called(deletedBefore)
+	return args.Get(0).(int), args.Error(1)
+}
+
+func (m *MockPetRepository) InsertAuditEntry(ctx context.Context, entry *entity.AuditEntry) error {
+	args := m.Called(entry)
+	return args.Error(0)
+}
+
+func (m *MockPetRepository) GetAuditEntries(ctx context.Context, filter *entity.AuditFilter, page *entity.PageRequest) ([]entity.AuditEntry, error) {
+	args := m.Called(filter, page)
+	return args.Get(0).([]entity.AuditEntry), args.Error(1)
+}
+
+func (m *MockPetRepository) CountAuditEntries(ctx context.Context, filter *entity.AuditFilter) (int, error) {
+	args := m.Called(filter)
+	return args.Get(0).(int), args.Error(1)
+}
+
+func (m *MockPetRepository) GetAllSpecies(ctx context.Context) ([]entity.Species, error) {
+	args := m.Called()
+	return args.Get(0).([]entity.Species), args.Error(1)
+}
+
+func (m *MockPetRepository) CreateSpecies(ctx context.Context, species *entity.Species) error {
+	args := m.Called(species)
+	return args.Error(0)
+}
+
+func (m *MockPetRepository) UpdateSpecies(ctx context.Context, name string, species *entity.Species) (int, error) {
+	args := m.Called(name, species)
+	return args.Get(0).(int), args.Error(1)
+}
+
+func (m *MockPetRepository) DeleteSpecies(ctx context.Context, name string) (int, error) {
+	args := m.Called(name)
+	return args.Get(0).(int), args.Error(1)
+}
+
+func (m *MockPetRepository) GetPetsBySpecies(ctx context.Context, name string) ([]entity.Pet, error) {
+	args := m.Called(name)
+	return args.Get(0).([]entity.Pet), args.Error(1)
+}
+
+func (m *MockPetRepository) GetAllPetSizes(ctx context.Context) ([]entity.PetSize, error) {
+	args := m.Called()
+	return args.Get(0).([]entity.PetSize), args.Error(1)
+}
+
+func (m *MockPetRepository) CreatePetSize(ctx context.Context, size *entity.PetSize) error {
+	args := m.Called(size)
+	return args.Error(0)
+}
+
+func (m *MockPetRepository) UpdatePetSize(ctx context.Context, name string, size *entity.PetSize) (int, error) {
+	args := m.Called(name, size)
+	return args.Get(0).(int), args.Error(1)
+}
+
+func (m *MockPetRepository) DeletePetSize(ctx context.Context, name string) (int, error) {
+	args := m.Called(name)
+	return args.Get(0).(int), args.Error(1)
+}
+
+func (m *MockPetRepository) GetPetsByPetSize(ctx context.Context, name string) ([]entity.Pet, error) {
+	args := m.Called(name)
+	return args.Get(0).([]entity.Pet), args.Error(1)
+}
+
+// WithTransaction runs fn with the mock itself.
+func (m *MockPetRepository) WithTransaction(ctx context.Context, fn func(repo PetRepository) error) error {
+	m.Called()
+	return fn(m)
+}
+
+// WithSavepoint runs fn with the mock itself.
+func (m *MockPetRepository) WithSavepoint(ctx context.Context, fn func(repo PetRepository) error) error {
+	m.Called()
+	return fn(m)
+}
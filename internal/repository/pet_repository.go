@@ -1,153 +1,621 @@
 package repository
 
 import (
+	"context"
 	"database/sql"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/japhy-tech/backend-test/internal/entity"
 )
 
+// PetRepository gives access to the pets.
+//
+// Deleted pets are kept in a trash until purged: only GetDeleted, CountDeleted,
+// Restore, Purge, GetPetsBySpecies and GetPetsByPetSize see them.
+//
+// The audit log is kept alongside the pets so that an entry is written in the
+// transaction of the change it records.
+//
+// Every method takes the context of the request, the queries are canceled when it is done.
+//
+// Update, Patch and Delete only affect the pet if its version is the given one,
+// or whatever its version when 0 is given. Updates increment the version.
+//
+// The species and the sizes of the pets are reference data: a pet can only have
+// a stored species and size, renaming them renames them on the pets (a change of
+// their version), and they can't be deleted while a pet has them, in the trash or
+// not (ErrInUse).
 type PetRepository interface {
-	Create(pet *entity.CreatePet) (int, error)
-	GetAll() ([]entity.Pet, error)
-	GetByID(id int) (*entity.Pet, error)
-	Update(id int, pet *entity.UpdatePet) (int, error)
-	Delete(id int) (int, error)
-	SearchPets(searchPets *entity.SearchPets) ([]entity.Pet, error)
+	Create(ctx context.Context, pet *entity.CreatePet) (int, error)
+	GetAll(ctx context.Context, page *entity.PageRequest) ([]entity.Pet, error)
+	Count(ctx context.Context) (int, error)
+	CountBySpecies(ctx context.Context) (map[string]int, error)
+	GetByID(ctx context.Context, id int) (*entity.Pet, error)
+	Update(ctx context.Context, id int, pet *entity.UpdatePet, version int) (int, error)
+	Patch(ctx context.Context, id int, pet *entity.PatchPet, version int) (int, error)
+	Delete(ctx context.Context, id int, version int) (int, error)
+	SearchPets(ctx context.Context, searchPets *entity.SearchPets, page *entity.PageRequest) ([]entity.Pet, error)
+	CountSearchPets(ctx context.Context, searchPets *entity.SearchPets) (int, error)
+
+	GetDeleted(ctx context.Context, page *entity.PageRequest) ([]entity.Pet, error)
+	CountDeleted(ctx context.Context) (int, error)
+	Restore(ctx context.Context, id int) (int, error)
+	Purge(ctx context.Context, deletedBefore time.Time) (int, error)
+
+	GetAllSpecies(ctx context.Context) ([]entity.Species, error)
+	CreateSpecies(ctx context.Context, species *entity.Species) error
+	UpdateSpecies(ctx context.Context, name string, species *entity.Species) (int, error)
+	DeleteSpecies(ctx context.Context, name string) (int, error)
+	GetPetsBySpecies(ctx context.Context, name string) ([]entity.Pet, error)
+	GetAllPetSizes(ctx context.Context) ([]entity.PetSize, error)
+	CreatePetSize(ctx context.Context, size *entity.PetSize) error
+	UpdatePetSize(ctx context.Context, name string, size *entity.PetSize) (int, error)
+	DeletePetSize(ctx context.Context, name string) (int, error)
+	GetPetsByPetSize(ctx context.Context, name string) ([]entity.Pet, error)
+
+	InsertAuditEntry(ctx context.Context, entry *entity.AuditEntry) error
+	GetAuditEntries(ctx context.Context, filter *entity.AuditFilter, page *entity.PageRequest) ([]entity.AuditEntry, error)
+	CountAuditEntries(ctx context.Context, filter *entity.AuditFilter) (int, error)
+
+	// WithTransaction runs fn with a repository bound to a transaction, which is
+	// committed if fn returns nil and rolled back otherwise.
+	// Within a transaction, fn runs in the ongoing one.
+	WithTransaction(ctx context.Context, fn func(repo PetRepository) error) error
+	// WithSavepoint runs fn within the ongoing transaction, whose changes made by fn
+	// only are rolled back if fn fails. Outside of a transaction, it is WithTransaction.
+	WithSavepoint(ctx context.Context, fn func(repo PetRepository) error) error
+}
+
+// petColumns are scanned by scanPet.
+const petColumns = "id, species, pet_size, name, average_male_adult_weight, average_female_adult_weight, version, created_at, updated_at, deleted_at"
+
+// sortColumns maps the sort fields to their column.
+var sortColumns = map[string]string{
+	"id":                          "id",
+	"species":                     "species",
+	"pet_size":                    "pet_size",
+	"name":                        "name",
+	"average_male_adult_weight":   "average_male_adult_weight",
+	"average_female_adult_weight": "average_female_adult_weight",
+}
+
+// dbtx is implemented by both *sql.DB and *sql.Tx.
+type dbtx interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
 }
 
 type petRepository struct {
-	DB *sql.DB
+	DB dbtx
+	// db starts the transactions, it is nil within a transaction
+	db *sql.DB
+	// queryTimeout bounds every query, no bound when 0
+	queryTimeout time.Duration
+	// system is the database system traced with the statements
+	system string
+	// savepoints is the number of savepoints the repository runs within
+	savepoints int
+}
+
+// NewPetRepository returns a MySQL repository whose queries are canceled after the
+// query timeout (0 for no timeout) or when their context is done.
+// Every statement is traced, see NewTracingPetRepository for the calls.
+func NewPetRepository(db *sql.DB, queryTimeout time.Duration) PetRepository {
+	return newPetRepository(db, queryTimeout, "mysql")
+}
+
+// NewSQLitePetRepository returns a repository on the SQLite database opened by
+// database.NewSQLiteDB, the queries of NewPetRepository being portable to SQLite.
+func NewSQLitePetRepository(db *sql.DB, queryTimeout time.Duration) PetRepository {
+	return newPetRepository(db, queryTimeout, "sqlite")
+}
+
+func newPetRepository(db *sql.DB, queryTimeout time.Duration, system string) PetRepository {
+	return &petRepository{DB: tracingDB{dbtx: db, system: system}, db: db, queryTimeout: queryTimeout, system: system}
+}
+
+// WithTransaction binds the transaction to ctx: it is rolled back if ctx is done before the commit.
+func (r *petRepository) WithTransaction(ctx context.Context, fn func(repo PetRepository) error) error {
+	return r.inTransaction(ctx, func(tx *petRepository) error {
+		return fn(tx)
+	})
+}
+
+// inTransaction is WithTransaction giving fn the SQL repository, for the changes
+// made of several statements.
+func (r *petRepository) inTransaction(ctx context.Context, fn func(tx *petRepository) error) error {
+	if r.db == nil {
+		return fn(r)
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return translateError(ctx, err)
+	}
+
+	err = fn(&petRepository{DB: tracingDB{dbtx: tx, system: r.system}, queryTimeout: r.queryTimeout, system: r.system})
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return translateError(ctx, tx.Commit())
 }
 
-func NewPetRepository(db *sql.DB) PetRepository {
-	return &petRepository{DB: db}
+func (r *petRepository) WithSavepoint(ctx context.Context, fn func(repo PetRepository) error) error {
+	if r.db != nil {
+		return r.WithTransaction(ctx, fn)
+	}
+
+	// The savepoints are numbered, a savepoint replaces the one of the same name with MySQL
+	savepoint := "savepoint_" + strconv.Itoa(r.savepoints+1)
+
+	_, err := r.DB.ExecContext(ctx, "SAVEPOINT "+savepoint)
+	if err != nil {
+		return translateError(ctx, err)
+	}
+
+	err = fn(&petRepository{DB: r.DB, queryTimeout: r.queryTimeout, system: r.system, savepoints: r.savepoints + 1})
+	if err != nil {
+		_, rollbackErr := r.DB.ExecContext(ctx, "ROLLBACK TO SAVEPOINT "+savepoint)
+		if rollbackErr != nil {
+			return translateError(ctx, rollbackErr)
+		}
+	}
+
+	// The savepoint is kept by the rollback to it
+	_, releaseErr := r.DB.ExecContext(ctx, "RELEASE SAVEPOINT "+savepoint)
+	if err == nil {
+		err = translateError(ctx, releaseErr)
+	}
+
+	return err
 }
 
-func (r *petRepository) Create(pet *entity.CreatePet) (int, error) {
-	result, err := r.DB.Exec(`
-		INSERT INTO pets (species, pet_size, name, average_male_adult_weight, average_female_adult_weight) 
-		VALUES (?, ?, ?, ?, ?)
-	`, pet.Species, pet.PetSize, pet.Name, pet.AverageMaleAdultWeight, pet.AverageFemaleAdultWeight)
+func (r *petRepository) Create(ctx context.Context, pet *entity.CreatePet) (int, error) {
+	ctx, cancel := r.queryContext(ctx)
+	defer cancel()
+
+	createdAt := now()
+	result, err := r.DB.ExecContext(ctx, `
+		INSERT INTO pets (species, pet_size, name, average_male_adult_weight, average_female_adult_weight, created_at, updated_at) 
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+	`, pet.Species, pet.PetSize, pet.Name, pet.AverageMaleAdultWeight, pet.AverageFemaleAdultWeight, createdAt, createdAt)
 	if err != nil {
-		return 0, err
+		return 0, translateError(ctx, err)
 	}
 
 	id, err := result.LastInsertId()
 	if err != nil {
-		return 0, err
+		return 0, translateError(ctx, err)
 	}
 
 	return int(id), nil
 }
 
-func (r *petRepository) GetAll() ([]entity.Pet, error) {
-	rows, err := r.DB.Query("SELECT id, species, pet_size, name, average_male_adult_weight, average_female_adult_weight FROM pets")
+func (r *petRepository) GetAll(ctx context.Context, page *entity.PageRequest) ([]entity.Pet, error) {
+	ctx, cancel := r.queryContext(ctx)
+	defer cancel()
+
+	query, args := paginate("SELECT "+petColumns+" FROM pets", notDeleted(), page)
+
+	rows, err := r.DB.QueryContext(ctx, query, args...)
 	if err != nil {
-		return nil, err
+		return nil, translateError(ctx, err)
 	}
 	defer rows.Close()
 
-	var pets []entity.Pet
+	return scanPets(ctx, rows)
+}
+
+func (r *petRepository) Count(ctx context.Context) (int, error) {
+	ctx, cancel := r.queryContext(ctx)
+	defer cancel()
+
+	var count int
+
+	err := r.DB.QueryRowContext(ctx, "SELECT COUNT(*) FROM pets WHERE deleted_at IS NULL").Scan(&count)
+	if err != nil {
+		return 0, translateError(ctx, err)
+	}
+
+	return count, nil
+}
+
+func (r *petRepository) CountBySpecies(ctx context.Context) (map[string]int, error) {
+	ctx, cancel := r.queryContext(ctx)
+	defer cancel()
+
+	rows, err := r.DB.QueryContext(ctx, "SELECT species, COUNT(*) FROM pets WHERE deleted_at IS NULL GROUP BY species")
+	if err != nil {
+		return nil, translateError(ctx, err)
+	}
+	defer rows.Close()
+
+	counts := map[string]int{}
 	for rows.Next() {
-		var pet entity.Pet
-		err := rows.Scan(&pet.ID, &pet.Species, &pet.PetSize, &pet.Name, &pet.AverageMaleAdultWeight, &pet.AverageFemaleAdultWeight)
+		var species string
+		var count int
+
+		err = rows.Scan(&species, &count)
 		if err != nil {
-			return nil, err
+			return nil, translateError(ctx, err)
 		}
-		pets = append(pets, pet)
+		counts[species] = count
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return nil, translateError(ctx, err)
 	}
 
-	return pets, nil
+	return counts, nil
 }
 
-func (r *petRepository) GetByID(id int) (*entity.Pet, error) {
-	var pet entity.Pet
+func (r *petRepository) GetByID(ctx context.Context, id int) (*entity.Pet, error) {
+	ctx, cancel := r.queryContext(ctx)
+	defer cancel()
 
-	err := r.DB.QueryRow(`
-		SELECT id, species, pet_size, name, average_male_adult_weight, average_female_adult_weight 
-		FROM pets 
-		WHERE id = ?
-	`, id).Scan(&pet.ID, &pet.Species, &pet.PetSize, &pet.Name, &pet.AverageMaleAdultWeight, &pet.AverageFemaleAdultWeight)
+	pet, err := scanPet(r.DB.QueryRowContext(ctx, "SELECT "+petColumns+" FROM pets WHERE id = ? AND deleted_at IS NULL", id))
 	if err != nil {
-		return nil, err
+		return nil, translateError(ctx, err)
 	}
 
-	return &pet, nil
+	return pet, nil
 }
 
-func (r *petRepository) Update(id int, pet *entity.UpdatePet) (int, error) {
-	result, err := r.DB.Exec(`
+func (r *petRepository) Update(ctx context.Context, id int, pet *entity.UpdatePet, version int) (int, error) {
+	ctx, cancel := r.queryContext(ctx)
+	defer cancel()
+
+	where := versionConditions(id, version)
+
+	result, err := r.DB.ExecContext(ctx, `
 		UPDATE pets 
-		SET species = ?, pet_size = ?, name = ?, average_male_adult_weight = ?, average_female_adult_weight = ? 
-		WHERE id = ?`, pet.Species, pet.PetSize, pet.Name, pet.AverageMaleAdultWeight, pet.AverageFemaleAdultWeight, id)
+		SET species = ?, pet_size = ?, name = ?, average_male_adult_weight = ?, average_female_adult_weight = ?, version = version + 1, updated_at = ?`+
+		where.String(),
+		append([]interface{}{pet.Species, pet.PetSize, pet.Name, pet.AverageMaleAdultWeight, pet.AverageFemaleAdultWeight, now()}, where.args...)...,
+	)
 	if err != nil {
-		return 0, err
+		return 0, translateError(ctx, err)
 	}
 
 	rowsAffected, err := result.RowsAffected()
 	if err != nil {
-		return 0, err
+		return 0, translateError(ctx, err)
 	}
 
 	return int(rowsAffected), nil
 }
 
-func (r *petRepository) Delete(id int) (int, error) {
-	result, err := r.DB.Exec(`
-		DELETE FROM pets 
-		WHERE id = ?
-	`, id)
+// Patch only updates the columns set in the patch.
+func (r *petRepository) Patch(ctx context.Context, id int, pet *entity.PatchPet, version int) (int, error) {
+	ctx, cancel := r.queryContext(ctx)
+	defer cancel()
+
+	var columns []string
+	var args []interface{}
+
+	if pet.Species != nil {
+		columns = append(columns, "species = ?")
+		args = append(args, *pet.Species)
+	}
+	if pet.PetSize != nil {
+		columns = append(columns, "pet_size = ?")
+		args = append(args, *pet.PetSize)
+	}
+	if pet.Name != nil {
+		columns = append(columns, "name = ?")
+		args = append(args, *pet.Name)
+	}
+	if pet.AverageMaleAdultWeight != nil {
+		columns = append(columns, "average_male_adult_weight = ?")
+		args = append(args, *pet.AverageMaleAdultWeight)
+	}
+	if pet.AverageFemaleAdultWeight != nil {
+		columns = append(columns, "average_female_adult_weight = ?")
+		args = append(args, *pet.AverageFemaleAdultWeight)
+	}
+
+	if len(columns) == 0 {
+		return 0, nil
+	}
+
+	columns = append(columns, "version = version + 1", "updated_at = ?")
+	args = append(args, now())
+	where := versionConditions(id, version)
+
+	result, err := r.DB.ExecContext(ctx, "UPDATE pets SET "+strings.Join(columns, ", ")+where.String(), append(args, where.args...)...)
 	if err != nil {
-		return 0, err
+		return 0, translateError(ctx, err)
 	}
 
 	rowsAffected, err := result.RowsAffected()
 	if err != nil {
-		return 0, err
+		return 0, translateError(ctx, err)
 	}
 
 	return int(rowsAffected), nil
 }
 
-func (r *petRepository) SearchPets(searchPets *entity.SearchPets) ([]entity.Pet, error) {
-	query := `
-		SELECT id, species, pet_size, name, average_male_adult_weight, average_female_adult_weight 
-		FROM pets 
-		WHERE 1=1
-	`
+// Delete moves the pet to the trash.
+func (r *petRepository) Delete(ctx context.Context, id int, version int) (int, error) {
+	ctx, cancel := r.queryContext(ctx)
+	defer cancel()
 
-	var args []interface{}
+	where := versionConditions(id, version)
+	deletedAt := now()
 
-	if searchPets.Species != "" {
-		query += " AND species = ?"
-		args = append(args, searchPets.Species)
+	result, err := r.DB.ExecContext(ctx, "UPDATE pets SET deleted_at = ?, version = version + 1, updated_at = ?"+where.String(), append([]interface{}{deletedAt, deletedAt}, where.args...)...)
+	if err != nil {
+		return 0, translateError(ctx, err)
 	}
 
-	if searchPets.MinWeight > 0 {
-		query += " AND (average_male_adult_weight >= ? OR average_female_adult_weight >= ?)"
-		args = append(args, searchPets.MinWeight, searchPets.MinWeight)
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return 0, translateError(ctx, err)
 	}
 
-	if searchPets.MaxWeight > 0 {
-		query += " AND (average_male_adult_weight <= ? OR average_female_adult_weight <= ?)"
-		args = append(args, searchPets.MaxWeight, searchPets.MaxWeight)
+	return int(rowsAffected), nil
+}
+
+func (r *petRepository) SearchPets(ctx context.Context, searchPets *entity.SearchPets, page *entity.PageRequest) ([]entity.Pet, error) {
+	ctx, cancel := r.queryContext(ctx)
+	defer cancel()
+
+	query, args := paginate("SELECT "+petColumns+" FROM pets", searchConditions(searchPets), page)
+
+	rows, err := r.DB.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, translateError(ctx, err)
 	}
+	defer rows.Close()
 
-	rows, err := r.DB.Query(query, args...)
+	return scanPets(ctx, rows)
+}
+
+func (r *petRepository) CountSearchPets(ctx context.Context, searchPets *entity.SearchPets) (int, error) {
+	ctx, cancel := r.queryContext(ctx)
+	defer cancel()
+
+	var count int
+
+	where := searchConditions(searchPets)
+
+	err := r.DB.QueryRowContext(ctx, "SELECT COUNT(*) FROM pets"+where.String(), where.args...).Scan(&count)
 	if err != nil {
-		return nil, err
+		return 0, translateError(ctx, err)
+	}
+
+	return count, nil
+}
+
+func (r *petRepository) GetDeleted(ctx context.Context, page *entity.PageRequest) ([]entity.Pet, error) {
+	ctx, cancel := r.queryContext(ctx)
+	defer cancel()
+
+	where := &whereClause{}
+	where.add("deleted_at IS NOT NULL")
+
+	query, args := paginate("SELECT "+petColumns+" FROM pets", where, page)
+
+	rows, err := r.DB.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, translateError(ctx, err)
 	}
 	defer rows.Close()
 
+	return scanPets(ctx, rows)
+}
+
+func (r *petRepository) CountDeleted(ctx context.Context) (int, error) {
+	ctx, cancel := r.queryContext(ctx)
+	defer cancel()
+
+	var count int
+
+	err := r.DB.QueryRowContext(ctx, "SELECT COUNT(*) FROM pets WHERE deleted_at IS NOT NULL").Scan(&count)
+	if err != nil {
+		return 0, translateError(ctx, err)
+	}
+
+	return count, nil
+}
+
+// Restore takes the pet out of the trash.
+func (r *petRepository) Restore(ctx context.Context, id int) (int, error) {
+	ctx, cancel := r.queryContext(ctx)
+	defer cancel()
+
+	result, err := r.DB.ExecContext(ctx, "UPDATE pets SET deleted_at = NULL, version = version + 1, updated_at = ? WHERE id = ? AND deleted_at IS NOT NULL", now(), id)
+	if err != nil {
+		return 0, translateError(ctx, err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return 0, translateError(ctx, err)
+	}
+
+	return int(rowsAffected), nil
+}
+
+// Purge permanently deletes the pets moved to the trash before the given time.
+func (r *petRepository) Purge(ctx context.Context, deletedBefore time.Time) (int, error) {
+	ctx, cancel := r.queryContext(ctx)
+	defer cancel()
+
+	result, err := r.DB.ExecContext(ctx, "DELETE FROM pets WHERE deleted_at IS NOT NULL AND deleted_at < ?", deletedBefore.UTC())
+	if err != nil {
+		return 0, translateError(ctx, err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return 0, translateError(ctx, err)
+	}
+
+	return int(rowsAffected), nil
+}
+
+// queryContext bounds a query with the query timeout.
+func (r *petRepository) queryContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	if r.queryTimeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+
+	return context.WithTimeout(ctx, r.queryTimeout)
+}
+
+// notDeleted matches the pets that are not in the trash.
+func notDeleted() *whereClause {
+	where := &whereClause{}
+	where.add("deleted_at IS NULL")
+
+	return where
+}
+
+// versionConditions matches the pet if it has the given version, any version matches when 0.
+// Pets in the trash never match.
+func versionConditions(id int, version int) *whereClause {
+	where := &whereClause{}
+	where.add("id = ?", id)
+	where.add("deleted_at IS NULL")
+
+	if version > 0 {
+		where.add("version = ?", version)
+	}
+
+	return where
+}
+
+// searchConditions returns the conditions matching the search criteria.
+func searchConditions(searchPets *entity.SearchPets) *whereClause {
+	where := notDeleted()
+
+	where.addIn("species", searchPets.Species)
+	where.addIn("pet_size", searchPets.PetSize)
+
+	if searchPets.Name != "" {
+		pattern := likePattern(strings.ToLower(searchPets.Name)) + "%"
+		if searchPets.NameMatch != entity.NameMatchPrefix {
+			pattern = "%" + pattern
+		}
+		where.add("LOWER(name) LIKE ? ESCAPE '!'", pattern)
+	}
+
+	if searchPets.WeightMode == entity.WeightModeOverlaps {
+		// The range between the male and female weights must overlap the searched range
+		if searchPets.MinWeight > 0 {
+			where.add("(average_male_adult_weight >= ? OR average_female_adult_weight >= ?)", searchPets.MinWeight, searchPets.MinWeight)
+		}
+		if searchPets.MaxWeight > 0 {
+			where.add("(average_male_adult_weight <= ? OR average_female_adult_weight <= ?)", searchPets.MaxWeight, searchPets.MaxWeight)
+		}
+	} else {
+		// Both weights must be within the searched range
+		if searchPets.MinWeight > 0 {
+			where.add("average_male_adult_weight >= ? AND average_female_adult_weight >= ?", searchPets.MinWeight, searchPets.MinWeight)
+		}
+		if searchPets.MaxWeight > 0 {
+			where.add("average_male_adult_weight <= ? AND average_female_adult_weight <= ?", searchPets.MaxWeight, searchPets.MaxWeight)
+		}
+	}
+
+	if searchPets.MinMaleWeight > 0 {
+		where.add("average_male_adult_weight >= ?", searchPets.MinMaleWeight)
+	}
+	if searchPets.MaxMaleWeight > 0 {
+		where.add("average_male_adult_weight <= ?", searchPets.MaxMaleWeight)
+	}
+	if searchPets.MinFemaleWeight > 0 {
+		where.add("average_female_adult_weight >= ?", searchPets.MinFemaleWeight)
+	}
+	if searchPets.MaxFemaleWeight > 0 {
+		where.add("average_female_adult_weight <= ?", searchPets.MaxFemaleWeight)
+	}
+
+	return where
+}
+
+// paginate appends the conditions, the keyset condition, the ordering and the limit of the page to the query.
+//
+// The cursor is the ID of the last pet of the previous page: rows are ordered by
+// the sort column then by ID, so the page starts right after the (value, id) of the cursor.
+//
+// Unknown sort fields fall back to the ID since the column is interpolated in the query.
+func paginate(query string, where *whereClause, page *entity.PageRequest) (string, []interface{}) {
+	column, ok := sortColumns[page.Sort]
+	if !ok {
+		column = "id"
+	}
+
+	comparator, direction := ">", "ASC"
+	if page.Order == entity.SortDesc {
+		comparator, direction = "<", "DESC"
+	}
+
+	if page.Cursor > 0 {
+		if column == "id" {
+			where.add(fmt.Sprintf("id %s ?", comparator), page.Cursor)
+		} else {
+			where.add(fmt.Sprintf("(%[1]s, id) %[2]s (SELECT %[1]s, id FROM pets WHERE id = ?)", column, comparator), page.Cursor)
+		}
+	}
+
+	query += where.String()
+	args := where.args
+
+	if column == "id" {
+		query += " ORDER BY id " + direction
+	} else {
+		query += fmt.Sprintf(" ORDER BY %s %s, id %s", column, direction, direction)
+	}
+
+	query += " LIMIT ?"
+	args = append(args, page.Limit)
+
+	return query, args
+}
+
+func scanPets(ctx context.Context, rows *sql.Rows) ([]entity.Pet, error) {
 	var pets []entity.Pet
 	for rows.Next() {
-		var pet entity.Pet
-		err := rows.Scan(&pet.ID, &pet.Species, &pet.PetSize, &pet.Name, &pet.AverageMaleAdultWeight, &pet.AverageFemaleAdultWeight)
+		pet, err := scanPet(rows)
 		if err != nil {
-			return nil, err
+			return nil, translateError(ctx, err)
 		}
-		pets = append(pets, pet)
+		pets = append(pets, *pet)
 	}
 
-	return pets, nil
+	return pets, translateError(ctx, rows.Err())
+}
+
+// now is the time of a change, to the microsecond like the DATETIME(6) columns.
+func now() time.Time {
+	return time.Now().UTC().Truncate(time.Microsecond)
+}
+
+// scanPet scans the petColumns of a row.
+func scanPet(row interface{ Scan(dest ...interface{}) error }) (*entity.Pet, error) {
+	var pet entity.Pet
+	var deletedAt sql.NullTime
+
+	err := row.Scan(&pet.ID, &pet.Species, &pet.PetSize, &pet.Name, &pet.AverageMaleAdultWeight, &pet.AverageFemaleAdultWeight, &pet.Version, &pet.CreatedAt, &pet.UpdatedAt, &deletedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	pet.CreatedAt = pet.CreatedAt.UTC()
+	pet.UpdatedAt = pet.UpdatedAt.UTC()
+	if deletedAt.Valid {
+		deletedAtUTC := deletedAt.Time.UTC()
+		pet.DeletedAt = &deletedAtUTC
+	}
+
+	return &pet, nil
 }
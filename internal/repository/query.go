@@ -0,0 +1,45 @@
+package repository
+
+import (
+	"strings"
+)
+
+// whereClause gathers the conditions of a query along with their arguments.
+type whereClause struct {
+	conditions []string
+	args       []interface{}
+}
+
+// add appends a condition, joined to the others by AND.
+func (w *whereClause) add(condition string, args ...interface{}) {
+	w.conditions = append(w.conditions, condition)
+	w.args = append(w.args, args...)
+}
+
+// addIn appends a "column IN (...)" condition, or nothing when values is empty.
+func (w *whereClause) addIn(column string, values []string) {
+	if len(values) == 0 {
+		return
+	}
+
+	args := make([]interface{}, len(values))
+	for i, value := range values {
+		args[i] = value
+	}
+
+	w.add(column+" IN (?"+strings.Repeat(", ?", len(values)-1)+")", args...)
+}
+
+// String returns the WHERE clause, or an empty string when there is no condition.
+func (w *whereClause) String() string {
+	if len(w.conditions) == 0 {
+		return ""
+	}
+
+	return " WHERE " + strings.Join(w.conditions, " AND ")
+}
+
+// likePattern escapes the LIKE wildcards of value, to be used with ESCAPE '!'.
+func likePattern(value string) string {
+	return strings.NewReplacer("!", "!!", "%", "!%", "_", "!_").Replace(value)
+}
@@ -0,0 +1,181 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/japhy-tech/backend-test/internal/entity"
+)
+
+// GetAllSpecies returns the species ordered by name.
+func (r *petRepository) GetAllSpecies(ctx context.Context) ([]entity.Species, error) {
+	ctx, cancel := r.queryContext(ctx)
+	defer cancel()
+
+	rows, err := r.DB.QueryContext(ctx, "SELECT name, min_weight, max_weight FROM species ORDER BY name")
+	if err != nil {
+		return nil, translateError(ctx, err)
+	}
+	defer rows.Close()
+
+	species := []entity.Species{}
+	for rows.Next() {
+		var s entity.Species
+
+		err = rows.Scan(&s.Name, &s.MinWeight, &s.MaxWeight)
+		if err != nil {
+			return nil, translateError(ctx, err)
+		}
+		species = append(species, s)
+	}
+
+	return species, translateError(ctx, rows.Err())
+}
+
+func (r *petRepository) CreateSpecies(ctx context.Context, species *entity.Species) error {
+	ctx, cancel := r.queryContext(ctx)
+	defer cancel()
+
+	_, err := r.DB.ExecContext(ctx, "INSERT INTO species (name, min_weight, max_weight) VALUES (?, ?, ?)",
+		species.Name, species.MinWeight, species.MaxWeight)
+
+	return translateError(ctx, err)
+}
+
+// UpdateSpecies renames the species and changes its weights, the foreign key renames it on the pets.
+//
+// MySQL doesn't count the rows left as is: 0 is returned when nothing changes.
+func (r *petRepository) UpdateSpecies(ctx context.Context, name string, species *entity.Species) (int, error) {
+	return r.updateReference(ctx, "species", name, species.Name,
+		"UPDATE species SET name = ?, min_weight = ?, max_weight = ? WHERE name = ?",
+		species.Name, species.MinWeight, species.MaxWeight, name)
+}
+
+// DeleteSpecies deletes the species, ErrInUse is returned while a pet has it.
+func (r *petRepository) DeleteSpecies(ctx context.Context, name string) (int, error) {
+	return r.deleteReference(ctx, "DELETE FROM species WHERE name = ?", name)
+}
+
+// GetPetsBySpecies returns the pets of the species, in the trash or not, ordered by ID.
+func (r *petRepository) GetPetsBySpecies(ctx context.Context, name string) ([]entity.Pet, error) {
+	return r.getPetsByReference(ctx, "species", name)
+}
+
+// GetAllPetSizes returns the sizes ordered by position.
+func (r *petRepository) GetAllPetSizes(ctx context.Context) ([]entity.PetSize, error) {
+	ctx, cancel := r.queryContext(ctx)
+	defer cancel()
+
+	rows, err := r.DB.QueryContext(ctx, "SELECT name, position FROM pet_sizes ORDER BY position, name")
+	if err != nil {
+		return nil, translateError(ctx, err)
+	}
+	defer rows.Close()
+
+	sizes := []entity.PetSize{}
+	for rows.Next() {
+		var size entity.PetSize
+
+		err = rows.Scan(&size.Name, &size.Position)
+		if err != nil {
+			return nil, translateError(ctx, err)
+		}
+		sizes = append(sizes, size)
+	}
+
+	return sizes, translateError(ctx, rows.Err())
+}
+
+func (r *petRepository) CreatePetSize(ctx context.Context, size *entity.PetSize) error {
+	ctx, cancel := r.queryContext(ctx)
+	defer cancel()
+
+	_, err := r.DB.ExecContext(ctx, "INSERT INTO pet_sizes (name, position) VALUES (?, ?)", size.Name, size.Position)
+
+	return translateError(ctx, err)
+}
+
+// UpdatePetSize renames the size and changes its position, the foreign key renames it on the pets.
+//
+// MySQL doesn't count the rows left as is: 0 is returned when nothing changes.
+func (r *petRepository) UpdatePetSize(ctx context.Context, name string, size *entity.PetSize) (int, error) {
+	return r.updateReference(ctx, "pet_size", name, size.Name,
+		"UPDATE pet_sizes SET name = ?, position = ? WHERE name = ?", size.Name, size.Position, name)
+}
+
+// DeletePetSize deletes the size, ErrInUse is returned while a pet has it.
+func (r *petRepository) DeletePetSize(ctx context.Context, name string) (int, error) {
+	return r.deleteReference(ctx, "DELETE FROM pet_sizes WHERE name = ?", name)
+}
+
+// GetPetsByPetSize returns the pets of the size, in the trash or not, ordered by ID.
+func (r *petRepository) GetPetsByPetSize(ctx context.Context, name string) ([]entity.Pet, error) {
+	return r.getPetsByReference(ctx, "pet_size", name)
+}
+
+// getPetsByReference returns the pets whose column has the name, in the trash or not,
+// through the index of the column.
+func (r *petRepository) getPetsByReference(ctx context.Context, column string, name string) ([]entity.Pet, error) {
+	ctx, cancel := r.queryContext(ctx)
+	defer cancel()
+
+	rows, err := r.DB.QueryContext(ctx, "SELECT "+petColumns+" FROM pets WHERE "+column+" = ? ORDER BY id", name)
+	if err != nil {
+		return nil, translateError(ctx, err)
+	}
+	defer rows.Close()
+
+	return scanPets(ctx, rows)
+}
+
+// execReference runs a change of a species or a size and returns the number of rows affected.
+func (r *petRepository) execReference(ctx context.Context, query string, args ...interface{}) (int, error) {
+	ctx, cancel := r.queryContext(ctx)
+	defer cancel()
+
+	result, err := r.DB.ExecContext(ctx, query, args...)
+	if err != nil {
+		return 0, translateError(ctx, err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return 0, translateError(ctx, err)
+	}
+
+	return int(rowsAffected), nil
+}
+
+// updateReference runs the update of a species or a size. A rename changes the version
+// and the update time of the pets whose column has the name, in the same transaction.
+func (r *petRepository) updateReference(ctx context.Context, column string, name string, newName string, query string, args ...interface{}) (int, error) {
+	var affected int
+
+	err := r.inTransaction(ctx, func(tx *petRepository) error {
+		if newName != name {
+			_, err := tx.execReference(ctx, "UPDATE pets SET version = version + 1, updated_at = ? WHERE "+column+" = ?", now(), name)
+			if err != nil {
+				return err
+			}
+		}
+
+		var err error
+		affected, err = tx.execReference(ctx, query, args...)
+
+		return err
+	})
+
+	return affected, err
+}
+
+// deleteReference deletes a species or a size. The only constraint a deletion can
+// violate is the foreign key of the pets, which SQLite reports as any other.
+func (r *petRepository) deleteReference(ctx context.Context, query string, name string) (int, error) {
+	affected, err := r.execReference(ctx, query, name)
+	if errors.Is(err, ErrConstraint) && !errors.Is(err, ErrInUse) {
+		return 0, fmt.Errorf("%w: %w", ErrInUse, err)
+	}
+
+	return affected, err
+}
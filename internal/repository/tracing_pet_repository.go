@@ -0,0 +1,337 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/japhy-tech/backend-test/internal/entity"
+	"github.com/japhy-tech/backend-test/internal/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tracingPetRepository wraps every call to a PetRepository in a span, along with
+// the number of rows returned or affected.
+type tracingPetRepository struct {
+	next PetRepository
+}
+
+// NewTracingPetRepository returns the repository tracing the calls to next,
+// including within its transactions.
+func NewTracingPetRepository(next PetRepository) PetRepository {
+	return &tracingPetRepository{next: next}
+}
+
+// rowsReturned is the number of rows read by a query.
+func rowsReturned(span trace.Span, count int) {
+	span.SetAttributes(attribute.Int("db.rows_returned", count))
+}
+
+// rowsAffected is the number of rows changed by a statement.
+func rowsAffected(span trace.Span, count int) {
+	span.SetAttributes(attribute.Int("db.rows_affected", count))
+}
+
+func (r *tracingPetRepository) Create(ctx context.Context, pet *entity.CreatePet) (int, error) {
+	ctx, span := tracing.Start(ctx, "PetRepository.Create")
+	id, err := r.next.Create(ctx, pet)
+	tracing.End(span, err)
+
+	return id, err
+}
+
+func (r *tracingPetRepository) GetAll(ctx context.Context, page *entity.PageRequest) ([]entity.Pet, error) {
+	ctx, span := tracing.Start(ctx, "PetRepository.GetAll")
+	pets, err := r.next.GetAll(ctx, page)
+	rowsReturned(span, len(pets))
+	tracing.End(span, err)
+
+	return pets, err
+}
+
+func (r *tracingPetRepository) Count(ctx context.Context) (int, error) {
+	ctx, span := tracing.Start(ctx, "PetRepository.Count")
+	count, err := r.next.Count(ctx)
+	tracing.End(span, err)
+
+	return count, err
+}
+
+func (r *tracingPetRepository) CountBySpecies(ctx context.Context) (map[string]int, error) {
+	ctx, span := tracing.Start(ctx, "PetRepository.CountBySpecies")
+	counts, err := r.next.CountBySpecies(ctx)
+	rowsReturned(span, len(counts))
+	tracing.End(span, err)
+
+	return counts, err
+}
+
+func (r *tracingPetRepository) GetByID(ctx context.Context, id int) (*entity.Pet, error) {
+	ctx, span := tracing.Start(ctx, "PetRepository.GetByID", attribute.Int("pet.id", id))
+	pet, err := r.next.GetByID(ctx, id)
+	tracing.End(span, err)
+
+	return pet, err
+}
+
+func (r *tracingPetRepository) Update(ctx context.Context, id int, pet *entity.UpdatePet, version int) (int, error) {
+	ctx, span := tracing.Start(ctx, "PetRepository.Update", attribute.Int("pet.id", id))
+	affected, err := r.next.Update(ctx, id, pet, version)
+	rowsAffected(span, affected)
+	tracing.End(span, err)
+
+	return affected, err
+}
+
+func (r *tracingPetRepository) Patch(ctx context.Context, id int, pet *entity.PatchPet, version int) (int, error) {
+	ctx, span := tracing.Start(ctx, "PetRepository.Patch", attribute.Int("pet.id", id))
+	affected, err := r.next.Patch(ctx, id, pet, version)
+	rowsAffected(span, affected)
+	tracing.End(span, err)
+
+	return affected, err
+}
+
+func (r *tracingPetRepository) Delete(ctx context.Context, id int, version int) (int, error) {
+	ctx, span := tracing.Start(ctx, "PetRepository.Delete", attribute.Int("pet.id", id))
+	affected, err := r.next.Delete(ctx, id, version)
+	rowsAffected(span, affected)
+	tracing.End(span, err)
+
+	return affected, err
+}
+
+func (r *tracingPetRepository) SearchPets(ctx context.Context, searchPets *entity.SearchPets, page *entity.PageRequest) ([]entity.Pet, error) {
+	ctx, span := tracing.Start(ctx, "PetRepository.SearchPets")
+	pets, err := r.next.SearchPets(ctx, searchPets, page)
+	rowsReturned(span, len(pets))
+	tracing.End(span, err)
+
+	return pets, err
+}
+
+func (r *tracingPetRepository) CountSearchPets(ctx context.Context, searchPets *entity.SearchPets) (int, error) {
+	ctx, span := tracing.Start(ctx, "PetRepository.CountSearchPets")
+	count, err := r.next.CountSearchPets(ctx, searchPets)
+	tracing.End(span, err)
+
+	return count, err
+}
+
+func (r *tracingPetRepository) GetDeleted(ctx context.Context, page *entity.PageRequest) ([]entity.Pet, error) {
+	ctx, span := tracing.Start(ctx, "PetRepository.GetDeleted")
+	pets, err := r.next.GetDeleted(ctx, page)
+	rowsReturned(span, len(pets))
+	tracing.End(span, err)
+
+	return pets, err
+}
+
+func (r *tracingPetRepository) CountDeleted(ctx context.Context) (int, error) {
+	ctx, span := tracing.Start(ctx, "PetRepository.CountDeleted")
+	count, err := r.next.CountDeleted(ctx)
+	tracing.End(span, err)
+
+	return count, err
+}
+
+func (r *tracingPetRepository) Restore(ctx context.Context, id int) (int, error) {
+	ctx, span := tracing.Start(ctx, "PetRepository.Restore", attribute.Int("pet.id", id))
+	affected, err := r.next.Restore(ctx, id)
+	rowsAffected(span, affected)
+	tracing.End(span, err)
+
+	return affected, err
+}
+
+func (r *tracingPetRepository) Purge(ctx context.Context, deletedBefore time.Time) (int, error) {
+	ctx, span := tracing.Start(ctx, "PetRepository.Purge")
+	affected, err := r.next.Purge(ctx, deletedBefore)
+	rowsAffected(span, affected)
+	tracing.End(span, err)
+
+	return affected, err
+}
+
+func (r *tracingPetRepository) InsertAuditEntry(ctx context.Context, entry *entity.AuditEntry) error {
+	ctx, span := tracing.Start(ctx, "PetRepository.InsertAuditEntry")
+	err := r.next.InsertAuditEntry(ctx, entry)
+	tracing.End(span, err)
+
+	return err
+}
+
+func (r *tracingPetRepository) GetAuditEntries(ctx context.Context, filter *entity.AuditFilter, page *entity.PageRequest) ([]entity.AuditEntry, error) {
+	ctx, span := tracing.Start(ctx, "PetRepository.GetAuditEntries")
+	entries, err := r.next.GetAuditEntries(ctx, filter, page)
+	rowsReturned(span, len(entries))
+	tracing.End(span, err)
+
+	return entries, err
+}
+
+func (r *tracingPetRepository) CountAuditEntries(ctx context.Context, filter *entity.AuditFilter) (int, error) {
+	ctx, span := tracing.Start(ctx, "PetRepository.CountAuditEntries")
+	count, err := r.next.CountAuditEntries(ctx, filter)
+	tracing.End(span, err)
+
+	return count, err
+}
+
+func (r *tracingPetRepository) GetAllSpecies(ctx context.Context) ([]entity.Species, error) {
+	ctx, span := tracing.Start(ctx, "PetRepository.GetAllSpecies")
+	species, err := r.next.GetAllSpecies(ctx)
+	rowsReturned(span, len(species))
+	tracing.End(span, err)
+
+	return species, err
+}
+
+func (r *tracingPetRepository) CreateSpecies(ctx context.Context, species *entity.Species) error {
+	ctx, span := tracing.Start(ctx, "PetRepository.CreateSpecies", attribute.String("species.name", species.Name))
+	err := r.next.CreateSpecies(ctx, species)
+	tracing.End(span, err)
+
+	return err
+}
+
+func (r *tracingPetRepository) UpdateSpecies(ctx context.Context, name string, species *entity.Species) (int, error) {
+	ctx, span := tracing.Start(ctx, "PetRepository.UpdateSpecies", attribute.String("species.name", name))
+	affected, err := r.next.UpdateSpecies(ctx, name, species)
+	rowsAffected(span, affected)
+	tracing.End(span, err)
+
+	return affected, err
+}
+
+func (r *tracingPetRepository) DeleteSpecies(ctx context.Context, name string) (int, error) {
+	ctx, span := tracing.Start(ctx, "PetRepository.DeleteSpecies", attribute.String("species.name", name))
+	affected, err := r.next.DeleteSpecies(ctx, name)
+	rowsAffected(span, affected)
+	tracing.End(span, err)
+
+	return affected, err
+}
+
+func (r *tracingPetRepository) GetPetsBySpecies(ctx context.Context, name string) ([]entity.Pet, error) {
+	ctx, span := tracing.Start(ctx, "PetRepository.GetPetsBySpecies", attribute.String("species.name", name))
+	pets, err := r.next.GetPetsBySpecies(ctx, name)
+	rowsReturned(span, len(pets))
+	tracing.End(span, err)
+
+	return pets, err
+}
+
+func (r *tracingPetRepository) GetAllPetSizes(ctx context.Context) ([]entity.PetSize, error) {
+	ctx, span := tracing.Start(ctx, "PetRepository.GetAllPetSizes")
+	sizes, err := r.next.GetAllPetSizes(ctx)
+	rowsReturned(span, len(sizes))
+	tracing.End(span, err)
+
+	return sizes, err
+}
+
+func (r *tracingPetRepository) CreatePetSize(ctx context.Context, size *entity.PetSize) error {
+	ctx, span := tracing.Start(ctx, "PetRepository.CreatePetSize", attribute.String("pet_size.name", size.Name))
+	err := r.next.CreatePetSize(ctx, size)
+	tracing.End(span, err)
+
+	return err
+}
+
+func (r *tracingPetRepository) UpdatePetSize(ctx context.Context, name string, size *entity.PetSize) (int, error) {
+	ctx, span := tracing.Start(ctx, "PetRepository.UpdatePetSize", attribute.String("pet_size.name", name))
+	affected, err := r.next.UpdatePetSize(ctx, name, size)
+	rowsAffected(span, affected)
+	tracing.End(span, err)
+
+	return affected, err
+}
+
+func (r *tracingPetRepository) DeletePetSize(ctx context.Context, name string) (int, error) {
+	ctx, span := tracing.Start(ctx, "PetRepository.DeletePetSize", attribute.String("pet_size.name", name))
+	affected, err := r.next.DeletePetSize(ctx, name)
+	rowsAffected(span, affected)
+	tracing.End(span, err)
+
+	return affected, err
+}
+
+func (r *tracingPetRepository) GetPetsByPetSize(ctx context.Context, name string) ([]entity.Pet, error) {
+	ctx, span := tracing.Start(ctx, "PetRepository.GetPetsByPetSize", attribute.String("pet_size.name", name))
+	pets, err := r.next.GetPetsByPetSize(ctx, name)
+	rowsReturned(span, len(pets))
+	tracing.End(span, err)
+
+	return pets, err
+}
+
+func (r *tracingPetRepository) WithTransaction(ctx context.Context, fn func(repo PetRepository) error) error {
+	ctx, span := tracing.Start(ctx, "PetRepository.WithTransaction")
+	err := r.next.WithTransaction(ctx, func(repo PetRepository) error {
+		return fn(&tracingPetRepository{next: repo})
+	})
+	tracing.End(span, err)
+
+	return err
+}
+
+func (r *tracingPetRepository) WithSavepoint(ctx context.Context, fn func(repo PetRepository) error) error {
+	ctx, span := tracing.Start(ctx, "PetRepository.WithSavepoint")
+	err := r.next.WithSavepoint(ctx, func(repo PetRepository) error {
+		return fn(&tracingPetRepository{next: repo})
+	})
+	tracing.End(span, err)
+
+	return err
+}
+
+// tracingDB starts a span for every statement, with its SQL and the system of
+// the database (mysql, sqlite).
+type tracingDB struct {
+	dbtx
+	system string
+}
+
+func (db tracingDB) startStatement(ctx context.Context, query string) (context.Context, trace.Span) {
+	return tracing.Tracer().Start(ctx, "sql",
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("db.system", db.system),
+			attribute.String("db.statement", query),
+		),
+	)
+}
+
+func (db tracingDB) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	ctx, span := db.startStatement(ctx, query)
+	result, err := db.dbtx.ExecContext(ctx, query, args...)
+	if err == nil {
+		affected, affectedErr := result.RowsAffected()
+		if affectedErr == nil {
+			rowsAffected(span, int(affected))
+		}
+	}
+	tracing.End(span, err)
+
+	return result, err
+}
+
+// QueryContext ends the span once the query answers, the rows read are counted
+// by the span of the repository call.
+func (db tracingDB) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	ctx, span := db.startStatement(ctx, query)
+	rows, err := db.dbtx.QueryContext(ctx, query, args...)
+	tracing.End(span, err)
+
+	return rows, err
+}
+
+func (db tracingDB) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	ctx, span := db.startStatement(ctx, query)
+	row := db.dbtx.QueryRowContext(ctx, query, args...)
+	tracing.End(span, row.Err())
+
+	return row
+}
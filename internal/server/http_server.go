@@ -0,0 +1,63 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+
+	charmLog "github.com/charmbracelet/log"
+	"github.com/japhy-tech/backend-test/internal/config"
+)
+
+// NewHTTPServer returns a server with the timeouts of the configuration.
+func NewHTTPServer(cfg *config.ServerConfig, handler http.Handler, logger *charmLog.Logger) *http.Server {
+	return &http.Server{
+		Addr:              cfg.Addr(),
+		Handler:           handler,
+		ReadHeaderTimeout: cfg.ReadHeaderTimeout,
+		ReadTimeout:       cfg.ReadTimeout,
+		WriteTimeout:      cfg.WriteTimeout,
+		IdleTimeout:       cfg.IdleTimeout,
+		ErrorLog:          logger.StandardLog(charmLog.StandardLogOptions{ForceLevel: charmLog.ErrorLevel}),
+	}
+}
+
+// Serve serves the requests accepted by the listener until ctx is done, then stops
+// accepting new ones and lets the in-flight ones complete within the shutdown timeout.
+func Serve(ctx context.Context, logger *charmLog.Logger, srv *http.Server, listener net.Listener, shutdownTimeout time.Duration) error {
+	served := make(chan error, 1)
+	go func() {
+		served <- srv.Serve(listener)
+	}()
+
+	logger.Info(fmt.Sprintf("Service started and listen on %s", listener.Addr()))
+
+	select {
+	case err := <-served:
+		return fmt.Errorf("unable to serve: %w", err)
+	case <-ctx.Done():
+	}
+
+	logger.Info(fmt.Sprintf("Shutting down, waiting up to %s for the in-flight requests", shutdownTimeout))
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+
+	err := srv.Shutdown(shutdownCtx)
+	if err != nil {
+		srv.Close()
+		return fmt.Errorf("unable to drain the in-flight requests: %w", err)
+	}
+
+	err = <-served
+	if !errors.Is(err, http.ErrServerClosed) {
+		return fmt.Errorf("unable to serve: %w", err)
+	}
+
+	logger.Info("Service stopped")
+
+	return nil
+}
@@ -3,28 +3,121 @@ package server
 import (
 	"database/sql"
 
+	nethttp "net/http"
+
 	charmLog "github.com/charmbracelet/log"
 	"github.com/gorilla/mux"
+	"github.com/japhy-tech/backend-test/database_actions"
+	"github.com/japhy-tech/backend-test/internal/auth"
+	"github.com/japhy-tech/backend-test/internal/config"
 	"github.com/japhy-tech/backend-test/internal/delivery/http"
+	"github.com/japhy-tech/backend-test/internal/health"
+	"github.com/japhy-tech/backend-test/internal/metrics"
+	"github.com/japhy-tech/backend-test/internal/ratelimit"
 	"github.com/japhy-tech/backend-test/internal/repository"
 	"github.com/japhy-tech/backend-test/internal/usecase"
+	httpSwagger "github.com/swaggo/http-swagger"
 )
 
 type App struct {
 	logger *charmLog.Logger
-	db     *sql.DB
+	// db is nil with the memory backend
+	db            *sql.DB
+	petRepo       repository.PetRepository
+	cfg           *config.Config
+	metrics       *metrics.Metrics
+	authenticator *auth.Authenticator
+	limiter       *ratelimit.Limiter
 }
 
-func NewApp(logger *charmLog.Logger, db *sql.DB) *App {
+// NewApp returns the application storing the pets in petRepo, db being the database
+// of the repository or nil when it has none.
+func NewApp(logger *charmLog.Logger, db *sql.DB, petRepo repository.PetRepository, cfg *config.Config) (*App, error) {
+	authenticator, err := auth.NewAuthenticator(&cfg.Auth)
+	if err != nil {
+		return nil, err
+	}
+
+	petUsecase := usecase.NewPetUsecase(petRepo)
+
+	sources := &metrics.Sources{
+		DB:                 db,
+		DBName:             cfg.Database.Name,
+		CountPetsBySpecies: petUsecase.CountPetsBySpecies,
+	}
+	switch cfg.Database.Backend {
+	case config.DatabaseBackendMySQL:
+		// Only the MySQL database is migrated
+		sources.MigrationVersion = database_actions.MigrationVersion
+	case config.DatabaseBackendSQLite:
+		sources.DBName = cfg.Database.SQLitePath
+	}
+
 	return &App{
-		logger: logger,
-		db:     db,
+		logger:        logger,
+		db:            db,
+		petRepo:       petRepo,
+		cfg:           cfg,
+		metrics:       metrics.New(sources, logger),
+		authenticator: authenticator,
+		limiter:       ratelimit.NewLimiter(ratelimit.NewMemoryStore(), &cfg.RateLimit),
+	}, nil
+}
+
+// RegisterMiddlewares sets the middlewares of every route: the request ID, the
+// access log, the metrics, the tracing and the recovery from panics, in this order.
+func (a *App) RegisterMiddlewares(r *mux.Router) {
+	r.Use(http.RequestID, http.AccessLog(a.logger), http.Metrics(a.metrics), http.Tracing, http.Recover(a.logger))
+}
+
+// RegisterSwagger serves the documentation of the API under /swagger/, with the
+// CORS and the security headers of the API.
+func (a *App) RegisterSwagger(r *mux.Router) {
+	swagger := r.PathPrefix("/swagger").Subrouter()
+	swagger.Use(http.CORS(&a.cfg.CORS), http.SecurityHeaders(a.cfg.Security.HSTSMaxAge))
+	swagger.PathPrefix("/").Handler(httpSwagger.WrapHandler)
+}
+
+// RegisterMetrics serves the metrics in the Prometheus text format on /metrics.
+func (a *App) RegisterMetrics(r *mux.Router) {
+	r.Handle("/metrics", a.metrics.Handler()).Methods(nethttp.MethodGet)
+}
+
+// RegisterHealth serves the liveness on /livez and the readiness on /readyz, which
+// checks the database, if any, along with the given checks.
+func (a *App) RegisterHealth(r *mux.Router, checks ...health.Check) {
+	if a.db != nil {
+		checks = append([]health.Check{health.Database(a.db)}, checks...)
 	}
+
+	checker := health.NewChecker(a.cfg.Server.ReadinessTimeout, checks...)
+	http.NewHealthHandler(r, checker)
 }
 
 // TODO: améliorer cette partie
 func (a *App) RegisterRoutes(r *mux.Router) {
-	petRepo := repository.NewPetRepository(a.db)
-	petUsecase := usecase.NewPetUsecase(petRepo)
+	r.Use(
+		http.CORS(&a.cfg.CORS),
+		http.SecurityHeaders(a.cfg.Security.HSTSMaxAge),
+		http.RequestTimeout(a.cfg.Server.RequestTimeout),
+	)
+	// The failed authentications have a budget of their own, the other requests the budget of their client
+	if a.cfg.RateLimit.Enabled {
+		r.Use(http.RateLimitAuthentication(a.limiter, a.logger), http.Authenticate(a.authenticator, a.logger), http.RateLimit(a.limiter, a.logger))
+	} else {
+		r.Use(http.Authenticate(a.authenticator, a.logger))
+	}
+
+	petRepo := repository.NewTracingPetRepository(a.petRepo)
+	petUsecase := usecase.NewTracingPetUsecase(usecase.NewPetUsecase(petRepo))
 	http.NewPetHandler(r, petUsecase, a.logger)
+
+	auditUsecase := usecase.NewAuditUsecase(petRepo)
+	http.NewAuditHandler(r, auditUsecase, a.logger)
+
+	referenceUsecase := usecase.NewReferenceUsecase(petRepo)
+	http.NewReferenceHandler(r, referenceUsecase, a.logger)
+
+	// The preflight requests are answered by the CORS middleware, which only runs on a matched route
+	r.Methods(nethttp.MethodOptions).HandlerFunc(http.MethodNotAllowed)
 }
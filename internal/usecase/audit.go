@@ -0,0 +1,106 @@
+package usecase
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/japhy-tech/backend-test/internal/entity"
+	"github.com/japhy-tech/backend-test/internal/repository"
+)
+
+// AnonymousActor is recorded in the audit log when the context carries no actor.
+const AnonymousActor = "anonymous"
+
+type actorKey struct{}
+
+// WithActor returns a context recording the changes made with it as done by the actor.
+func WithActor(ctx context.Context, actor string) context.Context {
+	return context.WithValue(ctx, actorKey{}, actor)
+}
+
+// ActorFromContext returns the actor set by WithActor, or AnonymousActor.
+func ActorFromContext(ctx context.Context) string {
+	actor, ok := ctx.Value(actorKey{}).(string)
+	if !ok || actor == "" {
+		return AnonymousActor
+	}
+
+	return actor
+}
+
+// AuditUsecase reads the audit log.
+type AuditUsecase interface {
+	GetAuditEntries(ctx context.Context, filter *entity.AuditFilter, page *entity.PageRequest) (*entity.AuditPage, error)
+}
+
+type auditUsecase struct {
+	petRepo repository.PetRepository
+}
+
+func NewAuditUsecase(petRepo repository.PetRepository) AuditUsecase {
+	return &auditUsecase{petRepo: petRepo}
+}
+
+func (u *auditUsecase) GetAuditEntries(ctx context.Context, filter *entity.AuditFilter, page *entity.PageRequest) (*entity.AuditPage, error) {
+	return getAuditPage(ctx, u.petRepo, filter, page)
+}
+
+// GetPetHistory returns the changes made to the pet, even once it has been purged.
+func (u *petUsecase) GetPetHistory(ctx context.Context, id int, page *entity.PageRequest) (*entity.AuditPage, error) {
+	filter := &entity.AuditFilter{EntityType: entity.AuditEntityPet, EntityID: id}
+
+	return getAuditPage(ctx, u.petRepo, filter, page)
+}
+
+func getAuditPage(ctx context.Context, repo repository.PetRepository, filter *entity.AuditFilter, page *entity.PageRequest) (*entity.AuditPage, error) {
+	entries, err := repo.GetAuditEntries(ctx, filter, lookAhead(page))
+	if err != nil {
+		return nil, fromRepository(err)
+	}
+
+	total, err := repo.CountAuditEntries(ctx, filter)
+	if err != nil {
+		return nil, fromRepository(err)
+	}
+
+	auditPage := &entity.AuditPage{
+		Entries: entries,
+		Limit:   page.Limit,
+		Total:   total,
+	}
+
+	if len(entries) > page.Limit {
+		auditPage.Entries = entries[:page.Limit]
+		auditPage.NextCursor = entries[page.Limit-1].ID
+	}
+
+	return auditPage, nil
+}
+
+// audit records a change of a pet, before is nil for a creation and after for a deletion.
+func (u *petUsecase) audit(ctx context.Context, action string, id int, before *entity.Pet, after *entity.Pet) error {
+	entry := &entity.AuditEntry{
+		EntityType: entity.AuditEntityPet,
+		EntityID:   id,
+		Action:     action,
+		Actor:      ActorFromContext(ctx),
+		CreatedAt:  time.Now().UTC(),
+	}
+
+	var err error
+	if before != nil {
+		entry.Before, err = json.Marshal(before)
+		if err != nil {
+			return err
+		}
+	}
+	if after != nil {
+		entry.After, err = json.Marshal(after)
+		if err != nil {
+			return err
+		}
+	}
+
+	return fromRepository(u.petRepo.InsertAuditEntry(ctx, entry))
+}
@@ -0,0 +1,66 @@
+package usecase
+
+import (
+	"errors"
+
+	"github.com/japhy-tech/backend-test/internal/repository"
+)
+
+var (
+	ErrNotFound    = errors.New("not found")
+	ErrConflict    = errors.New("conflict")
+	ErrValidation  = errors.New("validation failed")
+	ErrUnavailable = errors.New("service unavailable")
+	ErrTimeout     = errors.New("timeout")
+
+	ErrPreconditionFailed = errors.New("precondition failed")
+)
+
+// Error is a domain error of one of the kinds above.
+//
+// Message is safe to send to clients while Err keeps the cause for the logs.
+type Error struct {
+	Kind    error
+	Message string
+	Err     error
+}
+
+func (e *Error) Error() string {
+	if e.Err != nil {
+		return e.Message + ": " + e.Err.Error()
+	}
+
+	return e.Message
+}
+
+func (e *Error) Is(target error) bool {
+	return target == e.Kind
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// fromRepository turns the repository errors into domain errors, other errors
+// (including domain errors) are returned as is.
+func fromRepository(err error) error {
+	var domainErr *Error
+	if errors.As(err, &domainErr) {
+		return err
+	}
+
+	switch {
+	case errors.Is(err, repository.ErrNotFound):
+		return &Error{Kind: ErrNotFound, Message: "pet not found", Err: err}
+	case errors.Is(err, repository.ErrDuplicate):
+		return &Error{Kind: ErrConflict, Message: "pet already exists", Err: err}
+	case errors.Is(err, repository.ErrConstraint):
+		return &Error{Kind: ErrValidation, Message: "pet rejected by the database constraints", Err: err}
+	case errors.Is(err, repository.ErrTimeout):
+		return &Error{Kind: ErrTimeout, Message: "request timed out, please retry later", Err: err}
+	case errors.Is(err, repository.ErrUnavailable):
+		return &Error{Kind: ErrUnavailable, Message: "database unavailable, please retry later", Err: err}
+	}
+
+	return err
+}
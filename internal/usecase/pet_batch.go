@@ -0,0 +1,164 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+
+	"github.com/japhy-tech/backend-test/internal/entity"
+	"github.com/japhy-tech/backend-test/internal/repository"
+)
+
+const (
+	BatchSucceeded  = "succeeded"
+	BatchFailed     = "failed"
+	BatchRolledBack = "rolled_back"
+	BatchSkipped    = "skipped"
+)
+
+// BatchResult reports the outcome of one operation of a batch.
+//
+// In atomic mode, the operations that succeeded before a failure are rolled back
+// and the ones after it are skipped.
+type BatchResult struct {
+	Index   int          `json:"index"`
+	Op      string       `json:"op"`
+	ID      int          `json:"id,omitempty"`
+	Status  string       `json:"status" enums:"succeeded,failed,rolled_back,skipped"`
+	Pet     *entity.Pet  `json:"pet,omitempty"`
+	Message string       `json:"message,omitempty"`
+	Errors  []FieldError `json:"errors,omitempty"`
+}
+
+type BatchReport struct {
+	Mode      string        `json:"mode"`
+	Committed bool          `json:"committed"`
+	Succeeded int           `json:"succeeded"`
+	Failed    int           `json:"failed"`
+	Results   []BatchResult `json:"results"`
+}
+
+// errBatchAborted rolls back an atomic batch after a failed operation.
+var errBatchAborted = errors.New("batch aborted")
+
+// BatchPets applies the operations in a single transaction.
+//
+// Only the domain errors make an operation fail: any other error aborts the whole
+// batch and is returned. In best_effort mode, every operation runs within a savepoint
+// so that nothing is left of the ones that fail.
+func (u *petUsecase) BatchPets(ctx context.Context, batch *entity.BatchPets) (*BatchReport, error) {
+	report := &BatchReport{
+		Mode:    batch.Mode,
+		Results: make([]BatchResult, len(batch.Operations)),
+	}
+	if report.Mode == "" {
+		report.Mode = entity.BatchModeAtomic
+	}
+
+	err := u.inTransaction(ctx, func(txUsecase *petUsecase) error {
+		for i := range batch.Operations {
+			operation := &batch.Operations[i]
+			result := &report.Results[i]
+			result.Index = i
+			result.Op = operation.Op
+			result.ID = operation.ID
+
+			var pet *entity.Pet
+			var err error
+			if report.Mode == entity.BatchModeAtomic {
+				pet, err = txUsecase.applyBatchOperation(ctx, operation)
+			} else {
+				err = txUsecase.petRepo.WithSavepoint(ctx, func(repo repository.PetRepository) error {
+					pet, err = (&petUsecase{petRepo: repo}).applyBatchOperation(ctx, operation)
+					return err
+				})
+			}
+			if err != nil {
+				if !isOperationFailure(err) {
+					return err
+				}
+
+				result.fail(err)
+				if report.Mode == entity.BatchModeAtomic {
+					return errBatchAborted
+				}
+				continue
+			}
+
+			result.Status = BatchSucceeded
+			result.Pet = pet
+			if pet != nil {
+				result.ID = pet.ID
+			}
+		}
+
+		return nil
+	})
+
+	if errors.Is(err, errBatchAborted) {
+		for i := range report.Results {
+			result := &report.Results[i]
+			switch result.Status {
+			case BatchSucceeded:
+				result.Status = BatchRolledBack
+				result.Pet = nil
+			case "":
+				result.Index = i
+				result.Op = batch.Operations[i].Op
+				result.ID = batch.Operations[i].ID
+				result.Status = BatchSkipped
+			}
+		}
+		report.Failed = 1
+
+		return report, nil
+	}
+	if err != nil {
+		return nil, fromRepository(err)
+	}
+
+	report.Committed = true
+	for _, result := range report.Results {
+		if result.Status == BatchSucceeded {
+			report.Succeeded++
+		} else {
+			report.Failed++
+		}
+	}
+
+	return report, nil
+}
+
+func (u *petUsecase) applyBatchOperation(ctx context.Context, operation *entity.BatchOperation) (*entity.Pet, error) {
+	switch operation.Op {
+	case entity.BatchOpCreate:
+		return u.CreatePet(ctx, (*entity.CreatePet)(operation.Pet))
+	case entity.BatchOpUpdate:
+		return u.UpdatePet(ctx, operation.ID, operation.Pet, operation.Version)
+	case entity.BatchOpDelete:
+		return nil, u.DeletePet(ctx, operation.ID, operation.Version)
+	}
+
+	return nil, &Error{Kind: ErrValidation, Message: "unknown operation " + operation.Op}
+}
+
+// isOperationFailure tells whether the error only concerns the operation and
+// leaves the transaction usable.
+func isOperationFailure(err error) bool {
+	return errors.Is(err, ErrValidation) ||
+		errors.Is(err, ErrNotFound) ||
+		errors.Is(err, ErrConflict) ||
+		errors.Is(err, ErrPreconditionFailed)
+}
+
+func (r *BatchResult) fail(err error) {
+	r.Status = BatchFailed
+
+	var validationErr *ValidationError
+	var domainErr *Error
+	if errors.As(err, &validationErr) {
+		r.Message = ErrValidation.Error()
+		r.Errors = validationErr.Errors
+	} else if errors.As(err, &domainErr) {
+		r.Message = domainErr.Message
+	}
+}
@@ -0,0 +1,251 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/japhy-tech/backend-test/internal/entity"
+)
+
+// ImportedPet is a pet of the file that is not stored yet.
+type ImportedPet struct {
+	Line int              `json:"line"`
+	Pet  entity.CreatePet `json:"pet"`
+}
+
+// PetChange is a pet of the file that differs from the stored one.
+type PetChange struct {
+	Line   int        `json:"line"`
+	Before entity.Pet `json:"before"`
+	After  entity.Pet `json:"after"`
+}
+
+// ImportReport is the difference between an imported file and the stored pets.
+//
+// Pets are matched on their species and name, the IDs of the file are ignored.
+type ImportReport struct {
+	DryRun    bool          `json:"dry_run"`
+	Applied   bool          `json:"applied"`
+	New       []ImportedPet `json:"new"`
+	Changed   []PetChange   `json:"changed"`
+	Removed   []entity.Pet  `json:"removed"`
+	Unchanged int           `json:"unchanged"`
+}
+
+// ExportPets calls write with every pet, one page at a time.
+func (u *petUsecase) ExportPets(ctx context.Context, write func(pets []entity.Pet) error) error {
+	page := entity.NewPageRequest()
+
+	for {
+		pets, err := u.GetPets(ctx, page)
+		if err != nil {
+			return err
+		}
+
+		err = write(pets.Pets)
+		if err != nil {
+			return err
+		}
+
+		if pets.NextCursor == 0 {
+			return nil
+		}
+		page.Cursor = pets.NextCursor
+	}
+}
+
+// storedPets returns every stored pet, those in the trash first then the others.
+func (u *petUsecase) storedPets(ctx context.Context) ([]entity.Pet, error) {
+	var stored []entity.Pet
+
+	page := entity.NewPageRequest()
+	for {
+		pets, err := u.GetDeletedPets(ctx, page)
+		if err != nil {
+			return nil, err
+		}
+
+		stored = append(stored, pets.Pets...)
+
+		if pets.NextCursor == 0 {
+			break
+		}
+		page.Cursor = pets.NextCursor
+	}
+
+	err := u.ExportPets(ctx, func(pets []entity.Pet) error {
+		stored = append(stored, pets...)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return stored, nil
+}
+
+// ImportPets makes the stored pets match the records: the new pets are created,
+// the changed ones updated and the missing ones deleted, in a single transaction.
+//
+// A file without any pet is rejected, rather than deleting every stored pet.
+//
+// With dryRun, only the difference is returned.
+func (u *petUsecase) ImportPets(ctx context.Context, records []entity.PetRecord, dryRun bool) (*ImportReport, error) {
+	validator, err := u.validator(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	err = validateRecords(validator, records)
+	if err != nil {
+		return nil, err
+	}
+
+	if dryRun {
+		report, err := u.diffRecords(ctx, records)
+		if err != nil {
+			return nil, err
+		}
+		report.DryRun = true
+
+		return report, nil
+	}
+
+	var report *ImportReport
+	err = u.inTransaction(ctx, func(txUsecase *petUsecase) error {
+		report, err = txUsecase.diffRecords(ctx, records)
+		if err != nil {
+			return err
+		}
+
+		return txUsecase.applyImport(ctx, report)
+	})
+	if err != nil {
+		return nil, fromRepository(err)
+	}
+	report.Applied = true
+
+	return report, nil
+}
+
+// validateRecords checks every record, the errors are reported with their line.
+// As in the seed file, a weight of 0 is unknown, so that the exports can be imported back.
+func validateRecords(validator *PetValidator, records []entity.PetRecord) error {
+	if len(records) == 0 {
+		return &ValidationError{Errors: []FieldError{{Field: "file", Message: "has no pet, importing it would delete every stored pet"}}}
+	}
+
+	var errors []FieldError
+	lines := make(map[string]int, len(records))
+
+	for _, record := range records {
+		err := validator.ValidateSeed(&record.Pet)
+		if validationErr, ok := err.(*ValidationError); ok {
+			for _, fieldError := range validationErr.Errors {
+				fieldError.Line = record.Line
+				errors = append(errors, fieldError)
+			}
+		}
+
+		key := record.Pet.Key()
+		if line, ok := lines[key]; ok {
+			errors = append(errors, FieldError{
+				Line:    record.Line,
+				Field:   "name",
+				Message: fmt.Sprintf("%s is already on line %d", record.Pet.Name, line),
+			})
+		}
+		lines[key] = record.Line
+	}
+
+	if len(errors) > 0 {
+		return &ValidationError{Errors: errors}
+	}
+
+	return nil
+}
+
+func (u *petUsecase) diffRecords(ctx context.Context, records []entity.PetRecord) (*ImportReport, error) {
+	stored := make(map[string]entity.Pet)
+	err := u.ExportPets(ctx, func(pets []entity.Pet) error {
+		for _, pet := range pets {
+			stored[pet.Key()] = pet
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	report := &ImportReport{
+		New:     []ImportedPet{},
+		Changed: []PetChange{},
+		Removed: []entity.Pet{},
+	}
+
+	for _, record := range records {
+		key := record.Pet.Key()
+		before, ok := stored[key]
+		if !ok {
+			report.New = append(report.New, ImportedPet{Line: record.Line, Pet: record.Pet})
+			continue
+		}
+		delete(stored, key)
+
+		after := before
+		after.PetSize = record.Pet.PetSize
+		after.AverageMaleAdultWeight = record.Pet.AverageMaleAdultWeight
+		after.AverageFemaleAdultWeight = record.Pet.AverageFemaleAdultWeight
+
+		if after == before {
+			report.Unchanged++
+			continue
+		}
+		report.Changed = append(report.Changed, PetChange{Line: record.Line, Before: before, After: after})
+	}
+
+	for _, pet := range stored {
+		report.Removed = append(report.Removed, pet)
+	}
+	sortPetsByID(report.Removed)
+
+	return report, nil
+}
+
+func (u *petUsecase) applyImport(ctx context.Context, report *ImportReport) error {
+	for _, imported := range report.New {
+		_, err := u.createPet(ctx, &imported.Pet)
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, change := range report.Changed {
+		_, err := u.updatePet(ctx, change.Before.ID, &entity.UpdatePet{
+			Species:                  change.After.Species,
+			PetSize:                  change.After.PetSize,
+			Name:                     change.After.Name,
+			AverageMaleAdultWeight:   change.After.AverageMaleAdultWeight,
+			AverageFemaleAdultWeight: change.After.AverageFemaleAdultWeight,
+		}, change.Before.Version)
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, pet := range report.Removed {
+		err := u.DeletePet(ctx, pet.ID, pet.Version)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func sortPetsByID(pets []entity.Pet) {
+	sort.Slice(pets, func(i, j int) bool {
+		return pets[i].ID < pets[j].ID
+	})
+}
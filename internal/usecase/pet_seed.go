@@ -0,0 +1,211 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/japhy-tech/backend-test/internal/entity"
+	"github.com/japhy-tech/backend-test/internal/repository"
+)
+
+// SeedLine is a line of the seed file. ID is the ID of its pet once stored.
+type SeedLine struct {
+	Line    int          `json:"line"`
+	ID      int          `json:"id,omitempty"`
+	Species string       `json:"species,omitempty"`
+	Name    string       `json:"name,omitempty"`
+	Reason  string       `json:"reason,omitempty"`
+	Errors  []FieldError `json:"errors,omitempty"`
+}
+
+// SeedReport tells what became of every line of the seed file: its pet was inserted,
+// updated, skipped when already stored as is or in the trash, or the line is invalid.
+type SeedReport struct {
+	Inserted []SeedLine `json:"inserted"`
+	Updated  []SeedLine `json:"updated"`
+	Skipped  []SeedLine `json:"skipped"`
+	Invalid  []SeedLine `json:"invalid"`
+}
+
+// PetSeeder upserts the pets of the seed file, so that the edits of the file are
+// applied on every start.
+//
+// The pets are matched on their species and name, the IDs of the file are ignored.
+// The pets in the trash are left there.
+type PetSeeder struct {
+	usecase   *petUsecase
+	batchSize int
+}
+
+// NewPetSeeder returns a seeder writing batchSize lines per transaction.
+func NewPetSeeder(petRepo repository.PetRepository, batchSize int) *PetSeeder {
+	return &PetSeeder{
+		usecase:   &petUsecase{petRepo: petRepo},
+		batchSize: batchSize,
+	}
+}
+
+// Seed validates the records and upserts the valid ones, recording the changes in
+// the audit log along with the actor of the context.
+//
+// The batches already committed are kept when a batch fails, seeding again applies the others.
+func (s *PetSeeder) Seed(ctx context.Context, records []entity.PetRecord) (*SeedReport, error) {
+	report := &SeedReport{
+		Inserted: []SeedLine{},
+		Updated:  []SeedLine{},
+		Skipped:  []SeedLine{},
+		Invalid:  []SeedLine{},
+	}
+
+	validator, err := s.usecase.validator(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	valid := s.validate(validator, records, report)
+
+	stored, err := s.storedPets(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	for start := 0; start < len(valid); start += s.batchSize {
+		batch := valid[start:min(start+s.batchSize, len(valid))]
+
+		// The lines are only reported once their batch is committed
+		batchReport := &SeedReport{}
+		err = s.usecase.inTransaction(ctx, func(tx *petUsecase) error {
+			for _, record := range batch {
+				err := tx.upsertSeed(ctx, record, stored[record.Pet.Key()], batchReport)
+				if err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		report.Inserted = append(report.Inserted, batchReport.Inserted...)
+		report.Updated = append(report.Updated, batchReport.Updated...)
+		report.Skipped = append(report.Skipped, batchReport.Skipped...)
+	}
+
+	return report, nil
+}
+
+// validate reports the invalid records and returns the valid ones. A pet given on
+// several lines is only taken from the first one.
+func (s *PetSeeder) validate(validator *PetValidator, records []entity.PetRecord, report *SeedReport) []entity.PetRecord {
+	var valid []entity.PetRecord
+	lines := make(map[string]int, len(records))
+
+	for _, record := range records {
+		line := seedLine(record.Line, &record.Pet)
+
+		err := validator.ValidateSeed(&record.Pet)
+		if validationErr, ok := err.(*ValidationError); ok {
+			line.Reason = "invalid fields"
+			line.Errors = validationErr.Errors
+			report.Invalid = append(report.Invalid, line)
+			continue
+		}
+
+		key := record.Pet.Key()
+		if first, ok := lines[key]; ok {
+			line.Reason = fmt.Sprintf("%s is already on line %d", record.Pet.Name, first)
+			report.Invalid = append(report.Invalid, line)
+			continue
+		}
+		lines[key] = record.Line
+
+		valid = append(valid, record)
+	}
+
+	return valid
+}
+
+// storedPets returns the stored pets, in the trash or not, by key.
+func (s *PetSeeder) storedPets(ctx context.Context) (map[string]entity.Pet, error) {
+	pets, err := s.usecase.storedPets(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// A pet out of the trash prevails over a pet of the same key in the trash, listed before
+	stored := make(map[string]entity.Pet, len(pets))
+	for _, pet := range pets {
+		stored[pet.Key()] = pet
+	}
+
+	return stored, nil
+}
+
+// upsertSeed creates the pet of the record, or updates the stored one when they differ.
+func (u *petUsecase) upsertSeed(ctx context.Context, record entity.PetRecord, stored entity.Pet, report *SeedReport) error {
+	line := seedLine(record.Line, &record.Pet)
+
+	if stored.ID == 0 {
+		id, err := u.petRepo.Create(ctx, &record.Pet)
+		if err != nil {
+			return fromRepository(err)
+		}
+
+		created, err := u.GetPetByID(ctx, id)
+		if err != nil {
+			return err
+		}
+
+		line.ID = id
+		report.Inserted = append(report.Inserted, line)
+
+		return u.audit(ctx, entity.AuditActionCreate, id, nil, created)
+	}
+
+	line.ID = stored.ID
+
+	if stored.DeletedAt != nil {
+		line.Reason = "in the trash"
+		report.Skipped = append(report.Skipped, line)
+		return nil
+	}
+
+	updated := stored
+	updated.PetSize = record.Pet.PetSize
+	updated.AverageMaleAdultWeight = record.Pet.AverageMaleAdultWeight
+	updated.AverageFemaleAdultWeight = record.Pet.AverageFemaleAdultWeight
+
+	if updated == stored {
+		line.Reason = "unchanged"
+		report.Skipped = append(report.Skipped, line)
+		return nil
+	}
+
+	affected, err := u.petRepo.Update(ctx, stored.ID, &entity.UpdatePet{
+		Species:                  updated.Species,
+		PetSize:                  updated.PetSize,
+		Name:                     updated.Name,
+		AverageMaleAdultWeight:   updated.AverageMaleAdultWeight,
+		AverageFemaleAdultWeight: updated.AverageFemaleAdultWeight,
+	}, stored.Version)
+	if err != nil {
+		return fromRepository(err)
+	}
+	if affected == 0 {
+		return u.explainUnaffected(ctx, stored.ID)
+	}
+
+	after, err := u.GetPetByID(ctx, stored.ID)
+	if err != nil {
+		return err
+	}
+
+	report.Updated = append(report.Updated, line)
+
+	return u.audit(ctx, entity.AuditActionUpdate, stored.ID, &stored, after)
+}
+
+func seedLine(line int, pet *entity.CreatePet) SeedLine {
+	return SeedLine{Line: line, Species: pet.Species, Name: pet.Name}
+}
@@ -1,19 +1,39 @@
 package usecase
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"time"
 
 	"github.com/japhy-tech/backend-test/internal/entity"
 	"github.com/japhy-tech/backend-test/internal/repository"
 )
 
+// PetUsecase manages the pets.
+//
+// Every change is recorded in the audit log, in the same transaction, along with
+// the actor of the context (see WithActor).
+//
+// UpdatePet, PatchPet and DeletePet fail with ErrPreconditionFailed when the version
+// is not 0 and differs from the version of the stored pet.
 type PetUsecase interface {
-	CreatePet(pet *entity.CreatePet) (*entity.Pet, error)
-	GetPets() ([]entity.Pet, error)
-	GetPetByID(id int) (*entity.Pet, error)
-	UpdatePet(id int, pet *entity.UpdatePet) (*entity.Pet, error)
-	DeletePet(id int) error
-	SearchPets(searchPets *entity.SearchPets) ([]entity.Pet, error)
+	CreatePet(ctx context.Context, pet *entity.CreatePet) (*entity.Pet, error)
+	GetPets(ctx context.Context, page *entity.PageRequest) (*entity.PetPage, error)
+	GetPetByID(ctx context.Context, id int) (*entity.Pet, error)
+	CountPetsBySpecies(ctx context.Context) (map[string]int, error)
+	UpdatePet(ctx context.Context, id int, pet *entity.UpdatePet, version int) (*entity.Pet, error)
+	PatchPet(ctx context.Context, id int, pet *entity.PatchPet, version int) (*entity.Pet, error)
+	DeletePet(ctx context.Context, id int, version int) error
+	SearchPets(ctx context.Context, searchPets *entity.SearchPets, page *entity.PageRequest) (*entity.PetPage, error)
+	BatchPets(ctx context.Context, batch *entity.BatchPets) (*BatchReport, error)
+	ExportPets(ctx context.Context, write func(pets []entity.Pet) error) error
+	ImportPets(ctx context.Context, records []entity.PetRecord, dryRun bool) (*ImportReport, error)
+	GetDeletedPets(ctx context.Context, page *entity.PageRequest) (*entity.PetPage, error)
+	RestorePet(ctx context.Context, id int) (*entity.Pet, error)
+	PurgeDeletedPets(ctx context.Context, retention time.Duration) (int, error)
+	GetPetHistory(ctx context.Context, id int, page *entity.PageRequest) (*entity.AuditPage, error)
 }
 
 type petUsecase struct {
@@ -21,70 +41,367 @@ type petUsecase struct {
 }
 
 func NewPetUsecase(petRepo repository.PetRepository) PetUsecase {
-	return &petUsecase{petRepo: petRepo}
+	return &petUsecase{
+		petRepo: petRepo,
+	}
+}
+
+// validator returns the validator of the stored species and sizes.
+func (u *petUsecase) validator(ctx context.Context) (*PetValidator, error) {
+	species, err := u.petRepo.GetAllSpecies(ctx)
+	if err != nil {
+		return nil, fromRepository(err)
+	}
+
+	sizes, err := u.petRepo.GetAllPetSizes(ctx)
+	if err != nil {
+		return nil, fromRepository(err)
+	}
+
+	return NewPetValidator(species, sizes), nil
 }
 
-func (u *petUsecase) CreatePet(pet *entity.CreatePet) (*entity.Pet, error) {
-	id, err := u.petRepo.Create(pet)
+func (u *petUsecase) CreatePet(ctx context.Context, pet *entity.CreatePet) (*entity.Pet, error) {
+	validator, err := u.validator(ctx)
 	if err != nil {
 		return nil, err
 	}
 
-	createdPet := &entity.Pet{
-		ID:                       id,
-		Species:                  pet.Species,
-		PetSize:                  pet.PetSize,
-		Name:                     pet.Name,
-		AverageMaleAdultWeight:   pet.AverageMaleAdultWeight,
-		AverageFemaleAdultWeight: pet.AverageFemaleAdultWeight,
+	err = validator.ValidateCreate(pet)
+	if err != nil {
+		return nil, err
+	}
+
+	return u.createPet(ctx, pet)
+}
+
+// createPet creates a pet already validated.
+func (u *petUsecase) createPet(ctx context.Context, pet *entity.CreatePet) (*entity.Pet, error) {
+	var createdPet *entity.Pet
+	err := u.inTransaction(ctx, func(tx *petUsecase) error {
+		id, err := tx.petRepo.Create(ctx, pet)
+		if err != nil {
+			return fromRepository(err)
+		}
+
+		// Read back for the timestamps set by the repository
+		createdPet, err = tx.GetPetByID(ctx, id)
+		if err != nil {
+			return err
+		}
+
+		return tx.audit(ctx, entity.AuditActionCreate, id, nil, createdPet)
+	})
+	if err != nil {
+		return nil, err
 	}
 
 	return createdPet, nil
 }
 
-func (u *petUsecase) GetPets() ([]entity.Pet, error) {
-	return u.petRepo.GetAll()
+func (u *petUsecase) GetPets(ctx context.Context, page *entity.PageRequest) (*entity.PetPage, error) {
+	pets, err := u.petRepo.GetAll(ctx, lookAhead(page))
+	if err != nil {
+		return nil, fromRepository(err)
+	}
+
+	total, err := u.petRepo.Count(ctx)
+	if err != nil {
+		return nil, fromRepository(err)
+	}
+
+	return newPetPage(pets, page.Limit, total), nil
 }
 
-func (u *petUsecase) GetPetByID(id int) (*entity.Pet, error) {
-	return u.petRepo.GetByID(id)
+func (u *petUsecase) GetPetByID(ctx context.Context, id int) (*entity.Pet, error) {
+	pet, err := u.petRepo.GetByID(ctx, id)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, petNotFound(id)
+	}
+	if err != nil {
+		return nil, fromRepository(err)
+	}
+
+	return pet, nil
 }
 
-func (u *petUsecase) UpdatePet(id int, pet *entity.UpdatePet) (*entity.Pet, error) {
-	rowsAffected, err := u.petRepo.Update(id, pet)
+func (u *petUsecase) CountPetsBySpecies(ctx context.Context) (map[string]int, error) {
+	counts, err := u.petRepo.CountBySpecies(ctx)
+	if err != nil {
+		return nil, fromRepository(err)
+	}
+
+	return counts, nil
+}
+
+func (u *petUsecase) UpdatePet(ctx context.Context, id int, pet *entity.UpdatePet, version int) (*entity.Pet, error) {
+	validator, err := u.validator(ctx)
 	if err != nil {
 		return nil, err
 	}
 
-	if rowsAffected == 0 {
-		return nil, fmt.Errorf("ID not found")
+	err = validator.ValidateUpdate(pet)
+	if err != nil {
+		return nil, err
 	}
 
-	updatedPet := &entity.Pet{
-		ID:                       id,
-		Species:                  pet.Species,
-		PetSize:                  pet.PetSize,
-		Name:                     pet.Name,
-		AverageMaleAdultWeight:   pet.AverageMaleAdultWeight,
-		AverageFemaleAdultWeight: pet.AverageFemaleAdultWeight,
+	return u.updatePet(ctx, id, pet, version)
+}
+
+// updatePet replaces a pet with one already validated.
+func (u *petUsecase) updatePet(ctx context.Context, id int, pet *entity.UpdatePet, version int) (*entity.Pet, error) {
+	var updatedPet *entity.Pet
+	err := u.inTransaction(ctx, func(tx *petUsecase) error {
+		before, err := tx.GetPetByID(ctx, id)
+		if err != nil {
+			return err
+		}
+
+		rowsAffected, err := tx.petRepo.Update(ctx, id, pet, version)
+		if err != nil {
+			return fromRepository(err)
+		}
+
+		if rowsAffected == 0 {
+			return tx.explainUnaffected(ctx, id)
+		}
+
+		updatedPet, err = tx.GetPetByID(ctx, id)
+		if err != nil {
+			return err
+		}
+
+		return tx.audit(ctx, entity.AuditActionUpdate, id, before, updatedPet)
+	})
+	if err != nil {
+		return nil, err
 	}
 
 	return updatedPet, nil
 }
 
-func (u *petUsecase) DeletePet(id int) error {
-	rowsAffected, err := u.petRepo.Delete(id)
+// PatchPet validates the patched fields against the stored pet, since the weight bounds
+// of the stored species apply to the patched weights. The stored values left as is
+// aren't checked, so that a pet stored out of the bounds can still be edited.
+//
+// The patch applies to the version of the validated pet, even when version is 0: a pet
+// changed in the meantime fails with ErrPreconditionFailed rather than being patched
+// without validation.
+func (u *petUsecase) PatchPet(ctx context.Context, id int, pet *entity.PatchPet, version int) (*entity.Pet, error) {
+	var patchedPet *entity.Pet
+	err := u.inTransaction(ctx, func(tx *petUsecase) error {
+		before, err := tx.GetPetByID(ctx, id)
+		if err != nil {
+			return err
+		}
+
+		if version > 0 && before.Version != version {
+			return versionMismatch(id)
+		}
+
+		if pet.IsEmpty() {
+			patchedPet = before
+			return nil
+		}
+
+		validator, err := tx.validator(ctx)
+		if err != nil {
+			return err
+		}
+
+		err = validator.ValidatePatch(before, pet)
+		if err != nil {
+			return err
+		}
+
+		rowsAffected, err := tx.petRepo.Patch(ctx, id, pet, before.Version)
+		if err != nil {
+			return fromRepository(err)
+		}
+
+		if rowsAffected == 0 {
+			return tx.explainUnaffected(ctx, id)
+		}
+
+		patchedPet, err = tx.GetPetByID(ctx, id)
+		if err != nil {
+			return err
+		}
+
+		return tx.audit(ctx, entity.AuditActionUpdate, id, before, patchedPet)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return patchedPet, nil
+}
+
+func (u *petUsecase) DeletePet(ctx context.Context, id int, version int) error {
+	return u.inTransaction(ctx, func(tx *petUsecase) error {
+		before, err := tx.GetPetByID(ctx, id)
+		if err != nil {
+			return err
+		}
+
+		rowsAffected, err := tx.petRepo.Delete(ctx, id, version)
+		if err != nil {
+			return fromRepository(err)
+		}
+
+		if rowsAffected == 0 {
+			return tx.explainUnaffected(ctx, id)
+		}
+
+		return tx.audit(ctx, entity.AuditActionDelete, id, before, nil)
+	})
+}
+
+func (u *petUsecase) GetDeletedPets(ctx context.Context, page *entity.PageRequest) (*entity.PetPage, error) {
+	pets, err := u.petRepo.GetDeleted(ctx, lookAhead(page))
+	if err != nil {
+		return nil, fromRepository(err)
+	}
+
+	total, err := u.petRepo.CountDeleted(ctx)
+	if err != nil {
+		return nil, fromRepository(err)
+	}
+
+	return newPetPage(pets, page.Limit, total), nil
+}
+
+func (u *petUsecase) RestorePet(ctx context.Context, id int) (*entity.Pet, error) {
+	var restoredPet *entity.Pet
+	err := u.inTransaction(ctx, func(tx *petUsecase) error {
+		rowsAffected, err := tx.petRepo.Restore(ctx, id)
+		if err != nil {
+			return fromRepository(err)
+		}
+
+		if rowsAffected == 0 {
+			return &Error{Kind: ErrNotFound, Message: fmt.Sprintf("pet %d not found in the trash", id)}
+		}
+
+		restoredPet, err = tx.GetPetByID(ctx, id)
+		if err != nil {
+			return err
+		}
+
+		return tx.audit(ctx, entity.AuditActionRestore, id, nil, restoredPet)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return restoredPet, nil
+}
+
+// PurgeDeletedPets permanently deletes the pets that have been in the trash for longer
+// than the retention, and returns how many were deleted.
+//
+// The purge is audited as a whole, with the number of purged pets.
+func (u *petUsecase) PurgeDeletedPets(ctx context.Context, retention time.Duration) (int, error) {
+	deletedBefore := time.Now().Add(-retention).UTC()
+
+	var purged int
+	err := u.inTransaction(ctx, func(tx *petUsecase) error {
+		var err error
+		purged, err = tx.petRepo.Purge(ctx, deletedBefore)
+		if err != nil {
+			return fromRepository(err)
+		}
+
+		if purged == 0 {
+			return nil
+		}
+
+		summary, err := json.Marshal(map[string]interface{}{
+			"deleted_before": deletedBefore,
+			"purged":         purged,
+		})
+		if err != nil {
+			return err
+		}
+
+		return fromRepository(tx.petRepo.InsertAuditEntry(ctx, &entity.AuditEntry{
+			EntityType: entity.AuditEntityPet,
+			Action:     entity.AuditActionPurge,
+			Actor:      ActorFromContext(ctx),
+			CreatedAt:  time.Now().UTC(),
+			After:      summary,
+		}))
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return purged, nil
+}
+
+// inTransaction runs fn with a usecase bound to a transaction, see PetRepository.WithTransaction.
+func (u *petUsecase) inTransaction(ctx context.Context, fn func(tx *petUsecase) error) error {
+	err := u.petRepo.WithTransaction(ctx, func(repo repository.PetRepository) error {
+		return fn(&petUsecase{petRepo: repo})
+	})
+
+	return fromRepository(err)
+}
+
+// explainUnaffected tells why a conditional write affected no row:
+// either the pet does not exist or it has been modified in the meantime.
+func (u *petUsecase) explainUnaffected(ctx context.Context, id int) error {
+	_, err := u.GetPetByID(ctx, id)
 	if err != nil {
 		return err
 	}
 
-	if rowsAffected == 0 {
-		return fmt.Errorf("ID not found")
+	return versionMismatch(id)
+}
+
+func petNotFound(id int) error {
+	return &Error{Kind: ErrNotFound, Message: fmt.Sprintf("pet %d not found", id)}
+}
+
+func versionMismatch(id int) error {
+	return &Error{Kind: ErrPreconditionFailed, Message: fmt.Sprintf("pet %d has been modified since it was fetched", id)}
+}
+
+func (u *petUsecase) SearchPets(ctx context.Context, searchPets *entity.SearchPets, page *entity.PageRequest) (*entity.PetPage, error) {
+	pets, err := u.petRepo.SearchPets(ctx, searchPets, lookAhead(page))
+	if err != nil {
+		return nil, fromRepository(err)
 	}
 
-	return nil
+	total, err := u.petRepo.CountSearchPets(ctx, searchPets)
+	if err != nil {
+		return nil, fromRepository(err)
+	}
+
+	return newPetPage(pets, page.Limit, total), nil
 }
 
-func (u *petUsecase) SearchPets(searchPets *entity.SearchPets) ([]entity.Pet, error) {
-	return u.petRepo.SearchPets(searchPets)
+// lookAhead returns a copy of the page request fetching one more item than requested,
+// which tells whether a next page exists.
+func lookAhead(page *entity.PageRequest) *entity.PageRequest {
+	next := *page
+	next.Limit++
+
+	return &next
+}
+
+// newPetPage builds a page from pets fetched with lookAhead.
+func newPetPage(pets []entity.Pet, limit int, total int) *entity.PetPage {
+	page := &entity.PetPage{
+		Pets:  pets,
+		Limit: limit,
+		Total: total,
+	}
+
+	if len(pets) > limit {
+		page.Pets = pets[:limit]
+		page.NextCursor = pets[limit-1].ID
+	}
+
+	return page
 }
@@ -0,0 +1,217 @@
+package usecase
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/japhy-tech/backend-test/internal/entity"
+)
+
+// FieldError describes why the value of a field is invalid.
+//
+// Line is only set for the fields read from a file.
+type FieldError struct {
+	Line    int    `json:"line,omitempty"`
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// ValidationError lists every invalid field of a payload.
+type ValidationError struct {
+	Errors []FieldError
+}
+
+func (e *ValidationError) Error() string {
+	fields := make([]string, len(e.Errors))
+	for i, fieldError := range e.Errors {
+		fields[i] = fieldError.Field
+	}
+
+	return "invalid fields: " + strings.Join(fields, ", ")
+}
+
+func (e *ValidationError) Is(target error) bool {
+	return target == ErrValidation
+}
+
+// WeightBounds are the accepted average adult weights of a species, in grams.
+type WeightBounds struct {
+	Min uint
+	Max uint
+}
+
+// MaxNameLength is the size of the name column.
+const MaxNameLength = 255
+
+// namePattern only accepts lowercase slugs such as "bichon_frize".
+var namePattern = regexp.MustCompile(`^[a-z0-9]+(_[a-z0-9]+)*$`)
+
+// PetValidator checks the pets payloads against the rules of each field.
+type PetValidator struct {
+	species map[string]WeightBounds
+	sizes   []string
+}
+
+// NewPetValidator returns a validator accepting the given species and sizes, the
+// sizes being listed in the given order.
+func NewPetValidator(species []entity.Species, sizes []entity.PetSize) *PetValidator {
+	v := &PetValidator{
+		species: make(map[string]WeightBounds, len(species)),
+		sizes:   make([]string, len(sizes)),
+	}
+
+	for _, s := range species {
+		v.species[s.Name] = WeightBounds{Min: s.MinWeight, Max: s.MaxWeight}
+	}
+	for i, size := range sizes {
+		v.sizes[i] = size.Name
+	}
+
+	return v
+}
+
+func (v *PetValidator) ValidateCreate(pet *entity.CreatePet) error {
+	return v.validate(pet.Species, pet.PetSize, pet.Name, pet.AverageMaleAdultWeight, pet.AverageFemaleAdultWeight)
+}
+
+func (v *PetValidator) ValidateUpdate(pet *entity.UpdatePet) error {
+	return v.validate(pet.Species, pet.PetSize, pet.Name, pet.AverageMaleAdultWeight, pet.AverageFemaleAdultWeight)
+}
+
+// ValidateSeed validates a pet of the seed file or of an imported file, whose weights are 0 when unknown.
+func (v *PetValidator) ValidateSeed(pet *entity.CreatePet) error {
+	err := v.ValidateCreate(pet)
+
+	validationErr, ok := err.(*ValidationError)
+	if !ok {
+		return err
+	}
+
+	var errors []FieldError
+	for _, fieldError := range validationErr.Errors {
+		unknownWeight := fieldError.Field == "average_male_adult_weight" && pet.AverageMaleAdultWeight == 0 ||
+			fieldError.Field == "average_female_adult_weight" && pet.AverageFemaleAdultWeight == 0
+		if !unknownWeight {
+			errors = append(errors, fieldError)
+		}
+	}
+
+	if len(errors) > 0 {
+		return &ValidationError{Errors: errors}
+	}
+
+	return nil
+}
+
+// ValidatePatch validates the fields of the patch, the weights being checked against
+// the bounds of the patched species. The stored values left as is aren't checked,
+// apart from the weights when the species changes.
+func (v *PetValidator) ValidatePatch(stored *entity.Pet, patch *entity.PatchPet) error {
+	patched := *stored
+	patch.ApplyTo(&patched)
+
+	err := v.validate(patched.Species, patched.PetSize, patched.Name, patched.AverageMaleAdultWeight, patched.AverageFemaleAdultWeight)
+
+	validationErr, ok := err.(*ValidationError)
+	if !ok {
+		return err
+	}
+
+	speciesChanged := patched.Species != stored.Species
+	checked := map[string]bool{
+		"species":                     patch.Species != nil,
+		"pet_size":                    patch.PetSize != nil,
+		"name":                        patch.Name != nil,
+		"average_male_adult_weight":   patch.AverageMaleAdultWeight != nil || speciesChanged,
+		"average_female_adult_weight": patch.AverageFemaleAdultWeight != nil || speciesChanged,
+	}
+
+	var errors []FieldError
+	for _, fieldError := range validationErr.Errors {
+		if checked[fieldError.Field] {
+			errors = append(errors, fieldError)
+		}
+	}
+
+	if len(errors) > 0 {
+		return &ValidationError{Errors: errors}
+	}
+
+	return nil
+}
+
+// validate returns a *ValidationError listing every invalid field, or nil.
+func (v *PetValidator) validate(species, petSize, name string, maleWeight, femaleWeight uint) error {
+	var errors []FieldError
+
+	bounds, knownSpecies := v.species[species]
+	if !knownSpecies {
+		errors = append(errors, FieldError{
+			Field:   "species",
+			Message: fmt.Sprintf("must be one of %s", strings.Join(v.speciesNames(), ", ")),
+		})
+	}
+
+	if !v.isSize(petSize) {
+		errors = append(errors, FieldError{
+			Field:   "pet_size",
+			Message: fmt.Sprintf("must be one of %s", strings.Join(v.sizes, ", ")),
+		})
+	}
+
+	if name == "" {
+		errors = append(errors, FieldError{Field: "name", Message: "is required"})
+	} else if len(name) > MaxNameLength {
+		errors = append(errors, FieldError{Field: "name", Message: fmt.Sprintf("must be at most %d characters long", MaxNameLength)})
+	} else if !namePattern.MatchString(name) {
+		errors = append(errors, FieldError{Field: "name", Message: "must only contain lowercase letters and digits separated by underscores"})
+	}
+
+	// Weight bounds depend on the species, they can't be checked for an unknown one
+	if knownSpecies {
+		weights := []struct {
+			field  string
+			weight uint
+		}{
+			{"average_male_adult_weight", maleWeight},
+			{"average_female_adult_weight", femaleWeight},
+		}
+
+		for _, w := range weights {
+			if w.weight < bounds.Min || w.weight > bounds.Max {
+				errors = append(errors, FieldError{
+					Field:   w.field,
+					Message: fmt.Sprintf("must be between %d and %d grams for a %s", bounds.Min, bounds.Max, species),
+				})
+			}
+		}
+	}
+
+	if len(errors) > 0 {
+		return &ValidationError{Errors: errors}
+	}
+
+	return nil
+}
+
+func (v *PetValidator) speciesNames() []string {
+	names := make([]string, 0, len(v.species))
+	for name := range v.species {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+func (v *PetValidator) isSize(petSize string) bool {
+	for _, size := range v.sizes {
+		if size == petSize {
+			return true
+		}
+	}
+
+	return false
+}
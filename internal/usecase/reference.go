@@ -0,0 +1,299 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/japhy-tech/backend-test/internal/entity"
+	"github.com/japhy-tech/backend-test/internal/repository"
+)
+
+// ReferenceUsecase manages the species and the sizes the pets can have.
+//
+// Renaming a species or a size renames it on the pets, as an update of each of them
+// recorded in the audit log along with the actor of the context. Deleting one fails with
+// ErrConflict while a pet has it, in the trash or not. The weight bounds of a
+// species apply to the next changes of its pets, the stored pets are left as is.
+type ReferenceUsecase interface {
+	GetAllSpecies(ctx context.Context) ([]entity.Species, error)
+	GetSpecies(ctx context.Context, name string) (*entity.Species, error)
+	CreateSpecies(ctx context.Context, species *entity.Species) (*entity.Species, error)
+	UpdateSpecies(ctx context.Context, name string, species *entity.Species) (*entity.Species, error)
+	DeleteSpecies(ctx context.Context, name string) error
+	GetAllPetSizes(ctx context.Context) ([]entity.PetSize, error)
+	GetPetSize(ctx context.Context, name string) (*entity.PetSize, error)
+	CreatePetSize(ctx context.Context, size *entity.PetSize) (*entity.PetSize, error)
+	UpdatePetSize(ctx context.Context, name string, size *entity.PetSize) (*entity.PetSize, error)
+	DeletePetSize(ctx context.Context, name string) error
+}
+
+const (
+	referenceSpecies = "species"
+	referencePetSize = "pet size"
+)
+
+type referenceUsecase struct {
+	petRepo repository.PetRepository
+}
+
+func NewReferenceUsecase(petRepo repository.PetRepository) ReferenceUsecase {
+	return &referenceUsecase{petRepo: petRepo}
+}
+
+func (u *referenceUsecase) GetAllSpecies(ctx context.Context) ([]entity.Species, error) {
+	species, err := u.petRepo.GetAllSpecies(ctx)
+	if err != nil {
+		return nil, fromRepository(err)
+	}
+
+	return species, nil
+}
+
+func (u *referenceUsecase) GetSpecies(ctx context.Context, name string) (*entity.Species, error) {
+	species, err := u.GetAllSpecies(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range species {
+		if species[i].Name == name {
+			return &species[i], nil
+		}
+	}
+
+	return nil, referenceNotFound(referenceSpecies, name)
+}
+
+func (u *referenceUsecase) CreateSpecies(ctx context.Context, species *entity.Species) (*entity.Species, error) {
+	err := validateSpecies(species)
+	if err != nil {
+		return nil, err
+	}
+
+	err = u.petRepo.CreateSpecies(ctx, species)
+	if err != nil {
+		return nil, fromReferenceRepository(err, referenceSpecies, species.Name)
+	}
+
+	return species, nil
+}
+
+// UpdateSpecies renames the species to the name of the payload, if it differs.
+func (u *referenceUsecase) UpdateSpecies(ctx context.Context, name string, species *entity.Species) (*entity.Species, error) {
+	err := validateSpecies(species)
+	if err != nil {
+		return nil, err
+	}
+
+	rowsAffected, err := u.rename(ctx, name, species.Name, repository.PetRepository.GetPetsBySpecies, func(repo repository.PetRepository) (int, error) {
+		return repo.UpdateSpecies(ctx, name, species)
+	})
+	if err != nil {
+		return nil, fromReferenceRepository(err, referenceSpecies, species.Name)
+	}
+
+	// No row is affected either when the species doesn't exist or, with MySQL, when it is unchanged
+	if rowsAffected == 0 {
+		return u.GetSpecies(ctx, name)
+	}
+
+	return species, nil
+}
+
+func (u *referenceUsecase) DeleteSpecies(ctx context.Context, name string) error {
+	rowsAffected, err := u.petRepo.DeleteSpecies(ctx, name)
+	if err != nil {
+		return fromReferenceRepository(err, referenceSpecies, name)
+	}
+
+	if rowsAffected == 0 {
+		return referenceNotFound(referenceSpecies, name)
+	}
+
+	return nil
+}
+
+func (u *referenceUsecase) GetAllPetSizes(ctx context.Context) ([]entity.PetSize, error) {
+	sizes, err := u.petRepo.GetAllPetSizes(ctx)
+	if err != nil {
+		return nil, fromRepository(err)
+	}
+
+	return sizes, nil
+}
+
+func (u *referenceUsecase) GetPetSize(ctx context.Context, name string) (*entity.PetSize, error) {
+	sizes, err := u.GetAllPetSizes(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range sizes {
+		if sizes[i].Name == name {
+			return &sizes[i], nil
+		}
+	}
+
+	return nil, referenceNotFound(referencePetSize, name)
+}
+
+func (u *referenceUsecase) CreatePetSize(ctx context.Context, size *entity.PetSize) (*entity.PetSize, error) {
+	err := validatePetSize(size)
+	if err != nil {
+		return nil, err
+	}
+
+	err = u.petRepo.CreatePetSize(ctx, size)
+	if err != nil {
+		return nil, fromReferenceRepository(err, referencePetSize, size.Name)
+	}
+
+	return size, nil
+}
+
+// UpdatePetSize renames the size to the name of the payload, if it differs.
+func (u *referenceUsecase) UpdatePetSize(ctx context.Context, name string, size *entity.PetSize) (*entity.PetSize, error) {
+	err := validatePetSize(size)
+	if err != nil {
+		return nil, err
+	}
+
+	rowsAffected, err := u.rename(ctx, name, size.Name, repository.PetRepository.GetPetsByPetSize, func(repo repository.PetRepository) (int, error) {
+		return repo.UpdatePetSize(ctx, name, size)
+	})
+	if err != nil {
+		return nil, fromReferenceRepository(err, referencePetSize, size.Name)
+	}
+
+	// No row is affected either when the size doesn't exist or, with MySQL, when it is unchanged
+	if rowsAffected == 0 {
+		return u.GetPetSize(ctx, name)
+	}
+
+	return size, nil
+}
+
+func (u *referenceUsecase) DeletePetSize(ctx context.Context, name string) error {
+	rowsAffected, err := u.petRepo.DeletePetSize(ctx, name)
+	if err != nil {
+		return fromReferenceRepository(err, referencePetSize, name)
+	}
+
+	if rowsAffected == 0 {
+		return referenceNotFound(referencePetSize, name)
+	}
+
+	return nil
+}
+
+// rename runs update, which may rename a species or a size from name to newName on
+// the pets, list returning the pets of a species or a size. The renamed pets are
+// recorded in the audit log, in the transaction of the update.
+func (u *referenceUsecase) rename(ctx context.Context, name string, newName string, list func(repo repository.PetRepository, ctx context.Context, name string) ([]entity.Pet, error), update func(repo repository.PetRepository) (int, error)) (int, error) {
+	if newName == name {
+		return update(u.petRepo)
+	}
+
+	var rowsAffected int
+	err := u.petRepo.WithTransaction(ctx, func(tx repository.PetRepository) error {
+		pets := &petUsecase{petRepo: tx}
+
+		before, err := list(tx, ctx, name)
+		if err != nil {
+			return err
+		}
+
+		rowsAffected, err = update(tx)
+		if err != nil || rowsAffected == 0 {
+			return err
+		}
+
+		renamed, err := list(tx, ctx, newName)
+		if err != nil {
+			return err
+		}
+
+		after := make(map[int]*entity.Pet, len(renamed))
+		for i := range renamed {
+			after[renamed[i].ID] = &renamed[i]
+		}
+
+		for i := range before {
+			err = pets.audit(ctx, entity.AuditActionUpdate, before[i].ID, &before[i], after[before[i].ID])
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+
+	return rowsAffected, err
+}
+
+// validateSpecies returns a *ValidationError listing every invalid field, or nil.
+func validateSpecies(species *entity.Species) error {
+	errors := validateReferenceName(species.Name)
+
+	if species.MaxWeight == 0 {
+		errors = append(errors, FieldError{Field: "max_weight", Message: "is required"})
+	} else if species.MinWeight > species.MaxWeight {
+		errors = append(errors, FieldError{Field: "min_weight", Message: "must not exceed the max weight"})
+	}
+
+	if len(errors) > 0 {
+		return &ValidationError{Errors: errors}
+	}
+
+	return nil
+}
+
+// validatePetSize returns a *ValidationError listing every invalid field, or nil.
+func validatePetSize(size *entity.PetSize) error {
+	errors := validateReferenceName(size.Name)
+
+	if size.Position < 1 {
+		errors = append(errors, FieldError{Field: "position", Message: "must be at least 1"})
+	}
+
+	if len(errors) > 0 {
+		return &ValidationError{Errors: errors}
+	}
+
+	return nil
+}
+
+// validateReferenceName applies the rules of the pet names to the name of a species or a size.
+func validateReferenceName(name string) []FieldError {
+	switch {
+	case name == "":
+		return []FieldError{{Field: "name", Message: "is required"}}
+	case len(name) > MaxNameLength:
+		return []FieldError{{Field: "name", Message: fmt.Sprintf("must be at most %d characters long", MaxNameLength)}}
+	case !namePattern.MatchString(name):
+		return []FieldError{{Field: "name", Message: "must only contain lowercase letters and digits separated by underscores"}}
+	}
+
+	return nil
+}
+
+// fromReferenceRepository turns the repository errors about the species or the size
+// of the given name into domain errors, see fromRepository.
+func fromReferenceRepository(err error, reference string, name string) error {
+	switch {
+	// ErrInUse may also be an ErrConstraint, see repository.DeleteSpecies
+	case errors.Is(err, repository.ErrInUse):
+		return &Error{Kind: ErrConflict, Message: fmt.Sprintf("%s %s is used by pets", reference, name), Err: err}
+	case errors.Is(err, repository.ErrDuplicate):
+		return &Error{Kind: ErrConflict, Message: fmt.Sprintf("%s %s already exists", reference, name), Err: err}
+	case errors.Is(err, repository.ErrConstraint):
+		return &Error{Kind: ErrValidation, Message: fmt.Sprintf("%s %s rejected by the database constraints", reference, name), Err: err}
+	}
+
+	return fromRepository(err)
+}
+
+func referenceNotFound(reference string, name string) error {
+	return &Error{Kind: ErrNotFound, Message: fmt.Sprintf("%s %s not found", reference, name)}
+}
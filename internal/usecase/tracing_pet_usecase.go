@@ -0,0 +1,140 @@
+package usecase
+
+import (
+	"context"
+	"time"
+
+	"github.com/japhy-tech/backend-test/internal/entity"
+	"github.com/japhy-tech/backend-test/internal/tracing"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// tracingPetUsecase wraps every call to a PetUsecase in a span.
+type tracingPetUsecase struct {
+	next PetUsecase
+}
+
+// NewTracingPetUsecase returns the usecase tracing the calls to next.
+func NewTracingPetUsecase(next PetUsecase) PetUsecase {
+	return &tracingPetUsecase{next: next}
+}
+
+func (u *tracingPetUsecase) CreatePet(ctx context.Context, pet *entity.CreatePet) (*entity.Pet, error) {
+	ctx, span := tracing.Start(ctx, "PetUsecase.CreatePet")
+	createdPet, err := u.next.CreatePet(ctx, pet)
+	tracing.End(span, err)
+
+	return createdPet, err
+}
+
+func (u *tracingPetUsecase) GetPets(ctx context.Context, page *entity.PageRequest) (*entity.PetPage, error) {
+	ctx, span := tracing.Start(ctx, "PetUsecase.GetPets")
+	pets, err := u.next.GetPets(ctx, page)
+	tracing.End(span, err)
+
+	return pets, err
+}
+
+func (u *tracingPetUsecase) GetPetByID(ctx context.Context, id int) (*entity.Pet, error) {
+	ctx, span := tracing.Start(ctx, "PetUsecase.GetPetByID", attribute.Int("pet.id", id))
+	pet, err := u.next.GetPetByID(ctx, id)
+	tracing.End(span, err)
+
+	return pet, err
+}
+
+func (u *tracingPetUsecase) CountPetsBySpecies(ctx context.Context) (map[string]int, error) {
+	ctx, span := tracing.Start(ctx, "PetUsecase.CountPetsBySpecies")
+	counts, err := u.next.CountPetsBySpecies(ctx)
+	tracing.End(span, err)
+
+	return counts, err
+}
+
+func (u *tracingPetUsecase) UpdatePet(ctx context.Context, id int, pet *entity.UpdatePet, version int) (*entity.Pet, error) {
+	ctx, span := tracing.Start(ctx, "PetUsecase.UpdatePet", attribute.Int("pet.id", id))
+	updatedPet, err := u.next.UpdatePet(ctx, id, pet, version)
+	tracing.End(span, err)
+
+	return updatedPet, err
+}
+
+func (u *tracingPetUsecase) PatchPet(ctx context.Context, id int, pet *entity.PatchPet, version int) (*entity.Pet, error) {
+	ctx, span := tracing.Start(ctx, "PetUsecase.PatchPet", attribute.Int("pet.id", id))
+	patchedPet, err := u.next.PatchPet(ctx, id, pet, version)
+	tracing.End(span, err)
+
+	return patchedPet, err
+}
+
+func (u *tracingPetUsecase) DeletePet(ctx context.Context, id int, version int) error {
+	ctx, span := tracing.Start(ctx, "PetUsecase.DeletePet", attribute.Int("pet.id", id))
+	err := u.next.DeletePet(ctx, id, version)
+	tracing.End(span, err)
+
+	return err
+}
+
+func (u *tracingPetUsecase) SearchPets(ctx context.Context, searchPets *entity.SearchPets, page *entity.PageRequest) (*entity.PetPage, error) {
+	ctx, span := tracing.Start(ctx, "PetUsecase.SearchPets")
+	pets, err := u.next.SearchPets(ctx, searchPets, page)
+	tracing.End(span, err)
+
+	return pets, err
+}
+
+func (u *tracingPetUsecase) BatchPets(ctx context.Context, batch *entity.BatchPets) (*BatchReport, error) {
+	ctx, span := tracing.Start(ctx, "PetUsecase.BatchPets")
+	report, err := u.next.BatchPets(ctx, batch)
+	tracing.End(span, err)
+
+	return report, err
+}
+
+func (u *tracingPetUsecase) ExportPets(ctx context.Context, write func(pets []entity.Pet) error) error {
+	ctx, span := tracing.Start(ctx, "PetUsecase.ExportPets")
+	err := u.next.ExportPets(ctx, write)
+	tracing.End(span, err)
+
+	return err
+}
+
+func (u *tracingPetUsecase) ImportPets(ctx context.Context, records []entity.PetRecord, dryRun bool) (*ImportReport, error) {
+	ctx, span := tracing.Start(ctx, "PetUsecase.ImportPets", attribute.Int("import.records", len(records)), attribute.Bool("import.dry_run", dryRun))
+	report, err := u.next.ImportPets(ctx, records, dryRun)
+	tracing.End(span, err)
+
+	return report, err
+}
+
+func (u *tracingPetUsecase) GetDeletedPets(ctx context.Context, page *entity.PageRequest) (*entity.PetPage, error) {
+	ctx, span := tracing.Start(ctx, "PetUsecase.GetDeletedPets")
+	pets, err := u.next.GetDeletedPets(ctx, page)
+	tracing.End(span, err)
+
+	return pets, err
+}
+
+func (u *tracingPetUsecase) RestorePet(ctx context.Context, id int) (*entity.Pet, error) {
+	ctx, span := tracing.Start(ctx, "PetUsecase.RestorePet", attribute.Int("pet.id", id))
+	pet, err := u.next.RestorePet(ctx, id)
+	tracing.End(span, err)
+
+	return pet, err
+}
+
+func (u *tracingPetUsecase) PurgeDeletedPets(ctx context.Context, retention time.Duration) (int, error) {
+	ctx, span := tracing.Start(ctx, "PetUsecase.PurgeDeletedPets")
+	purged, err := u.next.PurgeDeletedPets(ctx, retention)
+	tracing.End(span, err)
+
+	return purged, err
+}
+
+func (u *tracingPetUsecase) GetPetHistory(ctx context.Context, id int, page *entity.PageRequest) (*entity.AuditPage, error) {
+	ctx, span := tracing.Start(ctx, "PetUsecase.GetPetHistory", attribute.Int("pet.id", id))
+	entries, err := u.next.GetPetHistory(ctx, id, page)
+	tracing.End(span, err)
+
+	return entries, err
+}
@@ -1,102 +1,192 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"flag"
 	"fmt"
 	"net"
-	"net/http"
 	"os"
+	"os/signal"
+	"sync/atomic"
+	"syscall"
 	"time"
 
 	charmLog "github.com/charmbracelet/log"
 	"github.com/gorilla/mux"
 	"github.com/japhy-tech/backend-test/database_actions"
-	"github.com/japhy-tech/backend-test/internal/database"
+	"github.com/japhy-tech/backend-test/internal/config"
+	"github.com/japhy-tech/backend-test/internal/health"
 	"github.com/japhy-tech/backend-test/internal/server"
+	"github.com/japhy-tech/backend-test/internal/tracing"
 
 	_ "github.com/japhy-tech/backend-test/docs"
-	httpSwagger "github.com/swaggo/http-swagger"
 )
 
-const (
-	ApiPort        = "5000"
-	BreedsFilePath = "database_actions/seeds/breeds.csv"
-)
+// @securityDefinitions.apikey ApiKeyAuth
+// @in header
+// @name X-API-Key
+// @description API key, see the Authentication section of the README
 
+// @securityDefinitions.apikey BearerAuth
+// @in header
+// @name Authorization
+// @description JWT signed with HS256 or RS256, as "Bearer <token>"
 func main() {
-	logger := charmLog.NewWithOptions(os.Stderr, charmLog.Options{
-		Formatter:       charmLog.TextFormatter,
-		ReportCaller:    true,
-		ReportTimestamp: true,
-		TimeFormat:      time.Kitchen,
-		Prefix:          "🧑‍💻 backend-test",
-		Level:           charmLog.DebugLevel,
-	})
-
-	err := validateEnv()
+	command, err := config.Parse(os.Args[1:], os.Getenv)
+	if errors.Is(err, flag.ErrHelp) {
+		return
+	}
 	if err != nil {
-		logger.Fatal(err.Error())
+		charmLog.Fatal(err.Error())
 	}
 
-	db := database.NewMysqlDB(logger)
+	cfg := command.Config
+	if command.PrintConfig {
+		fmt.Print(cfg)
+		return
+	}
 
-	defer db.Close()
-	db.SetMaxIdleConns(0)
+	logger := newLogger(&cfg.Log)
 
-	err = db.Ping()
+	err = cfg.Validate()
 	if err != nil {
 		logger.Fatal(err.Error())
 	}
 
-	logger.Info("Database connected")
-
-	err = database_actions.InitMigrator(db)
+	shutdownTracing, err := tracing.Setup(&cfg.Tracing)
 	if err != nil {
 		logger.Fatal(err.Error())
 	}
 
-	msg, err := database_actions.RunMigrate("up", 0)
-	if err != nil {
-		logger.Fatal(err.Error())
-	} else {
-		logger.Info(msg)
+	var subcommand string
+	if len(command.Args) > 0 {
+		subcommand = command.Args[0]
 	}
 
-	// Loading data into the pets table
-	nbRowsAffected, err := database_actions.LoadPetsTable(db, BreedsFilePath)
+	switch subcommand {
+	case "", "serve", "purge":
+	case "migrate":
+		err = migrate(logger, &cfg.Database, command.Args[1:])
+		shutdownTracing(context.Background())
+		if err != nil {
+			logger.Fatal(err.Error())
+		}
+		return
+	default:
+		logger.Fatal(fmt.Sprintf("Unknown command %s, expected serve, migrate or purge", subcommand))
+	}
+
+	store, err := openStorage(logger, &cfg.Database)
 	if err != nil {
-		logger.Fatal(fmt.Sprintf("Unable to load pets table %s", err.Error()))
+		logger.Fatal(err.Error())
 	}
-	if nbRowsAffected > 0 {
-		logger.Info(fmt.Sprintf("%d lines were successfully loaded into the pets table", nbRowsAffected))
+
+	if subcommand == "purge" {
+		err = purge(logger, store.petRepo, command.Args[1:])
+		store.close()
+		shutdownTracing(context.Background())
+		if err != nil {
+			logger.Fatal(err.Error())
+		}
+		return
 	}
 
-	app := server.NewApp(logger, db)
+	app, err := server.NewApp(logger, store.db, store.petRepo, cfg)
+	if err != nil {
+		logger.Fatal(err.Error())
+	}
 
 	r := mux.NewRouter()
+	app.RegisterMiddlewares(r)
 	app.RegisterRoutes(r.PathPrefix("/v1").Subrouter())
+	app.RegisterMetrics(r)
+
+	// The service is not ready until the seed is loaded
+	var seedLoaded atomic.Bool
+	app.RegisterHealth(r, append(store.checks, health.Done("seed", &seedLoaded))...)
+	app.RegisterSwagger(r)
 
-	r.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
-		w.WriteHeader(http.StatusOK)
-	}).Methods(http.MethodGet)
-	r.PathPrefix("/swagger/").Handler(httpSwagger.WrapHandler)
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
 
-	err = http.ListenAndServe(
-		net.JoinHostPort("", ApiPort),
-		r,
-	)
+	srv := server.NewHTTPServer(&cfg.Server, r, logger)
+	listener, err := net.Listen("tcp", srv.Addr)
 	if err != nil {
 		logger.Fatal(fmt.Sprintf("Unable to start service %s", err.Error()))
 	}
 
-	// =============================== Starting Msg ===============================
-	logger.Info(fmt.Sprintf("Service started and listen on port %s", ApiPort))
+	// Upserting the pets of the seed file, the failures leave the service not ready
+	// rather than stopping it midway through the shutdown or the requests
+	seedDone := make(chan struct{})
+	go func() {
+		defer close(seedDone)
+
+		report, err := database_actions.LoadPets(ctx, store.petRepo, cfg.Database.SeedPath)
+		if err != nil && ctx.Err() != nil {
+			logger.Info("Seed file loading stopped by the shutdown, the next start loads the lines left")
+			return
+		}
+		if err != nil {
+			logger.Error(fmt.Sprintf("Unable to load the seed file %s", err.Error()))
+			return
+		}
+		for _, line := range report.Invalid {
+			logger.Warn(fmt.Sprintf("Line %d of the seed file is invalid: %s", line.Line, line.Reason), "errors", line.Errors)
+		}
+		logger.Info("Seed file loaded",
+			"inserted", len(report.Inserted),
+			"updated", len(report.Updated),
+			"skipped", len(report.Skipped),
+			"invalid", len(report.Invalid))
+		seedLoaded.Store(true)
+	}()
+
+	err = server.Serve(ctx, logger, srv, listener, cfg.Server.ShutdownTimeout)
+	if err != nil {
+		logger.Error(err.Error())
+	}
+
+	// The seed is stopped, Serve may have failed without a signal, before closing its database
+	stop()
+	<-seedDone
+
+	err = store.close()
+	if err != nil {
+		logger.Error(fmt.Sprintf("Unable to close the database %s", err.Error()))
+	} else {
+		logger.Info("Database closed")
+	}
+
+	// The spans left are flushed within the shutdown timeout
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.Server.ShutdownTimeout)
+	defer cancel()
+
+	err = shutdownTracing(ctx)
+	if err != nil {
+		logger.Error(fmt.Sprintf("Unable to flush the spans %s", err.Error()))
+	}
 }
 
-// validateEnv checks that all the environment variables required to run the app are set.
-func validateEnv() error {
-	if os.Getenv("MYSQL_ROOT_PASSWORD") == "" {
-		return fmt.Errorf("MYSQL_ROOT_PASSWORD is not set")
+// newLogger returns a logger with the level and format of the configuration,
+// which has been validated.
+func newLogger(cfg *config.LogConfig) *charmLog.Logger {
+	level, _ := charmLog.ParseLevel(cfg.Level)
+
+	formatter := charmLog.TextFormatter
+	switch cfg.Format {
+	case config.LogFormatJSON:
+		formatter = charmLog.JSONFormatter
+	case config.LogFormatLogfmt:
+		formatter = charmLog.LogfmtFormatter
 	}
 
-	return nil
+	return charmLog.NewWithOptions(os.Stderr, charmLog.Options{
+		Formatter:       formatter,
+		ReportCaller:    true,
+		ReportTimestamp: true,
+		TimeFormat:      time.Kitchen,
+		Prefix:          "🧑‍💻 backend-test",
+		Level:           level,
+	})
 }
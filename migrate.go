@@ -0,0 +1,180 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	charmLog "github.com/charmbracelet/log"
+	"github.com/japhy-tech/backend-test/database_actions"
+	"github.com/japhy-tech/backend-test/internal/config"
+	"github.com/japhy-tech/backend-test/internal/database"
+)
+
+const migrateUsage = "backend-test migrate up|down|steps N|goto V|version|force V|create NAME"
+
+// migrate manages the migrations of the MySQL database.
+//
+//	backend-test migrate up|down|steps N|goto V|version|force V|create NAME
+//
+// up and down run every migration, steps runs N up migrations or -N down ones, goto runs
+// the migrations leading to the version V, and force sets the version without running
+// anything to clear a migration failed midway. create writes the files of a new migration
+// in the migrations directory of the repository.
+func migrate(logger *charmLog.Logger, cfg *config.DatabaseConfig, args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("a migrate action is required: %s", migrateUsage)
+	}
+	action, params := args[0], args[1:]
+
+	if action == "create" {
+		name, err := migrateParam(action, params)
+		if err != nil {
+			return err
+		}
+
+		dir := cfg.MigrationsPath
+		if dir == "" {
+			dir = database_actions.MigrationsDir
+		}
+
+		paths, err := database_actions.CreateMigration(dir, name)
+		if err != nil {
+			return err
+		}
+
+		logger.Info(fmt.Sprintf("Created the migration files %s", strings.Join(paths, " and ")))
+		return nil
+	}
+
+	if cfg.Backend != config.DatabaseBackendMySQL {
+		return fmt.Errorf("the migrations only apply to the mysql backend")
+	}
+
+	// The arguments are checked before connecting to the database
+	run, err := migrateAction(action, params)
+	if err != nil {
+		return err
+	}
+
+	db, err := database.NewMigrationDB(cfg)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	err = db.Ping()
+	if err != nil {
+		return err
+	}
+
+	err = database_actions.InitMigrator(db, cfg.MigrationsPath)
+	if err != nil {
+		return err
+	}
+
+	msg, err := run()
+	if err != nil {
+		return err
+	}
+
+	logger.Info(msg)
+
+	return nil
+}
+
+// migrateAction returns the action running on the database and its outcome.
+func migrateAction(action string, params []string) (func() (string, error), error) {
+	switch action {
+	case "up", "down":
+		if len(params) > 0 {
+			return nil, fmt.Errorf("%s takes no argument, use steps N to run some of the migrations", action)
+		}
+		return func() (string, error) { return database_actions.RunMigrate(action, 0) }, nil
+
+	case "steps":
+		steps, err := migrateIntParam(action, params)
+		if err != nil {
+			return nil, err
+		}
+		if steps == 0 {
+			return nil, fmt.Errorf("the number of steps can't be 0")
+		}
+
+		migrationType := "up"
+		if steps < 0 {
+			migrationType = "down"
+		}
+		return func() (string, error) { return database_actions.RunMigrate(migrationType, steps) }, nil
+
+	case "goto":
+		version, err := migrateIntParam(action, params)
+		if err != nil {
+			return nil, err
+		}
+		if version < 0 {
+			return nil, fmt.Errorf("the version can't be negative")
+		}
+		return func() (string, error) { return database_actions.MigrateTo(uint(version)) }, nil
+
+	case "force":
+		version, err := migrateIntParam(action, params)
+		if err != nil {
+			return nil, err
+		}
+		if version < -1 {
+			return nil, fmt.Errorf("the version must be -1, for no migration, or more")
+		}
+		return func() (string, error) { return database_actions.ForceMigrationVersion(version) }, nil
+
+	case "version":
+		if len(params) > 0 {
+			return nil, fmt.Errorf("version takes no argument")
+		}
+		return migrationVersion, nil
+	}
+
+	return nil, fmt.Errorf("unknown migrate action %s: %s", action, migrateUsage)
+}
+
+// migrationVersion describes the version of the database.
+func migrationVersion() (string, error) {
+	version, dirty, err := database_actions.MigrationVersion()
+	if err != nil {
+		return "", err
+	}
+
+	msg := fmt.Sprintf("The database is at the version %d", version)
+	if version < 0 {
+		msg = "No migration has been applied to the database"
+	}
+	if dirty {
+		msg += ", the migration failed midway: fix the database, then force the version"
+	}
+
+	return msg, nil
+}
+
+// migrateParam returns the only parameter of the action.
+func migrateParam(action string, params []string) (string, error) {
+	if len(params) != 1 {
+		return "", fmt.Errorf("%s takes exactly one argument: %s", action, migrateUsage)
+	}
+
+	return params[0], nil
+}
+
+// migrateIntParam returns the only parameter of the action, as an integer.
+func migrateIntParam(action string, params []string) (int, error) {
+	param, err := migrateParam(action, params)
+	if err != nil {
+		return 0, err
+	}
+
+	value, err := strconv.Atoi(param)
+	if err != nil {
+		return 0, fmt.Errorf("%s expects an integer, not %s", action, param)
+	}
+
+	return value, nil
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"time"
+
+	charmLog "github.com/charmbracelet/log"
+	"github.com/japhy-tech/backend-test/internal/repository"
+	"github.com/japhy-tech/backend-test/internal/usecase"
+)
+
+// DefaultTrashRetention is how long deleted pets stay in the trash by default.
+const DefaultTrashRetention = 30 * 24 * time.Hour
+
+// PurgeActor is the actor of the purges in the audit log.
+const PurgeActor = "purge-command"
+
+// purge permanently deletes the pets that have been in the trash for longer than the retention.
+//
+//	backend-test purge [-retention 720h]
+func purge(logger *charmLog.Logger, petRepo repository.PetRepository, args []string) error {
+	flags := flag.NewFlagSet("purge", flag.ContinueOnError)
+	retention := flags.Duration("retention", DefaultTrashRetention, "how long deleted pets stay in the trash")
+
+	err := flags.Parse(args)
+	if err != nil {
+		return err
+	}
+
+	if *retention < 0 {
+		return fmt.Errorf("the retention can't be negative")
+	}
+
+	petUsecase := usecase.NewPetUsecase(petRepo)
+
+	purged, err := petUsecase.PurgeDeletedPets(usecase.WithActor(context.Background(), PurgeActor), *retention)
+	if err != nil {
+		return err
+	}
+
+	logger.Info(fmt.Sprintf("%d pets deleted more than %s ago were purged from the trash", purged, *retention))
+
+	return nil
+}
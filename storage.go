@@ -0,0 +1,131 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+
+	charmLog "github.com/charmbracelet/log"
+	"github.com/japhy-tech/backend-test/database_actions"
+	"github.com/japhy-tech/backend-test/internal/config"
+	"github.com/japhy-tech/backend-test/internal/database"
+	"github.com/japhy-tech/backend-test/internal/health"
+	"github.com/japhy-tech/backend-test/internal/repository"
+)
+
+// storage is the backend the pets are stored in.
+type storage struct {
+	// db is nil for the memory backend
+	db      *sql.DB
+	petRepo repository.PetRepository
+	// migrationDB is the connection of the migrations, MySQL only
+	migrationDB *sql.DB
+	// checks are the readiness checks of the backend, on top of the database ping
+	checks []health.Check
+}
+
+// openStorage opens the backend of the configuration, with its schema up to date.
+func openStorage(logger *charmLog.Logger, cfg *config.DatabaseConfig) (*storage, error) {
+	switch cfg.Backend {
+	case config.DatabaseBackendSQLite:
+		return openSQLite(logger, cfg)
+	case config.DatabaseBackendMemory:
+		return openMemory(logger, cfg)
+	}
+
+	return openMySQL(logger, cfg)
+}
+
+// openMySQL connects to the database and runs the migrations, unless told otherwise.
+func openMySQL(logger *charmLog.Logger, cfg *config.DatabaseConfig) (*storage, error) {
+	db := database.NewMysqlDB(logger, cfg)
+
+	err := db.Ping()
+	if err != nil {
+		return nil, err
+	}
+
+	logger.Info("Database connected")
+
+	migrationDB, err := database.NewMigrationDB(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	err = database_actions.InitMigrator(migrationDB, cfg.MigrationsPath)
+	if err != nil {
+		migrationDB.Close()
+		return nil, err
+	}
+
+	if cfg.AutoMigrate {
+		msg, err := database_actions.RunMigrate("up", 0)
+		if err != nil {
+			migrationDB.Close()
+			return nil, err
+		}
+		logger.Info(msg)
+	}
+
+	expectedVersion, err := database_actions.LatestMigrationVersion()
+	if err != nil {
+		migrationDB.Close()
+		return nil, err
+	}
+
+	return &storage{
+		db:          db,
+		petRepo:     repository.NewPetRepository(db, cfg.QueryTimeout),
+		migrationDB: migrationDB,
+		checks:      []health.Check{health.Migrations(database_actions.MigrationVersion, expectedVersion)},
+	}, nil
+}
+
+// openSQLite opens the database file and upgrades its schema to the one of the binary.
+func openSQLite(logger *charmLog.Logger, cfg *config.DatabaseConfig) (*storage, error) {
+	db, err := database.NewSQLiteDB(cfg.SQLitePath)
+	if err != nil {
+		return nil, err
+	}
+
+	version, err := database_actions.UpgradeSQLiteSchema(db)
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	schemaVersion, err := database_actions.SQLiteSchemaVersion()
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	if version < schemaVersion {
+		logger.Info(fmt.Sprintf("SQLite schema upgraded from the version %d to %d", version, schemaVersion))
+	}
+
+	logger.Info(fmt.Sprintf("SQLite database %s opened", cfg.SQLitePath))
+
+	return &storage{
+		db:      db,
+		petRepo: repository.NewSQLitePetRepository(db, cfg.QueryTimeout),
+	}, nil
+}
+
+// openMemory returns an empty repository, the pets being kept in memory until the process stops.
+func openMemory(logger *charmLog.Logger, _ *config.DatabaseConfig) (*storage, error) {
+	logger.Warn("The pets are stored in memory, they will be lost when the service stops")
+
+	return &storage{petRepo: repository.NewMemoryPetRepository()}, nil
+}
+
+// close closes the database, if any.
+func (s *storage) close() error {
+	if s.migrationDB != nil {
+		s.migrationDB.Close()
+	}
+	if s.db == nil {
+		return nil
+	}
+
+	return s.db.Close()
+}
@@ -0,0 +1,96 @@
+package tests
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/japhy-tech/backend-test/internal/entity"
+	"github.com/japhy-tech/backend-test/internal/repository"
+	"github.com/japhy-tech/backend-test/internal/usecase"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestDeletePetUsecaseRecordsAudit(t *testing.T) {
+	mockRepo := new(repository.MockPetRepository)
+	petUsecase := usecase.NewPetUsecase(mockRepo)
+	mockReferences(mockRepo)
+
+	storedPet := &entity.Pet{ID: 3, Species: "dog", PetSize: "small", Name: "bolognese", Version: 2}
+
+	mockRepo.On("WithTransaction").Return()
+	mockRepo.On("GetByID", 3).Return(storedPet, nil)
+	mockRepo.On("Delete", 3, 2).Return(1, nil)
+	mockRepo.On("InsertAuditEntry", mock.MatchedBy(func(entry *entity.AuditEntry) bool {
+		return entry.EntityType == entity.AuditEntityPet &&
+			entry.EntityID == 3 &&
+			entry.Action == entity.AuditActionDelete &&
+			entry.Actor == "alice" &&
+			string(entry.Before) != "" &&
+			entry.After == nil
+	})).Return(nil)
+
+	err := petUsecase.DeletePet(usecase.WithActor(context.Background(), "alice"), 3, 2)
+
+	assert.NoError(t, err)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestActorFromContextDefaultsToAnonymous(t *testing.T) {
+	assert.Equal(t, usecase.AnonymousActor, usecase.ActorFromContext(context.Background()))
+	assert.Equal(t, usecase.AnonymousActor, usecase.ActorFromContext(usecase.WithActor(context.Background(), "")))
+}
+
+func TestGetPetHistoryHandler(t *testing.T) {
+	mockRepo := new(repository.MockPetRepository)
+	router := newTestRouter(mockRepo)
+
+	filter := &entity.AuditFilter{EntityType: entity.AuditEntityPet, EntityID: 3}
+	entries := []entity.AuditEntry{
+		{ID: 7, EntityType: entity.AuditEntityPet, EntityID: 3, Action: entity.AuditActionCreate, Actor: "alice", After: []byte(`{"id":3}`)},
+	}
+
+	mockRepo.On("GetAuditEntries", filter, mock.Anything).Return(entries, nil)
+	mockRepo.On("CountAuditEntries", filter).Return(1, nil)
+
+	req := httptest.NewRequest(http.MethodGet, "/pets/3/history", nil)
+	rec := httptest.NewRecorder()
+
+	router.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), `"action":"create"`)
+	assert.Contains(t, rec.Body.String(), `"after":{"id":3}`)
+	assert.Contains(t, rec.Body.String(), `"before":null`)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestGetAuditEntriesFilters(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := repository.NewPetRepository(db, 0)
+
+	from := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	filter := &entity.AuditFilter{Action: entity.AuditActionUpdate, Actor: "alice", From: from}
+	page := &entity.PageRequest{Limit: 10, Cursor: 5, Sort: "id", Order: entity.SortDesc}
+
+	rows := sqlmock.NewRows([]string{"id", "entity_type", "entity_id", "action", "actor", "created_at", "before_state", "after_state"}).
+		AddRow(4, "pet", 3, "update", "alice", from, []byte(`{"name":"a"}`), []byte(`{"name":"b"}`))
+
+	mock.ExpectQuery(`SELECT .* FROM audit_log WHERE action = \? AND actor = \? AND created_at >= \? AND id < \? ORDER BY id DESC LIMIT \?`).
+		WithArgs("update", "alice", from, 5, 10).
+		WillReturnRows(rows)
+
+	entries, err := repo.GetAuditEntries(context.Background(), filter, page)
+
+	assert.NoError(t, err)
+	assert.Len(t, entries, 1)
+	assert.JSONEq(t, `{"name":"b"}`, string(entries[0].After))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
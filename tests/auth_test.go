@@ -0,0 +1,148 @@
+package tests
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	charmLog "github.com/charmbracelet/log"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/gorilla/mux"
+	"github.com/japhy-tech/backend-test/internal/auth"
+	"github.com/japhy-tech/backend-test/internal/config"
+	delivery "github.com/japhy-tech/backend-test/internal/delivery/http"
+	"github.com/japhy-tech/backend-test/internal/entity"
+	"github.com/japhy-tech/backend-test/internal/repository"
+	"github.com/japhy-tech/backend-test/internal/usecase"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+const testHS256Key = "test-hs256-key-of-at-least-32-bytes"
+
+func newAuthRouter(t *testing.T, cfg *config.AuthConfig, mockRepo *repository.MockPetRepository) *mux.Router {
+	authenticator, err := auth.NewAuthenticator(cfg)
+	assert.NoError(t, err)
+
+	router := mux.NewRouter()
+	router.Use(delivery.Authenticate(authenticator, charmLog.New(io.Discard)))
+	mockReferences(mockRepo)
+	delivery.NewPetHandler(router, usecase.NewPetUsecase(mockRepo), charmLog.New(io.Discard))
+
+	return router
+}
+
+func newToken(t *testing.T, method jwt.SigningMethod, key interface{}, subject string, role string, expiresAt time.Time) string {
+	token, err := jwt.NewWithClaims(method, jwt.MapClaims{
+		"sub":  subject,
+		"role": role,
+		"exp":  expiresAt.Unix(),
+	}).SignedString(key)
+	assert.NoError(t, err)
+
+	return token
+}
+
+func serve(router *mux.Router, method string, path string, header string, value string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, nil)
+	if header != "" {
+		req.Header.Set(header, value)
+	}
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	return rec
+}
+
+func TestAuthMissingCredentials(t *testing.T) {
+	router := newAuthRouter(t, &config.AuthConfig{Enabled: true, APIKeys: []config.APIKey{{Name: "reader", Role: "viewer", Key: "key-1"}}}, new(repository.MockPetRepository))
+
+	rec := serve(router, http.MethodGet, "/pets/1", "", "")
+
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	assert.Equal(t, `Bearer realm="backend-test"`, rec.Header().Get("WWW-Authenticate"))
+	assert.JSONEq(t, `{"status":"error","message":"Authentication required"}`, rec.Body.String())
+
+	rec = serve(router, http.MethodGet, "/pets/1", auth.APIKeyHeader, "unknown")
+
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	assert.JSONEq(t, `{"status":"error","message":"Invalid credentials"}`, rec.Body.String())
+}
+
+func TestAuthAPIKeyRoles(t *testing.T) {
+	mockRepo := new(repository.MockPetRepository)
+	router := newAuthRouter(t, &config.AuthConfig{Enabled: true, APIKeys: []config.APIKey{
+		{Name: "reader", Role: "viewer", Key: "key-1"},
+		{Name: "back-office", Role: "admin", Key: "key-2"},
+	}}, mockRepo)
+
+	storedPet := &entity.Pet{ID: 1, Species: "dog", PetSize: "small", Name: "bolognese", Version: 1}
+	mockRepo.On("GetByID", 1).Return(storedPet, nil)
+
+	rec := serve(router, http.MethodGet, "/pets/1", auth.APIKeyHeader, "key-1")
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	rec = serve(router, http.MethodDelete, "/pets/1", auth.APIKeyHeader, "key-1")
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+	assert.JSONEq(t, `{"status":"error","message":"The admin role is required"}`, rec.Body.String())
+
+	mockRepo.On("WithTransaction").Return()
+	mockRepo.On("Delete", 1, 0).Return(1, nil)
+	mockRepo.On("InsertAuditEntry", mock.MatchedBy(func(entry *entity.AuditEntry) bool {
+		return entry.Actor == "back-office"
+	})).Return(nil)
+
+	rec = serve(router, http.MethodDelete, "/pets/1", auth.APIKeyHeader, "key-2")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestAuthHS256Token(t *testing.T) {
+	router := newAuthRouter(t, &config.AuthConfig{Enabled: true, JWT: config.JWTConfig{HS256Key: testHS256Key}}, new(repository.MockPetRepository))
+
+	token := newToken(t, jwt.SigningMethodHS256, []byte(testHS256Key), "alice", "editor", time.Now().Add(time.Hour))
+	rec := serve(router, http.MethodDelete, "/pets/1", "Authorization", "Bearer "+token)
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+
+	expired := newToken(t, jwt.SigningMethodHS256, []byte(testHS256Key), "alice", "admin", time.Now().Add(-time.Hour))
+	rec = serve(router, http.MethodDelete, "/pets/1", "Authorization", "Bearer "+expired)
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+
+	forged := newToken(t, jwt.SigningMethodHS256, []byte("another-key-of-at-least-32-bytes!!"), "alice", "admin", time.Now().Add(time.Hour))
+	rec = serve(router, http.MethodDelete, "/pets/1", "Authorization", "Bearer "+forged)
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+}
+
+func TestAuthRS256Token(t *testing.T) {
+	privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.NoError(t, err)
+
+	publicKey, err := x509.MarshalPKIXPublicKey(&privateKey.PublicKey)
+	assert.NoError(t, err)
+
+	publicKeyPEM := pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: publicKey})
+	file := filepath.Join(t.TempDir(), "public.pem")
+	assert.NoError(t, os.WriteFile(file, publicKeyPEM, 0o600))
+
+	mockRepo := new(repository.MockPetRepository)
+	router := newAuthRouter(t, &config.AuthConfig{Enabled: true, JWT: config.JWTConfig{RS256PublicKeyFile: file}}, mockRepo)
+
+	mockRepo.On("GetByID", 1).Return(&entity.Pet{ID: 1, Species: "dog", PetSize: "small", Name: "bolognese"}, nil)
+
+	token := newToken(t, jwt.SigningMethodRS256, privateKey, "bob", "viewer", time.Now().Add(time.Hour))
+	rec := serve(router, http.MethodGet, "/pets/1", "Authorization", "Bearer "+token)
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	// The public key used as an HMAC secret is rejected
+	confused := newToken(t, jwt.SigningMethodHS256, publicKeyPEM, "bob", "admin", time.Now().Add(time.Hour))
+	rec = serve(router, http.MethodGet, "/pets/1", "Authorization", "Bearer "+confused)
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+}
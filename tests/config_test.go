@@ -0,0 +1,133 @@
+package tests
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/japhy-tech/backend-test/internal/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseConfigPrecedence(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "config.yaml")
+	err := os.WriteFile(file, []byte("server:\n  port: 6000\ndatabase:\n  host: db\n  user: api\n  conn_max_lifetime: 5m\n"), 0o600)
+	assert.NoError(t, err)
+
+	env := map[string]string{
+		"CONFIG_FILE":   file,
+		"DB_USER":       "backend",
+		"DB_PASSWORD":   "secret",
+		"AUTH_API_KEYS": "back-office:admin:key-1, reader:viewer:key-2",
+	}
+
+	command, err := config.Parse([]string{"-port", "7000", "purge", "-retention", "1h"}, func(name string) string {
+		return env[name]
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, 7000, command.Config.Server.Port)
+	assert.Equal(t, "db", command.Config.Database.Host)
+	assert.Equal(t, "backend", command.Config.Database.User)
+	assert.Equal(t, 5*time.Minute, command.Config.Database.ConnMaxLifetime)
+	assert.Equal(t, "core", command.Config.Database.Name)
+	assert.Equal(t, []string{"purge", "-retention", "1h"}, command.Args)
+	assert.Equal(t, []config.APIKey{{Name: "back-office", Role: "admin", Key: "key-1"}, {Name: "reader", Role: "viewer", Key: "key-2"}}, command.Config.Auth.APIKeys)
+	assert.NoError(t, command.Config.Validate())
+}
+
+func TestParseConfigTOML(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "config.toml")
+	err := os.WriteFile(file, []byte("[database]\nmax_open_conns = 20\nmax_idle_conns = 5\n\n[log]\nformat = \"json\"\n"), 0o600)
+	assert.NoError(t, err)
+
+	command, err := config.Parse([]string{"-config", file}, func(string) string { return "" })
+
+	assert.NoError(t, err)
+	assert.Equal(t, 20, command.Config.Database.MaxOpenConns)
+	assert.Equal(t, 5, command.Config.Database.MaxIdleConns)
+	assert.Equal(t, config.LogFormatJSON, command.Config.Log.Format)
+}
+
+func TestParseConfigAutoMigrate(t *testing.T) {
+	command, err := config.Parse([]string{"migrate", "up"}, func(string) string { return "" })
+
+	assert.NoError(t, err)
+	assert.True(t, command.Config.Database.AutoMigrate)
+	assert.Empty(t, command.Config.Database.MigrationsPath)
+	assert.Equal(t, []string{"migrate", "up"}, command.Args)
+
+	env := map[string]string{"DB_AUTO_MIGRATE": "false"}
+	command, err = config.Parse([]string{"serve"}, func(name string) string { return env[name] })
+
+	assert.NoError(t, err)
+	assert.False(t, command.Config.Database.AutoMigrate)
+
+	command, err = config.Parse([]string{"-auto-migrate=true", "serve"}, func(name string) string { return env[name] })
+
+	assert.NoError(t, err)
+	assert.True(t, command.Config.Database.AutoMigrate)
+}
+
+func TestConfigValidateReportsEveryProblem(t *testing.T) {
+	cfg := config.Default()
+	cfg.Server.Port = 0
+	cfg.Database.MaxOpenConns = 2
+	cfg.Database.MaxIdleConns = 3
+	cfg.Log.Format = "xml"
+	cfg.CORS.AllowedOrigins = []string{"*"}
+	cfg.CORS.AllowCredentials = true
+
+	err := cfg.Validate()
+
+	assert.EqualError(t, err, "invalid configuration: the port must be between 1 and 65535; "+
+		"the database password is required (DB_PASSWORD or MYSQL_ROOT_PASSWORD); "+
+		"the database max idle connections can't exceed the max open connections; "+
+		"unknown log format: xml; "+
+		"the authentication requires an API key or a JWT key (AUTH_API_KEYS, AUTH_JWT_HS256_KEY or AUTH_JWT_RS256_PUBLIC_KEY_FILE); "+
+		"the CORS credentials can't be allowed from any origin")
+}
+
+func TestConfigValidateDatabaseBackend(t *testing.T) {
+	cfg := config.Default()
+	cfg.Database.Backend = config.DatabaseBackendMemory
+	cfg.Auth.Enabled = false
+
+	// The password is only required by MySQL
+	assert.NoError(t, cfg.Validate())
+
+	cfg.Database.Backend = config.DatabaseBackendSQLite
+	cfg.Database.SQLitePath = ""
+	assert.EqualError(t, cfg.Validate(), "invalid configuration: the SQLite path is required by the sqlite backend")
+
+	cfg.Database.Backend = "postgres"
+	assert.EqualError(t, cfg.Validate(), "invalid configuration: unknown database backend: postgres")
+}
+
+func TestConfigStringRedactsSecrets(t *testing.T) {
+	cfg := config.Default()
+	cfg.Database.Password = "secret"
+	cfg.Auth.APIKeys = []config.APIKey{{Name: "back-office", Role: "admin", Key: "api-key"}}
+
+	printed := cfg.String()
+
+	assert.NotContains(t, printed, "secret")
+	assert.NotContains(t, printed, "api-key")
+	assert.Contains(t, printed, "password: '********'")
+	assert.Equal(t, "secret", cfg.Database.Password)
+}
+
+func TestParseRateLimitRoutes(t *testing.T) {
+	env := map[string]string{
+		"RATE_LIMIT_ROUTES": "POST /v1/pets/search=60/1m, get /v1/pets/export=5/1h",
+	}
+
+	command, err := config.Parse(nil, func(name string) string { return env[name] })
+
+	assert.NoError(t, err)
+	assert.Equal(t, []config.RouteBudget{
+		{Method: "POST", Route: "/v1/pets/search", RateBudget: config.RateBudget{Requests: 60, Period: time.Minute}},
+		{Method: "GET", Route: "/v1/pets/export", RateBudget: config.RateBudget{Requests: 5, Period: time.Hour}},
+	}, command.Config.RateLimit.Routes)
+}
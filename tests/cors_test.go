@@ -0,0 +1,157 @@
+package tests
+
+import (
+	"crypto/tls"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	charmLog "github.com/charmbracelet/log"
+	"github.com/gorilla/mux"
+	"github.com/japhy-tech/backend-test/internal/auth"
+	"github.com/japhy-tech/backend-test/internal/config"
+	delivery "github.com/japhy-tech/backend-test/internal/delivery/http"
+	"github.com/stretchr/testify/assert"
+)
+
+// newCORSRouter returns a router with an authenticated route behind the CORS
+// middleware, as the /v1 routes are.
+func newCORSRouter(t *testing.T, cfg *config.CORSConfig) *mux.Router {
+	authenticator, err := auth.NewAuthenticator(&config.AuthConfig{
+		Enabled: true,
+		APIKeys: []config.APIKey{{Name: "reader", Role: auth.RoleViewer, Key: "viewer-key"}},
+	})
+	assert.NoError(t, err)
+
+	router := mux.NewRouter()
+	router.Use(
+		delivery.CORS(cfg),
+		delivery.SecurityHeaders(time.Hour),
+		delivery.Authenticate(authenticator, charmLog.New(nil)),
+	)
+	router.HandleFunc("/pets", func(w http.ResponseWriter, r *http.Request) {}).Methods(http.MethodGet)
+	router.Methods(http.MethodOptions).HandlerFunc(delivery.MethodNotAllowed)
+
+	return router
+}
+
+func TestCORSPreflight(t *testing.T) {
+	router := newCORSRouter(t, &config.CORSConfig{
+		AllowedOrigins: []string{"https://app.example.com"},
+		AllowedMethods: []string{http.MethodGet, http.MethodPost},
+		AllowedHeaders: []string{"Authorization", "Content-Type"},
+		MaxAge:         10 * time.Minute,
+	})
+
+	req := httptest.NewRequest(http.MethodOptions, "/pets", nil)
+	req.Header.Set("Origin", "https://app.example.com")
+	req.Header.Set("Access-Control-Request-Method", http.MethodGet)
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+	assert.Equal(t, "https://app.example.com", rec.Header().Get("Access-Control-Allow-Origin"))
+	assert.Equal(t, "GET, POST", rec.Header().Get("Access-Control-Allow-Methods"))
+	assert.Equal(t, "Authorization, Content-Type", rec.Header().Get("Access-Control-Allow-Headers"))
+	assert.Equal(t, "600", rec.Header().Get("Access-Control-Max-Age"))
+	assert.Empty(t, rec.Header().Get("Access-Control-Allow-Credentials"))
+
+	req = httptest.NewRequest(http.MethodOptions, "/pets", nil)
+	req.Header.Set("Origin", "https://evil.example.com")
+	req.Header.Set("Access-Control-Request-Method", http.MethodGet)
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+	assert.Empty(t, rec.Header().Get("Access-Control-Allow-Origin"))
+
+	// An OPTIONS request which is not a preflight goes through the authentication
+	req = httptest.NewRequest(http.MethodOptions, "/pets", nil)
+	req.Header.Set("X-API-Key", "viewer-key")
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusMethodNotAllowed, rec.Code)
+}
+
+func TestCORSActualRequest(t *testing.T) {
+	router := newCORSRouter(t, &config.CORSConfig{
+		AllowedOrigins: []string{"*"},
+		ExposedHeaders: []string{"ETag", "X-Request-ID"},
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/pets", nil)
+	req.Header.Set("Origin", "https://app.example.com")
+	req.Header.Set("X-API-Key", "viewer-key")
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "*", rec.Header().Get("Access-Control-Allow-Origin"))
+	assert.Equal(t, "ETag, X-Request-ID", rec.Header().Get("Access-Control-Expose-Headers"))
+	assert.Contains(t, rec.Header().Values("Vary"), "Origin")
+
+	// The errors carry the CORS headers too, for the browser to read them
+	req = httptest.NewRequest(http.MethodGet, "/pets", nil)
+	req.Header.Set("Origin", "https://app.example.com")
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	assert.Equal(t, "*", rec.Header().Get("Access-Control-Allow-Origin"))
+}
+
+func TestCORSWithCredentials(t *testing.T) {
+	router := newCORSRouter(t, &config.CORSConfig{
+		AllowedOrigins:   []string{"https://app.example.com"},
+		AllowCredentials: true,
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/pets", nil)
+	req.Header.Set("Origin", "https://APP.example.com")
+	req.Header.Set("X-API-Key", "viewer-key")
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	assert.Equal(t, "https://APP.example.com", rec.Header().Get("Access-Control-Allow-Origin"))
+	assert.Equal(t, "true", rec.Header().Get("Access-Control-Allow-Credentials"))
+
+	req = httptest.NewRequest(http.MethodGet, "/pets", nil)
+	req.Header.Set("Origin", "https://evil.example.com")
+	req.Header.Set("X-API-Key", "viewer-key")
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Empty(t, rec.Header().Get("Access-Control-Allow-Origin"))
+	assert.Empty(t, rec.Header().Get("Access-Control-Allow-Credentials"))
+}
+
+func TestSecurityHeaders(t *testing.T) {
+	router := newCORSRouter(t, &config.CORSConfig{})
+
+	req := httptest.NewRequest(http.MethodGet, "/pets", nil)
+	req.Header.Set("X-API-Key", "viewer-key")
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	assert.Equal(t, "nosniff", rec.Header().Get("X-Content-Type-Options"))
+	assert.Equal(t, "DENY", rec.Header().Get("X-Frame-Options"))
+	assert.Empty(t, rec.Header().Get("Strict-Transport-Security"))
+
+	req = httptest.NewRequest(http.MethodGet, "/pets", nil)
+	req.TLS = &tls.ConnectionState{}
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	assert.Equal(t, "max-age=3600; includeSubDomains", rec.Header().Get("Strict-Transport-Security"))
+
+	req = httptest.NewRequest(http.MethodGet, "/pets", nil)
+	req.Header.Set("X-Forwarded-Proto", "https")
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	assert.Equal(t, "max-age=3600; includeSubDomains", rec.Header().Get("Strict-Transport-Security"))
+}
@@ -0,0 +1,92 @@
+package tests
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gorilla/mux"
+	delivery "github.com/japhy-tech/backend-test/internal/delivery/http"
+	"github.com/japhy-tech/backend-test/internal/health"
+	"github.com/stretchr/testify/assert"
+)
+
+func newHealthRouter(checker *health.Checker) *mux.Router {
+	router := mux.NewRouter()
+	delivery.NewHealthHandler(router, checker)
+
+	return router
+}
+
+func TestReadyzReportsEveryCheck(t *testing.T) {
+	db, mock, err := sqlmock.New(sqlmock.MonitorPingsOption(true))
+	assert.NoError(t, err)
+	defer db.Close()
+
+	mock.ExpectPing()
+
+	var seedLoaded atomic.Bool
+	version := func() (int, bool, error) { return 5, false, nil }
+	router := newHealthRouter(health.NewChecker(time.Second,
+		health.Database(db),
+		health.Migrations(version, 5),
+		health.Done("seed", &seedLoaded),
+	))
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+	assert.Contains(t, rec.Body.String(), `"status":"down"`)
+	assert.Contains(t, rec.Body.String(), `"database":{"status":"up"`)
+	assert.Contains(t, rec.Body.String(), `"migrations":{"status":"up"`)
+	assert.Contains(t, rec.Body.String(), `"error":"not completed yet"`)
+
+	mock.ExpectPing()
+	seedLoaded.Store(true)
+
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), `"status":"up"`)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestReadyzDirtyMigration(t *testing.T) {
+	version := func() (int, bool, error) { return 4, true, nil }
+	checker := health.NewChecker(time.Second, health.Migrations(version, 5))
+
+	report := checker.Check(context.Background())
+
+	assert.Equal(t, health.StatusDown, report.Status)
+	assert.Equal(t, "the migration 4 failed midway", report.Checks["migrations"].Error)
+}
+
+func TestReadyzCheckTimeout(t *testing.T) {
+	checker := health.NewChecker(10*time.Millisecond, health.Check{
+		Name: "slow",
+		Run: func(ctx context.Context) error {
+			<-ctx.Done()
+			return ctx.Err()
+		},
+	})
+
+	report := checker.Check(context.Background())
+
+	assert.Equal(t, health.StatusDown, report.Status)
+	assert.Equal(t, context.DeadlineExceeded.Error(), report.Checks["slow"].Error)
+}
+
+func TestLivez(t *testing.T) {
+	router := newHealthRouter(health.NewChecker(time.Second))
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/livez", nil))
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+}
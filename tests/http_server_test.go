@@ -0,0 +1,56 @@
+package tests
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	charmLog "github.com/charmbracelet/log"
+	"github.com/japhy-tech/backend-test/internal/config"
+	"github.com/japhy-tech/backend-test/internal/server"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestServeDrainsInFlightRequests(t *testing.T) {
+	logger := charmLog.New(io.Discard)
+	started := make(chan struct{})
+
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		time.Sleep(100 * time.Millisecond)
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+
+	srv := server.NewHTTPServer(&config.Default().Server, handler, logger)
+	ctx, cancel := context.WithCancel(context.Background())
+
+	served := make(chan error, 1)
+	go func() {
+		served <- server.Serve(ctx, logger, srv, listener, time.Second)
+	}()
+
+	responses := make(chan *http.Response, 1)
+	go func() {
+		resp, err := http.Get("http://" + listener.Addr().String())
+		assert.NoError(t, err)
+		responses <- resp
+	}()
+
+	<-started
+	cancel()
+
+	resp := <-responses
+	if assert.NotNil(t, resp) {
+		assert.Equal(t, http.StatusNoContent, resp.StatusCode)
+	}
+	assert.NoError(t, <-served)
+
+	_, err = http.Get("http://" + listener.Addr().String())
+	assert.Error(t, err)
+}
@@ -0,0 +1,85 @@
+package tests
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	charmLog "github.com/charmbracelet/log"
+	"github.com/gorilla/mux"
+	delivery "github.com/japhy-tech/backend-test/internal/delivery/http"
+	"github.com/japhy-tech/backend-test/internal/metrics"
+	"github.com/japhy-tech/backend-test/internal/repository"
+	"github.com/stretchr/testify/assert"
+)
+
+func scrape(t *testing.T, m *metrics.Metrics) string {
+	rec := httptest.NewRecorder()
+	m.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	return rec.Body.String()
+}
+
+func TestMetricsRecordRequestsByRouteTemplate(t *testing.T) {
+	m := metrics.New(&metrics.Sources{}, charmLog.New(nil))
+
+	router := mux.NewRouter()
+	router.Use(delivery.Metrics(m))
+	router.HandleFunc("/pets/{id:[0-9]+}", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}).Methods(http.MethodGet)
+
+	for _, path := range []string{"/pets/1", "/pets/2"} {
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	body := scrape(t, m)
+
+	assert.Contains(t, body, `backend_test_http_requests_total{method="GET",route="/pets/{id}",status="404"} 2`)
+	assert.Contains(t, body, `backend_test_http_request_duration_seconds_count{method="GET",route="/pets/{id}"} 2`)
+}
+
+func TestMetricsCollectDatabaseState(t *testing.T) {
+	db, _, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	m := metrics.New(&metrics.Sources{
+		DB:     db,
+		DBName: "core",
+		MigrationVersion: func() (int, bool, error) {
+			return 5, false, nil
+		},
+		CountPetsBySpecies: func(ctx context.Context) (map[string]int, error) {
+			return map[string]int{"dog": 12, "cat": 7}, nil
+		},
+	}, charmLog.New(nil))
+
+	body := scrape(t, m)
+
+	assert.Contains(t, body, `go_sql_max_open_connections{db_name="core"} 0`)
+	assert.Contains(t, body, "backend_test_migration_version 5")
+	assert.Contains(t, body, "backend_test_migration_dirty 0")
+	assert.Contains(t, body, `backend_test_pets{species="cat"} 7`)
+	assert.Contains(t, body, `backend_test_pets{species="dog"} 12`)
+}
+
+func TestCountBySpecies(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := repository.NewPetRepository(db, 0)
+
+	mock.ExpectQuery(`SELECT species, COUNT\(\*\) FROM pets WHERE deleted_at IS NULL GROUP BY species`).
+		WillReturnRows(sqlmock.NewRows([]string{"species", "count"}).AddRow("cat", 7).AddRow("dog", 12))
+
+	counts, err := repo.CountBySpecies(context.Background())
+
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]int{"cat": 7, "dog": 12}, counts)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
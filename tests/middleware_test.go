@@ -0,0 +1,82 @@
+package tests
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	charmLog "github.com/charmbracelet/log"
+	"github.com/gorilla/mux"
+	delivery "github.com/japhy-tech/backend-test/internal/delivery/http"
+	"github.com/stretchr/testify/assert"
+)
+
+func newMiddlewareRouter(logger *charmLog.Logger) *mux.Router {
+	router := mux.NewRouter()
+	router.Use(delivery.RequestID, delivery.AccessLog(logger), delivery.Recover(logger))
+
+	router.HandleFunc("/pets/{id:[0-9]+}", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(delivery.RequestIDFromContext(r.Context())))
+	})
+	router.HandleFunc("/panic", func(w http.ResponseWriter, r *http.Request) {
+		panic("boom")
+	})
+
+	return router
+}
+
+func TestRequestIDIsPropagated(t *testing.T) {
+	router := newMiddlewareRouter(charmLog.New(io.Discard))
+
+	req := httptest.NewRequest(http.MethodGet, "/pets/3", nil)
+	req.Header.Set(delivery.RequestIDHeader, "abc-123")
+	rec := httptest.NewRecorder()
+
+	router.ServeHTTP(rec, req)
+
+	assert.Equal(t, "abc-123", rec.Header().Get(delivery.RequestIDHeader))
+	assert.Equal(t, "abc-123", rec.Body.String())
+}
+
+func TestRequestIDIsGenerated(t *testing.T) {
+	router := newMiddlewareRouter(charmLog.New(io.Discard))
+
+	req := httptest.NewRequest(http.MethodGet, "/pets/3", nil)
+	req.Header.Set(delivery.RequestIDHeader, "not valid")
+	rec := httptest.NewRecorder()
+
+	router.ServeHTTP(rec, req)
+
+	assert.Len(t, rec.Header().Get(delivery.RequestIDHeader), 32)
+	assert.Equal(t, rec.Header().Get(delivery.RequestIDHeader), rec.Body.String())
+}
+
+func TestAccessLogUsesRouteTemplate(t *testing.T) {
+	var logs bytes.Buffer
+	router := newMiddlewareRouter(charmLog.NewWithOptions(&logs, charmLog.Options{Formatter: charmLog.LogfmtFormatter}))
+
+	req := httptest.NewRequest(http.MethodGet, "/pets/3", nil)
+	req.Header.Set(delivery.RequestIDHeader, "abc-123")
+
+	router.ServeHTTP(httptest.NewRecorder(), req)
+
+	assert.Contains(t, logs.String(), `method=GET route=/pets/{id} status=200`)
+	assert.Contains(t, logs.String(), `bytes=7 request_id=abc-123`)
+}
+
+func TestRecoverAnswersWithJSONError(t *testing.T) {
+	var logs bytes.Buffer
+	router := newMiddlewareRouter(charmLog.NewWithOptions(&logs, charmLog.Options{Formatter: charmLog.LogfmtFormatter}))
+
+	rec := httptest.NewRecorder()
+
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/panic", nil))
+
+	assert.Equal(t, http.StatusInternalServerError, rec.Code)
+	assert.JSONEq(t, `{"status": "error", "message": "Internal server error"}`, rec.Body.String())
+	assert.Contains(t, logs.String(), "err=boom")
+	assert.Contains(t, logs.String(), "goroutine")
+	assert.Contains(t, logs.String(), "status=500")
+}
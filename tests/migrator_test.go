@@ -0,0 +1,132 @@
+package tests
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/japhy-tech/backend-test/database_actions"
+	"github.com/japhy-tech/backend-test/internal/database"
+	"github.com/japhy-tech/backend-test/internal/entity"
+	"github.com/japhy-tech/backend-test/internal/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLatestMigrationVersionOfTheBinary(t *testing.T) {
+	files, err := filepath.Glob("../database_actions/migrations/*.up.sql")
+	require.NoError(t, err)
+
+	version, err := database_actions.LatestMigrationVersion()
+
+	require.NoError(t, err)
+	assert.Equal(t, len(files), version)
+}
+
+func TestCreateMigration(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"1_start.up.sql", "1_start.down.sql", "12_add_index.up.sql", "12_add_index.down.sql", "README.md"} {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), nil, 0o600))
+	}
+
+	paths, err := database_actions.CreateMigration(dir, "add_breed_origin")
+
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		filepath.Join(dir, "13_add_breed_origin.up.sql"),
+		filepath.Join(dir, "13_add_breed_origin.down.sql"),
+	}, paths)
+	for _, path := range paths {
+		assert.FileExists(t, path)
+	}
+}
+
+func TestCreateMigrationRejectsInvalidNames(t *testing.T) {
+	for _, name := range []string{"", "Add index", "add-index", "../add_index", "add__index"} {
+		_, err := database_actions.CreateMigration(t.TempDir(), name)
+
+		assert.Error(t, err, name)
+	}
+}
+
+func TestCreateMigrationMissingDirectory(t *testing.T) {
+	_, err := database_actions.CreateMigration(filepath.Join(t.TempDir(), "missing"), "add_index")
+
+	assert.Error(t, err)
+}
+
+func TestUpgradeSQLiteSchemaOfAnUnversionedFile(t *testing.T) {
+	ctx := context.Background()
+	db, err := database.NewSQLiteDB(filepath.Join(t.TempDir(), "pets.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+
+	// A file of the first version, created before the schema had a version
+	schema, err := os.ReadFile("../database_actions/sqlite/1_create_pets_and_audit_log_tables.sql")
+	require.NoError(t, err)
+	_, err = db.Exec(string(schema))
+	require.NoError(t, err)
+	_, err = db.Exec(`INSERT INTO pets (id, species, pet_size, name, average_male_adult_weight, average_female_adult_weight) VALUES
+		(1, ' Dog', 'Small ', 'Beagle', 12000, NULL),
+		(2, 'cat', 'medium', '', 4000, 3000),
+		(3, 'dog', 'small', 'beagle', 11000, 9000),
+		(4, 'hamster', 'small', 'roborovski', 30, 25),
+		(9, 'cat', 'small', 'siamese', 4000, 3000)`)
+	require.NoError(t, err)
+	// The pet 9 has been purged
+	_, err = db.Exec("DELETE FROM pets WHERE id = 9")
+	require.NoError(t, err)
+
+	version, err := database_actions.UpgradeSQLiteSchema(db)
+	require.NoError(t, err)
+	assert.Equal(t, 1, version)
+
+	schemaVersion, err := database_actions.SQLiteSchemaVersion()
+	require.NoError(t, err)
+	var userVersion int
+	require.NoError(t, db.QueryRow("PRAGMA user_version").Scan(&userVersion))
+	assert.Equal(t, schemaVersion, userVersion)
+
+	var rejected int
+	require.NoError(t, db.QueryRow("SELECT COUNT(*) FROM pets_rejected").Scan(&rejected))
+	assert.Equal(t, 2, rejected)
+
+	repo := repository.NewSQLitePetRepository(db, time.Second)
+
+	pet, err := repo.GetByID(ctx, 1)
+	require.NoError(t, err)
+	assert.Equal(t, "dog", pet.Species)
+	assert.Equal(t, "small", pet.PetSize)
+	assert.Equal(t, "beagle", pet.Name)
+	assert.Equal(t, uint(0), pet.AverageFemaleAdultWeight)
+
+	// The duplicate is moved to the trash
+	deleted, err := repo.GetDeleted(ctx, entity.NewPageRequest())
+	require.NoError(t, err)
+	require.Len(t, deleted, 1)
+	assert.Equal(t, 3, deleted[0].ID)
+
+	// The ID of the purged pet is not given again
+	id, err := repo.Create(ctx, &entity.CreatePet{Species: "cat", PetSize: "small", Name: "siamese", AverageMaleAdultWeight: 4000, AverageFemaleAdultWeight: 3000})
+	require.NoError(t, err)
+	assert.Equal(t, 10, id)
+
+	// The upgraded file is left as is
+	version, err = database_actions.UpgradeSQLiteSchema(db)
+	require.NoError(t, err)
+	assert.Equal(t, schemaVersion, version)
+}
+
+func TestUpgradeSQLiteSchemaRefusesANewerFile(t *testing.T) {
+	db, err := database.NewSQLiteDB(filepath.Join(t.TempDir(), "pets.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+
+	_, err = db.Exec("PRAGMA user_version = 99")
+	require.NoError(t, err)
+
+	_, err = database_actions.UpgradeSQLiteSchema(db)
+	assert.ErrorContains(t, err, "SQLite schema version 99 is newer")
+}
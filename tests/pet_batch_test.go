@@ -0,0 +1,137 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/japhy-tech/backend-test/internal/entity"
+	"github.com/japhy-tech/backend-test/internal/repository"
+	"github.com/japhy-tech/backend-test/internal/usecase"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestBatchPetsAtomicRollsBack(t *testing.T) {
+	mockRepo := new(repository.MockPetRepository)
+	petUsecase := usecase.NewPetUsecase(mockRepo)
+	mockReferences(mockRepo)
+
+	pet := &entity.UpdatePet{
+		Species:                  "dog",
+		PetSize:                  "small",
+		Name:                     "bolognese",
+		AverageMaleAdultWeight:   4000,
+		AverageFemaleAdultWeight: 3000,
+	}
+
+	batch := &entity.BatchPets{
+		Operations: []entity.BatchOperation{
+			{Op: entity.BatchOpCreate, Pet: pet},
+			{Op: entity.BatchOpDelete, ID: 42},
+			{Op: entity.BatchOpDelete, ID: 43},
+		},
+	}
+
+	mockRepo.On("WithTransaction").Return()
+	mockRepo.On("Create", (*entity.CreatePet)(pet)).Return(12, nil)
+	mockRepo.On("GetByID", 12).Return(&entity.Pet{ID: 12, Species: "dog", PetSize: "small", Name: "bolognese", Version: 1}, nil)
+	mockRepo.On("InsertAuditEntry", mock.Anything).Return(nil)
+	mockRepo.On("GetByID", 42).Return((*entity.Pet)(nil), repository.ErrNotFound)
+
+	report, err := petUsecase.BatchPets(context.Background(), batch)
+
+	assert.NoError(t, err)
+	assert.False(t, report.Committed)
+	assert.Equal(t, []string{usecase.BatchRolledBack, usecase.BatchFailed, usecase.BatchSkipped}, []string{
+		report.Results[0].Status,
+		report.Results[1].Status,
+		report.Results[2].Status,
+	})
+	assert.Equal(t, "pet 42 not found", report.Results[1].Message)
+	mockRepo.AssertNotCalled(t, "Delete", 43, 0)
+}
+
+func TestBatchPetsBestEffortCommits(t *testing.T) {
+	mockRepo := new(repository.MockPetRepository)
+	petUsecase := usecase.NewPetUsecase(mockRepo)
+	mockReferences(mockRepo)
+
+	batch := &entity.BatchPets{
+		Mode: entity.BatchModeBestEffort,
+		Operations: []entity.BatchOperation{
+			{Op: entity.BatchOpCreate, Pet: &entity.UpdatePet{Species: "dgo"}},
+			{Op: entity.BatchOpDelete, ID: 43},
+		},
+	}
+
+	mockRepo.On("WithTransaction").Return()
+	mockRepo.On("WithSavepoint").Return().Times(2)
+	mockRepo.On("GetByID", 43).Return(&entity.Pet{ID: 43, Name: "akita", Version: 1}, nil)
+	mockRepo.On("Delete", 43, 0).Return(1, nil)
+	mockRepo.On("InsertAuditEntry", mock.Anything).Return(nil)
+
+	report, err := petUsecase.BatchPets(context.Background(), batch)
+
+	assert.NoError(t, err)
+	assert.True(t, report.Committed)
+	assert.Equal(t, 1, report.Succeeded)
+	assert.Equal(t, 1, report.Failed)
+	assert.Equal(t, usecase.BatchFailed, report.Results[0].Status)
+	assert.NotEmpty(t, report.Results[0].Errors)
+	assert.Equal(t, usecase.BatchSucceeded, report.Results[1].Status)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestWithTransactionRollsBackOnError(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := repository.NewPetRepository(db, 0)
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`UPDATE pets SET deleted_at = \?`).WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), 42).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectRollback()
+
+	errAbort := errors.New("abort")
+	err = repo.WithTransaction(context.Background(), func(tx repository.PetRepository) error {
+		_, err := tx.Delete(context.Background(), 42, 0)
+		assert.NoError(t, err)
+		return errAbort
+	})
+
+	assert.ErrorIs(t, err, errAbort)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestWithSavepointRollsBackToTheSavepointOnError(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := repository.NewPetRepository(db, 0)
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`SAVEPOINT savepoint_1`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(`UPDATE pets SET deleted_at = \?`).WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), 42).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`ROLLBACK TO SAVEPOINT savepoint_1`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(`RELEASE SAVEPOINT savepoint_1`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectCommit()
+
+	errAbort := errors.New("abort")
+	err = repo.WithTransaction(context.Background(), func(tx repository.PetRepository) error {
+		err := tx.WithSavepoint(context.Background(), func(sp repository.PetRepository) error {
+			_, err := sp.Delete(context.Background(), 42, 0)
+			assert.NoError(t, err)
+			return errAbort
+		})
+		assert.ErrorIs(t, err, errAbort)
+
+		return nil
+	})
+
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,330 @@
+package tests
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	charmLog "github.com/charmbracelet/log"
+	"github.com/gorilla/mux"
+	"github.com/japhy-tech/backend-test/internal/auth"
+	"github.com/japhy-tech/backend-test/internal/config"
+	delivery "github.com/japhy-tech/backend-test/internal/delivery/http"
+	"github.com/japhy-tech/backend-test/internal/entity"
+	"github.com/japhy-tech/backend-test/internal/repository"
+	"github.com/japhy-tech/backend-test/internal/usecase"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func newTestRouter(mockRepo *repository.MockPetRepository) *mux.Router {
+	router := mux.NewRouter()
+	withoutAuth(router)
+	mockReferences(mockRepo)
+	delivery.NewPetHandler(router, usecase.NewPetUsecase(mockRepo), charmLog.New(io.Discard))
+
+	return router
+}
+
+// withoutAuth authenticates every request as an admin, as when the authentication is disabled.
+func withoutAuth(router *mux.Router) {
+	authenticator, _ := auth.NewAuthenticator(&config.AuthConfig{})
+	router.Use(delivery.Authenticate(authenticator, charmLog.New(io.Discard)))
+}
+
+// mockReferences stores the default species and sizes, against which the pets are validated.
+func mockReferences(mockRepo *repository.MockPetRepository) {
+	mockRepo.On("GetAllSpecies").Return(entity.DefaultSpecies, nil).Maybe()
+	mockRepo.On("GetAllPetSizes").Return(entity.DefaultPetSizes, nil).Maybe()
+}
+
+func TestPatchPetHandler(t *testing.T) {
+	mockRepo := new(repository.MockPetRepository)
+	router := newTestRouter(mockRepo)
+
+	weight := uint(7000)
+	patch := &entity.PatchPet{AverageMaleAdultWeight: &weight}
+
+	mockRepo.On("WithTransaction").Return()
+	mockRepo.On("Patch", 4, patch, 0).Return(1, nil)
+	mockRepo.On("InsertAuditEntry", mock.Anything).Return(nil)
+	mockRepo.On("GetByID", 4).Return(&entity.Pet{
+		ID:                       4,
+		Species:                  "dog",
+		PetSize:                  "small",
+		Name:                     "bichon_frize",
+		AverageMaleAdultWeight:   7000,
+		AverageFemaleAdultWeight: 7000,
+	}, nil)
+
+	req := httptest.NewRequest(http.MethodPatch, "/pets/4", strings.NewReader(`{"average_male_adult_weight": 7000}`))
+	req.Header.Set("Content-Type", delivery.MergePatchContentType)
+	rec := httptest.NewRecorder()
+
+	router.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), `"name":"bichon_frize"`)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestPatchPetHandlerRejectsRemovedMembers(t *testing.T) {
+	mockRepo := new(repository.MockPetRepository)
+	router := newTestRouter(mockRepo)
+
+	req := httptest.NewRequest(http.MethodPatch, "/pets/4", strings.NewReader(`{"name": null}`))
+	req.Header.Set("Content-Type", delivery.MergePatchContentType)
+	rec := httptest.NewRecorder()
+
+	router.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	mockRepo.AssertNotCalled(t, "Patch")
+}
+
+func TestPatchPetHandlerRejectsTooLargeDocuments(t *testing.T) {
+	mockRepo := new(repository.MockPetRepository)
+	router := newTestRouter(mockRepo)
+
+	body := `{"name": "` + strings.Repeat("a", delivery.MaxMergePatchSize) + `"}`
+	req := httptest.NewRequest(http.MethodPatch, "/pets/4", strings.NewReader(body))
+	req.Header.Set("Content-Type", delivery.MergePatchContentType)
+	rec := httptest.NewRecorder()
+
+	router.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusRequestEntityTooLarge, rec.Code)
+	mockRepo.AssertNotCalled(t, "Patch")
+}
+
+func TestCreatePetHandlerValidationErrors(t *testing.T) {
+	mockRepo := new(repository.MockPetRepository)
+	router := newTestRouter(mockRepo)
+
+	body := `{"species": "dgo", "pet_size": "huge", "name": "Bichon Frisé", "average_male_adult_weight": 0}`
+	req := httptest.NewRequest(http.MethodPost, "/pets", strings.NewReader(body))
+	rec := httptest.NewRecorder()
+
+	router.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+	assert.JSONEq(t, `{
+		"status": "error",
+		"message": "Validation failed",
+		"errors": [
+			{"field": "species", "message": "must be one of cat, dog"},
+			{"field": "pet_size", "message": "must be one of small, medium, tall"},
+			{"field": "name", "message": "must only contain lowercase letters and digits separated by underscores"}
+		]
+	}`, rec.Body.String())
+	mockRepo.AssertNotCalled(t, "Create")
+}
+
+func TestCreatePetHandlerConstraintViolations(t *testing.T) {
+	tests := []struct {
+		name       string
+		err        error
+		statusCode int
+		message    string
+	}{
+		{"duplicate", repository.ErrDuplicate, http.StatusConflict, "pet already exists"},
+		{"check constraint", repository.ErrConstraint, http.StatusUnprocessableEntity, "pet rejected by the database constraints"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := new(repository.MockPetRepository)
+			router := newTestRouter(mockRepo)
+
+			mockRepo.On("WithTransaction").Return()
+			mockRepo.On("Create", mock.Anything).Return(0, tt.err)
+
+			body := `{"species": "dog", "pet_size": "small", "name": "bolognese", "average_male_adult_weight": 4000, "average_female_adult_weight": 3000}`
+			req := httptest.NewRequest(http.MethodPost, "/pets", strings.NewReader(body))
+			rec := httptest.NewRecorder()
+
+			router.ServeHTTP(rec, req)
+
+			assert.Equal(t, tt.statusCode, rec.Code)
+			assert.JSONEq(t, `{"status": "error", "message": "`+tt.message+`"}`, rec.Body.String())
+		})
+	}
+}
+
+func TestGetPetHandlerNotFound(t *testing.T) {
+	mockRepo := new(repository.MockPetRepository)
+	router := newTestRouter(mockRepo)
+
+	mockRepo.On("GetByID", 7).Return((*entity.Pet)(nil), fmt.Errorf("%w: %w", repository.ErrNotFound, sql.ErrNoRows))
+
+	req := httptest.NewRequest(http.MethodGet, "/pets/7", nil)
+	rec := httptest.NewRecorder()
+
+	router.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+	assert.JSONEq(t, `{"status": "error", "message": "pet 7 not found"}`, rec.Body.String())
+}
+
+func TestGetPetsHandlerHidesDatabaseErrors(t *testing.T) {
+	mockRepo := new(repository.MockPetRepository)
+	router := newTestRouter(mockRepo)
+
+	mockRepo.On("GetAll", mock.Anything).Return(([]entity.Pet)(nil), errors.New("Error 1146: Table 'core.pets' doesn't exist"))
+
+	req := httptest.NewRequest(http.MethodGet, "/pets", nil)
+	rec := httptest.NewRecorder()
+
+	router.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusInternalServerError, rec.Code)
+	assert.JSONEq(t, `{"status": "error", "message": "Internal server error"}`, rec.Body.String())
+}
+
+func TestGetPetHandlerSetsETag(t *testing.T) {
+	mockRepo := new(repository.MockPetRepository)
+	router := newTestRouter(mockRepo)
+
+	mockRepo.On("GetByID", 3).Return(&entity.Pet{ID: 3, Name: "bolognese", Version: 5}, nil)
+
+	req := httptest.NewRequest(http.MethodGet, "/pets/3", nil)
+	rec := httptest.NewRecorder()
+
+	router.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, `"5"`, rec.Header().Get("ETag"))
+}
+
+func TestDeletePetHandlerStaleIfMatch(t *testing.T) {
+	mockRepo := new(repository.MockPetRepository)
+	router := newTestRouter(mockRepo)
+
+	mockRepo.On("WithTransaction").Return()
+	mockRepo.On("Delete", 3, 4).Return(0, nil)
+	mockRepo.On("GetByID", 3).Return(&entity.Pet{ID: 3, Name: "bolognese", Version: 5}, nil)
+
+	req := httptest.NewRequest(http.MethodDelete, "/pets/3", nil)
+	req.Header.Set("If-Match", `"4"`)
+	rec := httptest.NewRecorder()
+
+	router.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusPreconditionFailed, rec.Code)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestImportPetsHandlerDryRun(t *testing.T) {
+	mockRepo := new(repository.MockPetRepository)
+	router := newTestRouter(mockRepo)
+
+	storedPets := []entity.Pet{
+		{ID: 3, Species: "dog", PetSize: "small", Name: "bolognese", AverageMaleAdultWeight: 4000, AverageFemaleAdultWeight: 3000, Version: 1},
+		{ID: 9, Species: "dog", PetSize: "tall", Name: "akita", AverageMaleAdultWeight: 40000, AverageFemaleAdultWeight: 35000, Version: 1},
+	}
+
+	mockRepo.On("GetAll", mock.Anything).Return(storedPets, nil)
+	mockRepo.On("Count").Return(2, nil)
+
+	file := `"id","species","pet_size","name","average_male_adult_weight","average_female_adult_weight"` + "\n" +
+		"3,dog,small,bolognese,4500,3000\n" +
+		"4,dog,small,bichon_frize,8000,7000\n"
+
+	req := httptest.NewRequest(http.MethodPost, "/pets/import?dry_run=true", strings.NewReader(file))
+	req.Header.Set("Content-Type", "text/csv")
+	rec := httptest.NewRecorder()
+
+	router.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	var response struct {
+		Data usecase.ImportReport `json:"data"`
+	}
+	assert.NoError(t, json.NewDecoder(rec.Body).Decode(&response))
+	assert.True(t, response.Data.DryRun)
+	assert.False(t, response.Data.Applied)
+	assert.Len(t, response.Data.New, 1)
+	assert.Equal(t, "bichon_frize", response.Data.New[0].Pet.Name)
+	assert.Len(t, response.Data.Changed, 1)
+	assert.Equal(t, uint(4500), response.Data.Changed[0].After.AverageMaleAdultWeight)
+	assert.Len(t, response.Data.Removed, 1)
+	assert.Equal(t, "akita", response.Data.Removed[0].Name)
+	mockRepo.AssertNotCalled(t, "WithTransaction")
+}
+
+func TestRestorePetHandlerNotInTrash(t *testing.T) {
+	mockRepo := new(repository.MockPetRepository)
+	router := newTestRouter(mockRepo)
+
+	mockRepo.On("WithTransaction").Return()
+	mockRepo.On("Restore", 3).Return(0, nil)
+
+	req := httptest.NewRequest(http.MethodPost, "/pets/3/restore", nil)
+	rec := httptest.NewRecorder()
+
+	router.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+	assert.JSONEq(t, `{"status": "error", "message": "pet 3 not found in the trash"}`, rec.Body.String())
+}
+
+func TestGetPetHandlerTimeout(t *testing.T) {
+	mockRepo := new(repository.MockPetRepository)
+	router := newTestRouter(mockRepo)
+
+	mockRepo.On("GetByID", 3).Return((*entity.Pet)(nil), fmt.Errorf("%w: %w", repository.ErrTimeout, context.DeadlineExceeded))
+
+	req := httptest.NewRequest(http.MethodGet, "/pets/3", nil)
+	rec := httptest.NewRecorder()
+
+	router.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusGatewayTimeout, rec.Code)
+	assert.JSONEq(t, `{"status": "error", "message": "request timed out, please retry later"}`, rec.Body.String())
+}
+
+func TestRequestTimeoutCancelsContext(t *testing.T) {
+	router := mux.NewRouter()
+	router.Use(delivery.RequestTimeout(time.Millisecond))
+	router.HandleFunc("/slow", func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+		assert.ErrorIs(t, r.Context().Err(), context.DeadlineExceeded)
+		w.WriteHeader(http.StatusGatewayTimeout)
+	})
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/slow", nil))
+
+	assert.Equal(t, http.StatusGatewayTimeout, rec.Code)
+}
+
+func TestExportPetsHandlerAbortsOnAFailedPage(t *testing.T) {
+	mockRepo := new(repository.MockPetRepository)
+	router := newTestRouter(mockRepo)
+
+	firstPage := make([]entity.Pet, entity.MaxPageLimit+1)
+	for i := range firstPage {
+		firstPage[i] = entity.Pet{ID: i + 1, Species: "dog", PetSize: "small", Name: fmt.Sprintf("dog_%d", i+1)}
+	}
+
+	mockRepo.On("GetAll", mock.MatchedBy(func(page *entity.PageRequest) bool { return page.Cursor == 0 })).Return(firstPage, nil)
+	mockRepo.On("GetAll", mock.MatchedBy(func(page *entity.PageRequest) bool { return page.Cursor != 0 })).Return([]entity.Pet(nil), errors.New("connection lost"))
+	mockRepo.On("Count").Return(len(firstPage)+1, nil)
+
+	req := httptest.NewRequest(http.MethodGet, "/pets/export", nil)
+	rec := httptest.NewRecorder()
+
+	// The status has been sent with the first page, the truncated file must not look complete
+	assert.PanicsWithValue(t, http.ErrAbortHandler, func() { router.ServeHTTP(rec, req) })
+	assert.Equal(t, http.StatusOK, rec.Code)
+	mockRepo.AssertExpectations(t)
+}
@@ -0,0 +1,683 @@
+package tests
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/japhy-tech/backend-test/database_actions"
+	"github.com/japhy-tech/backend-test/internal/database"
+	"github.com/japhy-tech/backend-test/internal/entity"
+	"github.com/japhy-tech/backend-test/internal/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// The conformance suite checks that every PetRepository behaves the same, it runs on
+// an empty repository for every test.
+
+func TestMemoryPetRepositoryConformance(t *testing.T) {
+	testPetRepositoryConformance(t, func(t *testing.T) repository.PetRepository {
+		return repository.NewMemoryPetRepository()
+	})
+}
+
+func TestSQLitePetRepositoryConformance(t *testing.T) {
+	testPetRepositoryConformance(t, func(t *testing.T) repository.PetRepository {
+		db, err := database.NewSQLiteDB(filepath.Join(t.TempDir(), "pets.db"))
+		require.NoError(t, err)
+		t.Cleanup(func() { db.Close() })
+
+		_, err = database_actions.UpgradeSQLiteSchema(db)
+		require.NoError(t, err)
+
+		return repository.NewSQLitePetRepository(db, time.Second)
+	})
+}
+
+// TestMySQLPetRepositoryConformance runs on the database of TEST_MYSQL_DSN
+// (e.g. root:password@tcp(localhost:3306)/test?parseTime=true), whose tables are emptied.
+func TestMySQLPetRepositoryConformance(t *testing.T) {
+	dsn := os.Getenv("TEST_MYSQL_DSN")
+	if dsn == "" {
+		t.Skip("TEST_MYSQL_DSN is not set")
+	}
+
+	db, err := sql.Open("mysql", dsn)
+	require.NoError(t, err)
+	defer db.Close()
+
+	require.NoError(t, database_actions.InitMigrator(db, ""))
+	_, err = database_actions.RunMigrate("up", 0)
+	require.NoError(t, err)
+
+	testPetRepositoryConformance(t, func(t *testing.T) repository.PetRepository {
+		for _, table := range []string{"pets", "audit_log"} {
+			_, err := db.Exec("TRUNCATE TABLE " + table)
+			require.NoError(t, err)
+		}
+
+		// The referenced tables can't be truncated, they are emptied then given the defaults back
+		for _, table := range []string{"species", "pet_sizes"} {
+			_, err := db.Exec("DELETE FROM " + table)
+			require.NoError(t, err)
+		}
+
+		repo := repository.NewPetRepository(db, time.Second)
+		for i := range entity.DefaultSpecies {
+			require.NoError(t, repo.CreateSpecies(context.Background(), &entity.DefaultSpecies[i]))
+		}
+		for i := range entity.DefaultPetSizes {
+			require.NoError(t, repo.CreatePetSize(context.Background(), &entity.DefaultPetSizes[i]))
+		}
+
+		return repo
+	})
+}
+
+// conformancePets are created in this order, with the IDs 1 to 6.
+var conformancePets = []entity.CreatePet{
+	{Species: "dog", PetSize: "small", Name: "affenpinscher", AverageMaleAdultWeight: 6000, AverageFemaleAdultWeight: 5000},
+	{Species: "dog", PetSize: "medium", Name: "beagle", AverageMaleAdultWeight: 12000, AverageFemaleAdultWeight: 10000},
+	{Species: "cat", PetSize: "small", Name: "siamese", AverageMaleAdultWeight: 4000, AverageFemaleAdultWeight: 3000},
+	{Species: "cat", PetSize: "medium", Name: "maine_coon", AverageMaleAdultWeight: 8000, AverageFemaleAdultWeight: 5500},
+	{Species: "dog", PetSize: "tall", Name: "great_dane", AverageMaleAdultWeight: 70000, AverageFemaleAdultWeight: 55000},
+	{Species: "dog", PetSize: "small", Name: "bichon_frize", AverageMaleAdultWeight: 8000, AverageFemaleAdultWeight: 7000},
+}
+
+func testPetRepositoryConformance(t *testing.T, newRepository func(t *testing.T) repository.PetRepository) {
+	ctx := context.Background()
+
+	// seeded returns a repository holding the conformancePets
+	seeded := func(t *testing.T) repository.PetRepository {
+		repo := newRepository(t)
+		for i := range conformancePets {
+			id, err := repo.Create(ctx, &conformancePets[i])
+			require.NoError(t, err)
+			require.Equal(t, i+1, id)
+		}
+
+		return repo
+	}
+
+	t.Run("CreateAndGetByID", func(t *testing.T) {
+		repo := seeded(t)
+
+		pet, err := repo.GetByID(ctx, 4)
+		require.NoError(t, err)
+		assert.WithinDuration(t, time.Now(), pet.CreatedAt, time.Minute)
+		assert.Equal(t, pet.CreatedAt, pet.UpdatedAt)
+
+		pet.CreatedAt, pet.UpdatedAt = time.Time{}, time.Time{}
+		assert.Equal(t, &entity.Pet{
+			ID:                       4,
+			Species:                  "cat",
+			PetSize:                  "medium",
+			Name:                     "maine_coon",
+			AverageMaleAdultWeight:   8000,
+			AverageFemaleAdultWeight: 5500,
+			Version:                  entity.InitialVersion,
+		}, pet)
+
+		_, err = repo.GetByID(ctx, 42)
+		assert.ErrorIs(t, err, repository.ErrNotFound)
+	})
+
+	t.Run("GetAllPaginates", func(t *testing.T) {
+		repo := seeded(t)
+
+		page := &entity.PageRequest{Limit: 2, Sort: "name", Order: entity.SortAsc}
+		pets, err := repo.GetAll(ctx, page)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"affenpinscher", "beagle"}, petNames(pets))
+
+		page.Cursor = pets[1].ID
+		pets, err = repo.GetAll(ctx, page)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"bichon_frize", "great_dane"}, petNames(pets))
+
+		// The pets of the same size are ordered by ID
+		pets, err = repo.GetAll(ctx, &entity.PageRequest{Limit: 10, Sort: "pet_size", Order: entity.SortDesc})
+		assert.NoError(t, err)
+		assert.Equal(t, []int{5, 6, 3, 1, 4, 2}, petIDs(pets))
+
+		pets, err = repo.GetAll(ctx, &entity.PageRequest{Limit: 10, Cursor: 3, Sort: "pet_size", Order: entity.SortDesc})
+		assert.NoError(t, err)
+		assert.Equal(t, []int{1, 4, 2}, petIDs(pets))
+
+		pets, err = repo.GetAll(ctx, &entity.PageRequest{Limit: 3, Cursor: 5, Sort: "id", Order: entity.SortDesc})
+		assert.NoError(t, err)
+		assert.Equal(t, []int{4, 3, 2}, petIDs(pets))
+	})
+
+	t.Run("Counts", func(t *testing.T) {
+		repo := seeded(t)
+
+		_, err := repo.Delete(ctx, 3, 0)
+		require.NoError(t, err)
+
+		count, err := repo.Count(ctx)
+		assert.NoError(t, err)
+		assert.Equal(t, 5, count)
+
+		counts, err := repo.CountBySpecies(ctx)
+		assert.NoError(t, err)
+		assert.Equal(t, map[string]int{"dog": 4, "cat": 1}, counts)
+
+		count, err = repo.CountDeleted(ctx)
+		assert.NoError(t, err)
+		assert.Equal(t, 1, count)
+	})
+
+	t.Run("UpdateChecksTheVersion", func(t *testing.T) {
+		repo := seeded(t)
+		update := &entity.UpdatePet{Species: "dog", PetSize: "medium", Name: "harrier", AverageMaleAdultWeight: 25000, AverageFemaleAdultWeight: 22000}
+
+		affected, err := repo.Update(ctx, 2, update, 2)
+		assert.NoError(t, err)
+		assert.Equal(t, 0, affected)
+
+		affected, err = repo.Update(ctx, 2, update, 1)
+		assert.NoError(t, err)
+		assert.Equal(t, 1, affected)
+
+		// Any version matches when 0
+		update.Name = "english_harrier"
+		affected, err = repo.Update(ctx, 2, update, 0)
+		assert.NoError(t, err)
+		assert.Equal(t, 1, affected)
+
+		pet, err := repo.GetByID(ctx, 2)
+		assert.NoError(t, err)
+		assert.Equal(t, "english_harrier", pet.Name)
+		assert.Equal(t, uint(25000), pet.AverageMaleAdultWeight)
+		assert.Equal(t, 3, pet.Version)
+
+		affected, err = repo.Update(ctx, 42, update, 0)
+		assert.NoError(t, err)
+		assert.Equal(t, 0, affected)
+	})
+
+	t.Run("PatchOnlyChangesTheGivenFields", func(t *testing.T) {
+		repo := seeded(t)
+		name := "toy_beagle"
+
+		affected, err := repo.Patch(ctx, 2, &entity.PatchPet{Name: &name}, 1)
+		assert.NoError(t, err)
+		assert.Equal(t, 1, affected)
+
+		pet, err := repo.GetByID(ctx, 2)
+		assert.NoError(t, err)
+		assert.Equal(t, "toy_beagle", pet.Name)
+		assert.Equal(t, "medium", pet.PetSize)
+		assert.Equal(t, 2, pet.Version)
+
+		affected, err = repo.Patch(ctx, 2, &entity.PatchPet{}, 0)
+		assert.NoError(t, err)
+		assert.Equal(t, 0, affected)
+	})
+
+	t.Run("TrashAndRestore", func(t *testing.T) {
+		repo := seeded(t)
+
+		affected, err := repo.Delete(ctx, 3, 2)
+		assert.NoError(t, err)
+		assert.Equal(t, 0, affected)
+
+		affected, err = repo.Delete(ctx, 3, 1)
+		assert.NoError(t, err)
+		assert.Equal(t, 1, affected)
+
+		_, err = repo.GetByID(ctx, 3)
+		assert.ErrorIs(t, err, repository.ErrNotFound)
+
+		// The pets in the trash can't be changed
+		affected, err = repo.Delete(ctx, 3, 0)
+		assert.NoError(t, err)
+		assert.Equal(t, 0, affected)
+
+		deleted, err := repo.GetDeleted(ctx, entity.NewPageRequest())
+		assert.NoError(t, err)
+		require.Len(t, deleted, 1)
+		assert.Equal(t, "siamese", deleted[0].Name)
+		assert.Equal(t, 2, deleted[0].Version)
+		assert.NotNil(t, deleted[0].DeletedAt)
+
+		affected, err = repo.Restore(ctx, 3)
+		assert.NoError(t, err)
+		assert.Equal(t, 1, affected)
+
+		affected, err = repo.Restore(ctx, 3)
+		assert.NoError(t, err)
+		assert.Equal(t, 0, affected)
+
+		pet, err := repo.GetByID(ctx, 3)
+		assert.NoError(t, err)
+		assert.Equal(t, 3, pet.Version)
+		assert.Nil(t, pet.DeletedAt)
+	})
+
+	t.Run("SpeciesAndNameAreUniqueOutOfTheTrash", func(t *testing.T) {
+		repo := seeded(t)
+		siamese := conformancePets[2]
+
+		_, err := repo.Create(ctx, &siamese)
+		assert.ErrorIs(t, err, repository.ErrDuplicate)
+
+		// Another species may have the name
+		_, err = repo.Create(ctx, &entity.CreatePet{Species: "dog", PetSize: "small", Name: "siamese"})
+		assert.NoError(t, err)
+
+		name := "beagle"
+		_, err = repo.Patch(ctx, 6, &entity.PatchPet{Name: &name}, 0)
+		assert.ErrorIs(t, err, repository.ErrDuplicate)
+
+		_, err = repo.Update(ctx, 6, &entity.UpdatePet{Species: "dog", PetSize: "medium", Name: "beagle"}, 0)
+		assert.ErrorIs(t, err, repository.ErrDuplicate)
+
+		// A pet in the trash frees its species and name, until it is restored
+		affected, err := repo.Delete(ctx, 3, 0)
+		require.NoError(t, err)
+		require.Equal(t, 1, affected)
+
+		id, err := repo.Create(ctx, &siamese)
+		assert.NoError(t, err)
+
+		_, err = repo.Restore(ctx, 3)
+		assert.ErrorIs(t, err, repository.ErrDuplicate)
+
+		affected, err = repo.Delete(ctx, id, 0)
+		require.NoError(t, err)
+		require.Equal(t, 1, affected)
+
+		count, err := repo.CountDeleted(ctx)
+		assert.NoError(t, err)
+		assert.Equal(t, 2, count)
+	})
+
+	t.Run("ChangesMoveTheUpdateTime", func(t *testing.T) {
+		repo := seeded(t)
+
+		created, err := repo.GetByID(ctx, 2)
+		require.NoError(t, err)
+
+		time.Sleep(2 * time.Millisecond)
+		name := "toy_beagle"
+		_, err = repo.Patch(ctx, 2, &entity.PatchPet{Name: &name}, 0)
+		require.NoError(t, err)
+
+		patched, err := repo.GetByID(ctx, 2)
+		require.NoError(t, err)
+		assert.Equal(t, created.CreatedAt, patched.CreatedAt)
+		assert.True(t, patched.UpdatedAt.After(created.UpdatedAt))
+
+		time.Sleep(2 * time.Millisecond)
+		_, err = repo.Delete(ctx, 2, 0)
+		require.NoError(t, err)
+
+		deleted, err := repo.GetDeleted(ctx, entity.NewPageRequest())
+		require.NoError(t, err)
+		require.Len(t, deleted, 1)
+		assert.Equal(t, *deleted[0].DeletedAt, deleted[0].UpdatedAt)
+		assert.True(t, deleted[0].UpdatedAt.After(patched.UpdatedAt))
+	})
+
+	t.Run("ReferenceData", func(t *testing.T) {
+		repo := newRepository(t)
+
+		species, err := repo.GetAllSpecies(ctx)
+		assert.NoError(t, err)
+		assert.Equal(t, entity.DefaultSpecies, species)
+
+		sizes, err := repo.GetAllPetSizes(ctx)
+		assert.NoError(t, err)
+		assert.Equal(t, entity.DefaultPetSizes, sizes)
+
+		rabbit := entity.Species{Name: "rabbit", MinWeight: 500, MaxWeight: 8000}
+		assert.NoError(t, repo.CreateSpecies(ctx, &rabbit))
+		assert.ErrorIs(t, repo.CreateSpecies(ctx, &rabbit), repository.ErrDuplicate)
+
+		assert.NoError(t, repo.CreatePetSize(ctx, &entity.PetSize{Name: "tiny", Position: 0}))
+		assert.ErrorIs(t, repo.CreatePetSize(ctx, &entity.PetSize{Name: "tiny", Position: 4}), repository.ErrDuplicate)
+
+		species, err = repo.GetAllSpecies(ctx)
+		assert.NoError(t, err)
+		assert.Equal(t, []entity.Species{entity.DefaultSpecies[0], entity.DefaultSpecies[1], rabbit}, species)
+
+		sizes, err = repo.GetAllPetSizes(ctx)
+		assert.NoError(t, err)
+		assert.Equal(t, "tiny", sizes[0].Name)
+
+		_, err = repo.Create(ctx, &entity.CreatePet{Species: "rabbit", PetSize: "tiny", Name: "netherland_dwarf"})
+		assert.NoError(t, err)
+
+		// The pets can only have a stored species and size
+		_, err = repo.Create(ctx, &entity.CreatePet{Species: "hamster", PetSize: "tiny", Name: "syrian"})
+		assert.ErrorIs(t, err, repository.ErrConstraint)
+
+		_, err = repo.Update(ctx, 1, &entity.UpdatePet{Species: "rabbit", PetSize: "huge", Name: "netherland_dwarf"}, 0)
+		assert.ErrorIs(t, err, repository.ErrConstraint)
+	})
+
+	t.Run("RenamesCascadeToThePets", func(t *testing.T) {
+		repo := seeded(t)
+
+		stored, err := repo.GetByID(ctx, 1)
+		require.NoError(t, err)
+
+		affected, err := repo.Delete(ctx, 5, 0)
+		require.NoError(t, err)
+		require.Equal(t, 1, affected)
+
+		affected, err = repo.UpdatePetSize(ctx, "tall", &entity.PetSize{Name: "large", Position: 3})
+		assert.NoError(t, err)
+		assert.Equal(t, 1, affected)
+
+		affected, err = repo.UpdateSpecies(ctx, "dog", &entity.Species{Name: "canine", MinWeight: 1000, MaxWeight: 90000})
+		assert.NoError(t, err)
+		assert.Equal(t, 1, affected)
+
+		// The pets in the trash are renamed too, every rename is a change of their version
+		deleted, err := repo.GetDeleted(ctx, entity.NewPageRequest())
+		require.NoError(t, err)
+		require.Len(t, deleted, 1)
+		assert.Equal(t, "canine", deleted[0].Species)
+		assert.Equal(t, "large", deleted[0].PetSize)
+		assert.Equal(t, entity.InitialVersion+3, deleted[0].Version)
+
+		pet, err := repo.GetByID(ctx, 1)
+		require.NoError(t, err)
+		assert.Equal(t, "canine", pet.Species)
+		assert.Equal(t, entity.InitialVersion+1, pet.Version)
+		assert.False(t, pet.UpdatedAt.Before(stored.UpdatedAt))
+
+		_, err = repo.UpdateSpecies(ctx, "cat", &entity.Species{Name: "canine", MinWeight: 1000, MaxWeight: 15000})
+		assert.ErrorIs(t, err, repository.ErrDuplicate)
+
+		affected, err = repo.UpdateSpecies(ctx, "dog", &entity.Species{Name: "dog", MinWeight: 1000, MaxWeight: 100000})
+		assert.NoError(t, err)
+		assert.Equal(t, 0, affected)
+
+		affected, err = repo.UpdatePetSize(ctx, "tall", &entity.PetSize{Name: "tall", Position: 3})
+		assert.NoError(t, err)
+		assert.Equal(t, 0, affected)
+	})
+
+	t.Run("PetsByReference", func(t *testing.T) {
+		repo := seeded(t)
+
+		affected, err := repo.Delete(ctx, 6, 0)
+		require.NoError(t, err)
+		require.Equal(t, 1, affected)
+
+		// The pets in the trash are listed too
+		pets, err := repo.GetPetsBySpecies(ctx, "dog")
+		require.NoError(t, err)
+		assert.Equal(t, []string{"affenpinscher", "beagle", "great_dane", "bichon_frize"}, petNames(pets))
+		assert.NotNil(t, pets[3].DeletedAt)
+
+		pets, err = repo.GetPetsByPetSize(ctx, "small")
+		require.NoError(t, err)
+		assert.Equal(t, []string{"affenpinscher", "siamese", "bichon_frize"}, petNames(pets))
+
+		pets, err = repo.GetPetsBySpecies(ctx, "rabbit")
+		require.NoError(t, err)
+		assert.Empty(t, pets)
+	})
+
+	t.Run("ReferencesInUseCantBeDeleted", func(t *testing.T) {
+		repo := seeded(t)
+
+		_, err := repo.DeleteSpecies(ctx, "cat")
+		assert.ErrorIs(t, err, repository.ErrInUse)
+
+		_, err = repo.DeletePetSize(ctx, "medium")
+		assert.ErrorIs(t, err, repository.ErrInUse)
+
+		// The pets in the trash keep their species until they are purged
+		for _, id := range []int{3, 4} {
+			_, err := repo.Delete(ctx, id, 0)
+			require.NoError(t, err)
+		}
+
+		_, err = repo.DeleteSpecies(ctx, "cat")
+		assert.ErrorIs(t, err, repository.ErrInUse)
+
+		_, err = repo.Purge(ctx, time.Now().Add(time.Minute))
+		require.NoError(t, err)
+
+		affected, err := repo.DeleteSpecies(ctx, "cat")
+		assert.NoError(t, err)
+		assert.Equal(t, 1, affected)
+
+		affected, err = repo.DeleteSpecies(ctx, "cat")
+		assert.NoError(t, err)
+		assert.Equal(t, 0, affected)
+
+		species, err := repo.GetAllSpecies(ctx)
+		assert.NoError(t, err)
+		assert.Equal(t, []entity.Species{entity.DefaultSpecies[1]}, species)
+	})
+
+	t.Run("Purge", func(t *testing.T) {
+		repo := seeded(t)
+
+		for _, id := range []int{1, 5} {
+			_, err := repo.Delete(ctx, id, 0)
+			require.NoError(t, err)
+		}
+
+		purged, err := repo.Purge(ctx, time.Now().Add(-time.Hour))
+		assert.NoError(t, err)
+		assert.Equal(t, 0, purged)
+
+		purged, err = repo.Purge(ctx, time.Now().Add(time.Hour))
+		assert.NoError(t, err)
+		assert.Equal(t, 2, purged)
+
+		count, err := repo.CountDeleted(ctx)
+		assert.NoError(t, err)
+		assert.Equal(t, 0, count)
+
+		affected, err := repo.Restore(ctx, 1)
+		assert.NoError(t, err)
+		assert.Equal(t, 0, affected)
+	})
+
+	t.Run("SearchPets", func(t *testing.T) {
+		repo := seeded(t)
+
+		// The pets in the trash are never found
+		_, err := repo.Delete(ctx, 1, 0)
+		require.NoError(t, err)
+
+		tests := []struct {
+			name     string
+			search   entity.SearchPets
+			expected []int
+		}{
+			{"everything", entity.SearchPets{}, []int{2, 3, 4, 5, 6}},
+			{"species", entity.SearchPets{Species: entity.StringList{"cat"}}, []int{3, 4}},
+			{"species and sizes", entity.SearchPets{Species: entity.StringList{"dog"}, PetSize: entity.StringList{"small", "tall"}}, []int{5, 6}},
+			{"name contains, regardless of the case", entity.SearchPets{Name: "E_C"}, []int{4}},
+			{"name prefix", entity.SearchPets{Name: "b", NameMatch: entity.NameMatchPrefix}, []int{2, 6}},
+			{"name with a LIKE wildcard", entity.SearchPets{Name: "%"}, nil},
+			{"name with a LIKE single character wildcard", entity.SearchPets{Name: "_"}, []int{4, 5, 6}},
+			{"both weights within the range", entity.SearchPets{MinWeight: 5000, MaxWeight: 9000}, []int{4, 6}},
+			{"weights overlapping the range", entity.SearchPets{MinWeight: 9000, MaxWeight: 11000, WeightMode: entity.WeightModeOverlaps}, []int{2}},
+			{"weights overlapping an open range", entity.SearchPets{MaxWeight: 5000, WeightMode: entity.WeightModeOverlaps}, []int{3}},
+			{"male weight", entity.SearchPets{MinMaleWeight: 8000, MaxMaleWeight: 12000}, []int{2, 4, 6}},
+			{"female weight", entity.SearchPets{MinFemaleWeight: 5500, MaxFemaleWeight: 7000}, []int{4, 6}},
+			{"every criterion", entity.SearchPets{Species: entity.StringList{"dog"}, Name: "a", MinMaleWeight: 7000, MaxFemaleWeight: 60000}, []int{2, 5}},
+		}
+
+		for _, test := range tests {
+			t.Run(test.name, func(t *testing.T) {
+				pets, err := repo.SearchPets(ctx, &test.search, entity.NewPageRequest())
+				assert.NoError(t, err)
+				assert.Equal(t, test.expected, petIDs(pets))
+
+				count, err := repo.CountSearchPets(ctx, &test.search)
+				assert.NoError(t, err)
+				assert.Equal(t, len(test.expected), count)
+			})
+		}
+
+		search := &entity.SearchPets{Species: entity.StringList{"dog"}}
+		page := &entity.PageRequest{Limit: 2, Sort: "average_male_adult_weight", Order: entity.SortDesc}
+
+		pets, err := repo.SearchPets(ctx, search, page)
+		assert.NoError(t, err)
+		assert.Equal(t, []int{5, 2}, petIDs(pets))
+
+		page.Cursor = 2
+		pets, err = repo.SearchPets(ctx, search, page)
+		assert.NoError(t, err)
+		assert.Equal(t, []int{6}, petIDs(pets))
+	})
+
+	t.Run("WithTransaction", func(t *testing.T) {
+		repo := newRepository(t)
+
+		failure := errors.New("failure")
+		err := repo.WithTransaction(ctx, func(tx repository.PetRepository) error {
+			_, err := tx.Create(ctx, &conformancePets[0])
+			require.NoError(t, err)
+
+			count, err := tx.Count(ctx)
+			require.NoError(t, err)
+			assert.Equal(t, 1, count)
+
+			return failure
+		})
+		assert.ErrorIs(t, err, failure)
+
+		count, err := repo.Count(ctx)
+		assert.NoError(t, err)
+		assert.Equal(t, 0, count)
+
+		err = repo.WithTransaction(ctx, func(tx repository.PetRepository) error {
+			_, err := tx.Create(ctx, &conformancePets[0])
+			if err != nil {
+				return err
+			}
+
+			// A nested transaction runs in the ongoing one
+			return tx.WithTransaction(ctx, func(nested repository.PetRepository) error {
+				_, err := nested.Create(ctx, &conformancePets[1])
+				return err
+			})
+		})
+		assert.NoError(t, err)
+
+		pets, err := repo.GetAll(ctx, entity.NewPageRequest())
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"affenpinscher", "beagle"}, petNames(pets))
+	})
+
+	t.Run("WithSavepoint", func(t *testing.T) {
+		repo := newRepository(t)
+
+		failure := errors.New("failure")
+		err := repo.WithTransaction(ctx, func(tx repository.PetRepository) error {
+			_, err := tx.Create(ctx, &conformancePets[0])
+			require.NoError(t, err)
+
+			// Only the changes made within the failed savepoint are rolled back
+			err = tx.WithSavepoint(ctx, func(savepoint repository.PetRepository) error {
+				_, err := savepoint.Create(ctx, &conformancePets[1])
+				require.NoError(t, err)
+
+				return failure
+			})
+			assert.ErrorIs(t, err, failure)
+
+			return tx.WithSavepoint(ctx, func(savepoint repository.PetRepository) error {
+				_, err := savepoint.Create(ctx, &conformancePets[2])
+				return err
+			})
+		})
+		assert.NoError(t, err)
+
+		pets, err := repo.GetAll(ctx, entity.NewPageRequest())
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"affenpinscher", "siamese"}, petNames(pets))
+	})
+
+	t.Run("AuditEntries", func(t *testing.T) {
+		repo := newRepository(t)
+		start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+		entries := []entity.AuditEntry{
+			{EntityType: entity.AuditEntityPet, EntityID: 1, Action: entity.AuditActionCreate, Actor: "alice", CreatedAt: start, After: json.RawMessage(`{"name":"beagle"}`)},
+			{EntityType: entity.AuditEntityPet, EntityID: 1, Action: entity.AuditActionUpdate, Actor: "bob", CreatedAt: start.Add(time.Hour), Before: json.RawMessage(`{"name":"beagle"}`), After: json.RawMessage(`{"name":"harrier"}`)},
+			{EntityType: entity.AuditEntityPet, EntityID: 2, Action: entity.AuditActionCreate, Actor: "alice", CreatedAt: start.Add(2 * time.Hour)},
+			{EntityType: entity.AuditEntityPet, EntityID: 1, Action: entity.AuditActionDelete, Actor: "alice", CreatedAt: start.Add(3 * time.Hour)},
+		}
+		for i := range entries {
+			require.NoError(t, repo.InsertAuditEntry(ctx, &entries[i]))
+		}
+
+		found, err := repo.GetAuditEntries(ctx, &entity.AuditFilter{EntityID: 1}, &entity.PageRequest{Limit: 2, Order: entity.SortDesc})
+		assert.NoError(t, err)
+		require.Len(t, found, 2)
+		assert.Equal(t, entity.AuditActionDelete, found[0].Action)
+		assert.Equal(t, entity.AuditActionUpdate, found[1].Action)
+		assert.True(t, start.Add(time.Hour).Equal(found[1].CreatedAt))
+		assert.JSONEq(t, `{"name":"beagle"}`, string(found[1].Before))
+		assert.JSONEq(t, `{"name":"harrier"}`, string(found[1].After))
+
+		found, err = repo.GetAuditEntries(ctx, &entity.AuditFilter{EntityID: 1}, &entity.PageRequest{Limit: 2, Cursor: found[1].ID, Order: entity.SortDesc})
+		assert.NoError(t, err)
+		require.Len(t, found, 1)
+		assert.Equal(t, entity.AuditActionCreate, found[0].Action)
+		assert.Nil(t, found[0].Before)
+
+		// From is inclusive and To is exclusive
+		filter := &entity.AuditFilter{Actor: "alice", From: start, To: start.Add(3 * time.Hour)}
+		found, err = repo.GetAuditEntries(ctx, filter, &entity.PageRequest{Limit: 10, Order: entity.SortAsc})
+		assert.NoError(t, err)
+		assert.Len(t, found, 2)
+
+		count, err := repo.CountAuditEntries(ctx, filter)
+		assert.NoError(t, err)
+		assert.Equal(t, 2, count)
+
+		count, err = repo.CountAuditEntries(ctx, &entity.AuditFilter{Action: entity.AuditActionCreate})
+		assert.NoError(t, err)
+		assert.Equal(t, 2, count)
+	})
+
+	t.Run("CanceledContext", func(t *testing.T) {
+		repo := newRepository(t)
+
+		canceled, cancel := context.WithCancel(ctx)
+		cancel()
+
+		_, err := repo.Count(canceled)
+		assert.ErrorIs(t, err, context.Canceled)
+	})
+}
+
+func petIDs(pets []entity.Pet) []int {
+	var ids []int
+	for _, pet := range pets {
+		ids = append(ids, pet.ID)
+	}
+
+	return ids
+}
+
+func petNames(pets []entity.Pet) []string {
+	var names []string
+	for _, pet := range pets {
+		names = append(names, pet.Name)
+	}
+
+	return names
+}
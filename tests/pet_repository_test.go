@@ -1,44 +1,207 @@
 package tests
 
 import (
+	"context"
 	"testing"
+	"time"
 
 	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/go-sql-driver/mysql"
 	"github.com/japhy-tech/backend-test/internal/entity"
 	"github.com/japhy-tech/backend-test/internal/repository"
 	"github.com/stretchr/testify/assert"
 )
 
+// petTimestamp is the creation and last update time of the scanned pets.
+var petTimestamp = time.Date(2024, time.March, 1, 12, 0, 0, 0, time.UTC)
+
 func TestSearchPets(t *testing.T) {
 	db, mock, err := sqlmock.New()
 	assert.NoError(t, err)
 	defer db.Close()
 
-	repo := repository.NewPetRepository(db)
+	repo := repository.NewPetRepository(db, 0)
 
 	searchCriteria := &entity.SearchPets{
-		Species:   "dog",
+		Species:   entity.StringList{"dog"},
 		MinWeight: 40,
 		MaxWeight: 70,
 	}
 
-	rows := sqlmock.NewRows([]string{"id", "species", "pet_size", "name", "average_male_adult_weight", "average_female_adult_weight"}).
-		AddRow(2, "dog", "small", "little_one", 60, 50)
+	rows := sqlmock.NewRows([]string{"id", "species", "pet_size", "name", "average_male_adult_weight", "average_female_adult_weight", "version", "created_at", "updated_at", "deleted_at"}).
+		AddRow(2, "dog", "small", "little_one", 60, 50, 1, petTimestamp, petTimestamp, nil)
 
 	query := `
-		SELECT id, species, pet_size, name, average_male_adult_weight, average_female_adult_weight 
+		SELECT id, species, pet_size, name, average_male_adult_weight, average_female_adult_weight, version, created_at, updated_at, deleted_at 
 		FROM pets 
-		WHERE 1=1 AND species = \? AND \(average_male_adult_weight >= \? OR average_female_adult_weight >= \?\) AND \(average_male_adult_weight <= \? OR average_female_adult_weight <= \?\)
+		WHERE deleted_at IS NULL AND species IN \(\?\) AND average_male_adult_weight >= \? AND average_female_adult_weight >= \? AND average_male_adult_weight <= \? AND average_female_adult_weight <= \?
 	`
 
 	mock.ExpectQuery(query).
-		WithArgs(searchCriteria.Species, searchCriteria.MinWeight, searchCriteria.MinWeight, searchCriteria.MaxWeight, searchCriteria.MaxWeight).
+		WithArgs("dog", searchCriteria.MinWeight, searchCriteria.MinWeight, searchCriteria.MaxWeight, searchCriteria.MaxWeight, entity.MaxPageLimit).
 		WillReturnRows(rows)
 
-	result, err := repo.SearchPets(searchCriteria)
+	result, err := repo.SearchPets(context.Background(), searchCriteria, entity.NewPageRequest())
 
 	assert.NoError(t, err)
 	assert.Len(t, result, 1)
 	assert.Equal(t, "little_one", result[0].Name)
 	assert.NoError(t, mock.ExpectationsWereMet())
 }
+
+func TestGetAllPetsAfterCursor(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := repository.NewPetRepository(db, 0)
+
+	page := &entity.PageRequest{
+		Limit:  2,
+		Cursor: 5,
+		Sort:   "name",
+		Order:  entity.SortDesc,
+	}
+
+	rows := sqlmock.NewRows([]string{"id", "species", "pet_size", "name", "average_male_adult_weight", "average_female_adult_weight", "version", "created_at", "updated_at", "deleted_at"}).
+		AddRow(3, "dog", "small", "bolognese", 4000, 3000, 1, petTimestamp, petTimestamp, nil)
+
+	query := `WHERE deleted_at IS NULL AND \(name, id\) < \(SELECT name, id FROM pets WHERE id = \?\) ORDER BY name DESC, id DESC LIMIT \?`
+
+	mock.ExpectQuery(query).
+		WithArgs(page.Cursor, page.Limit).
+		WillReturnRows(rows)
+
+	result, err := repo.GetAll(context.Background(), page)
+
+	assert.NoError(t, err)
+	assert.Len(t, result, 1)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSearchPetsFilters(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := repository.NewPetRepository(db, 0)
+
+	searchCriteria := &entity.SearchPets{
+		Species:         entity.StringList{"dog", "cat"},
+		PetSize:         entity.StringList{"small"},
+		Name:            "Bichon_",
+		NameMatch:       entity.NameMatchPrefix,
+		MinWeight:       3000,
+		WeightMode:      entity.WeightModeOverlaps,
+		MaxFemaleWeight: 8000,
+	}
+
+	query := `SELECT COUNT\(\*\) FROM pets WHERE deleted_at IS NULL AND species IN \(\?, \?\) AND pet_size IN \(\?\) AND LOWER\(name\) LIKE \? ESCAPE '!' ` +
+		`AND \(average_male_adult_weight >= \? OR average_female_adult_weight >= \?\) AND average_female_adult_weight <= \?`
+
+	mock.ExpectQuery(query).
+		WithArgs("dog", "cat", "small", "bichon!_%", 3000, 3000, 8000).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(4))
+
+	count, err := repo.CountSearchPets(context.Background(), searchCriteria)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 4, count)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestCreatePetDuplicate(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := repository.NewPetRepository(db, 0)
+
+	mock.ExpectExec("INSERT INTO pets").
+		WillReturnError(&mysql.MySQLError{Number: 1062, Message: "Duplicate entry 'dog-akita' for key 'pets.species_name'"})
+
+	_, err = repo.Create(context.Background(), &entity.CreatePet{Species: "dog", Name: "akita"})
+
+	assert.ErrorIs(t, err, repository.ErrDuplicate)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestCreatePetViolatingACheckConstraint(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := repository.NewPetRepository(db, 0)
+
+	mock.ExpectExec("INSERT INTO pets").
+		WillReturnError(&mysql.MySQLError{Number: 3819, Message: "Check constraint 'chk_pets_species' is violated."})
+
+	_, err = repo.Create(context.Background(), &entity.CreatePet{Species: "bird", Name: "parrot"})
+
+	assert.ErrorIs(t, err, repository.ErrConstraint)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestUpdatePetWithVersion(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := repository.NewPetRepository(db, 0)
+
+	pet := &entity.UpdatePet{
+		Species:                  "dog",
+		PetSize:                  "small",
+		Name:                     "bolognese",
+		AverageMaleAdultWeight:   4000,
+		AverageFemaleAdultWeight: 3000,
+	}
+
+	mock.ExpectExec(`version = version \+ 1, updated_at = \? WHERE id = \? AND deleted_at IS NULL AND version = \?`).
+		WithArgs(pet.Species, pet.PetSize, pet.Name, pet.AverageMaleAdultWeight, pet.AverageFemaleAdultWeight, sqlmock.AnyArg(), 3, 2).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	rowsAffected, err := repo.Update(context.Background(), 3, pet, 2)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 0, rowsAffected)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPurgeDeletedPets(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := repository.NewPetRepository(db, 0)
+
+	deletedBefore := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+
+	mock.ExpectExec(`DELETE FROM pets WHERE deleted_at IS NOT NULL AND deleted_at < \?`).
+		WithArgs(deletedBefore).
+		WillReturnResult(sqlmock.NewResult(0, 3))
+
+	purged, err := repo.Purge(context.Background(), deletedBefore)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 3, purged)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetPetByIDQueryTimeout(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := repository.NewPetRepository(db, 10*time.Millisecond)
+
+	mock.ExpectQuery(`SELECT .* FROM pets WHERE id = \?`).
+		WithArgs(3).
+		WillDelayFor(time.Second).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+	_, err = repo.GetByID(context.Background(), 3)
+
+	assert.ErrorIs(t, err, repository.ErrTimeout)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
@@ -1,17 +1,22 @@
 package tests
 
 import (
+	"context"
 	"testing"
+	"time"
 
 	"github.com/japhy-tech/backend-test/internal/entity"
 	"github.com/japhy-tech/backend-test/internal/repository"
 	"github.com/japhy-tech/backend-test/internal/usecase"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 )
 
 func TestCreatePetUsecase(t *testing.T) {
 	mockRepo := new(repository.MockPetRepository)
 	usecase := usecase.NewPetUsecase(mockRepo)
+	mockReferences(mockRepo)
 
 	pet := &entity.CreatePet{
 		Species:                  "dog",
@@ -28,13 +33,131 @@ func TestCreatePetUsecase(t *testing.T) {
 		Name:                     "doggo",
 		AverageMaleAdultWeight:   60000,
 		AverageFemaleAdultWeight: 58000,
+		Version:                  1,
+		CreatedAt:                time.Date(2024, time.March, 1, 12, 0, 0, 0, time.UTC),
+		UpdatedAt:                time.Date(2024, time.March, 1, 12, 0, 0, 0, time.UTC),
 	}
 
+	mockRepo.On("WithTransaction").Return()
 	mockRepo.On("Create", pet).Return(1, nil)
+	mockRepo.On("GetByID", 1).Return(createdPet, nil)
+	mockRepo.On("InsertAuditEntry", mock.Anything).Return(nil)
 
-	result, err := usecase.CreatePet(pet)
+	result, err := usecase.CreatePet(context.Background(), pet)
 
 	assert.NoError(t, err)
 	assert.Equal(t, createdPet, result)
 	mockRepo.AssertExpectations(t)
 }
+
+func TestGetPetsUsecaseNextCursor(t *testing.T) {
+	mockRepo := new(repository.MockPetRepository)
+	usecase := usecase.NewPetUsecase(mockRepo)
+	mockReferences(mockRepo)
+
+	page := &entity.PageRequest{Limit: 2, Sort: "id", Order: entity.SortAsc}
+	lookAhead := &entity.PageRequest{Limit: 3, Sort: "id", Order: entity.SortAsc}
+
+	pets := []entity.Pet{{ID: 1}, {ID: 2}, {ID: 3}}
+
+	mockRepo.On("GetAll", lookAhead).Return(pets, nil)
+	mockRepo.On("Count").Return(10, nil)
+
+	result, err := usecase.GetPets(context.Background(), page)
+
+	assert.NoError(t, err)
+	assert.Len(t, result.Pets, 2)
+	assert.Equal(t, 2, result.NextCursor)
+	assert.Equal(t, 10, result.Total)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestPatchPetUsecaseReturnsStoredPet(t *testing.T) {
+	mockRepo := new(repository.MockPetRepository)
+	usecase := usecase.NewPetUsecase(mockRepo)
+	mockReferences(mockRepo)
+
+	name := "doggo"
+	patch := &entity.PatchPet{Name: &name}
+
+	storedPet := &entity.Pet{
+		ID:                       1,
+		Species:                  "dog",
+		PetSize:                  "tall",
+		Name:                     "doggo",
+		AverageMaleAdultWeight:   60000,
+		AverageFemaleAdultWeight: 58000,
+		Version:                  3,
+	}
+
+	mockRepo.On("WithTransaction").Return()
+	mockRepo.On("GetByID", 1).Return(storedPet, nil)
+	// Without a version from the caller, the patch is conditional on the version of the validated pet
+	mockRepo.On("Patch", 1, patch, 3).Return(1, nil)
+	mockRepo.On("InsertAuditEntry", mock.Anything).Return(nil)
+
+	result, err := usecase.PatchPet(context.Background(), 1, patch, 0)
+
+	assert.NoError(t, err)
+	assert.Equal(t, storedPet, result)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestCreatePetUsecaseWeightBounds(t *testing.T) {
+	mockRepo := new(repository.MockPetRepository)
+	petUsecase := usecase.NewPetUsecase(mockRepo)
+	mockReferences(mockRepo)
+
+	pet := &entity.CreatePet{
+		Species:                  "cat",
+		PetSize:                  "medium",
+		Name:                     "maine_coon",
+		AverageMaleAdultWeight:   0,
+		AverageFemaleAdultWeight: 30000,
+	}
+
+	result, err := petUsecase.CreatePet(context.Background(), pet)
+
+	var validationErr *usecase.ValidationError
+	assert.ErrorAs(t, err, &validationErr)
+	assert.Nil(t, result)
+	assert.Equal(t, []usecase.FieldError{
+		{Field: "average_male_adult_weight", Message: "must be between 1000 and 15000 grams for a cat"},
+		{Field: "average_female_adult_weight", Message: "must be between 1000 and 15000 grams for a cat"},
+	}, validationErr.Errors)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestPatchPetUsecaseOnAPetStoredOutOfTheBounds(t *testing.T) {
+	ctx := context.Background()
+	petRepo := repository.NewMemoryPetRepository()
+	petUsecase := usecase.NewPetUsecase(petRepo)
+
+	// The weights of the seeded pets are 0 when unknown
+	id, err := petRepo.Create(ctx, &entity.CreatePet{Species: "cat", PetSize: "medium", Name: "chartreux"})
+	require.NoError(t, err)
+
+	size := "small"
+	patched, err := petUsecase.PatchPet(ctx, id, &entity.PatchPet{PetSize: &size}, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, "small", patched.PetSize)
+
+	weight := uint(20000)
+	_, err = petUsecase.PatchPet(ctx, id, &entity.PatchPet{AverageMaleAdultWeight: &weight}, 0)
+
+	var validationErr *usecase.ValidationError
+	require.ErrorAs(t, err, &validationErr)
+	assert.Equal(t, []usecase.FieldError{
+		{Field: "average_male_adult_weight", Message: "must be between 1000 and 15000 grams for a cat"},
+	}, validationErr.Errors)
+
+	// Both weights are checked against the bounds of a new species
+	species := "dog"
+	_, err = petUsecase.PatchPet(ctx, id, &entity.PatchPet{Species: &species}, 0)
+
+	require.ErrorAs(t, err, &validationErr)
+	assert.Equal(t, []string{"average_male_adult_weight", "average_female_adult_weight"}, []string{
+		validationErr.Errors[0].Field,
+		validationErr.Errors[1].Field,
+	})
+}
@@ -0,0 +1,66 @@
+package tests
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/japhy-tech/backend-test/internal/entity"
+	"github.com/japhy-tech/backend-test/internal/petfile"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReadBreedsFile(t *testing.T) {
+	file, err := os.Open("../database_actions/seeds/breeds.csv")
+	assert.NoError(t, err)
+	defer file.Close()
+
+	records, err := petfile.Read(file, petfile.FormatCSV)
+
+	assert.NoError(t, err)
+	assert.Len(t, records, 325)
+	assert.Equal(t, entity.PetRecord{
+		Line: 2,
+		ID:   1,
+		Pet: entity.CreatePet{
+			Species:                  "dog",
+			PetSize:                  "small",
+			Name:                     "affenpinscher",
+			AverageMaleAdultWeight:   6000,
+			AverageFemaleAdultWeight: 5000,
+		},
+	}, records[0])
+}
+
+func TestReadCSVMapsHeaderAndReportsLines(t *testing.T) {
+	file := "name,species,pet_size,average_female_adult_weight,average_male_adult_weight\n" +
+		"bolognese,dog,small,3000,4000\n" +
+		"akita,dog,tall,heavy,40000\n"
+
+	records, err := petfile.Read(strings.NewReader(file), petfile.FormatCSV)
+
+	var parseErrors *petfile.ParseErrors
+	assert.ErrorAs(t, err, &parseErrors)
+	assert.Equal(t, []*petfile.LineError{
+		{Line: 3, Field: "average_female_adult_weight", Message: "must be a positive integer"},
+	}, parseErrors.Errors)
+	assert.Len(t, records, 1)
+	assert.Equal(t, uint(4000), records[0].Pet.AverageMaleAdultWeight)
+}
+
+func TestWriteNDJSONRoundTrip(t *testing.T) {
+	var buffer bytes.Buffer
+
+	writer, err := petfile.NewWriter(&buffer, petfile.FormatNDJSON)
+	assert.NoError(t, err)
+	assert.NoError(t, writer.Write(&entity.Pet{ID: 3, Species: "dog", PetSize: "small", Name: "bolognese", AverageMaleAdultWeight: 4000, AverageFemaleAdultWeight: 3000}))
+	assert.NoError(t, writer.Flush())
+
+	records, err := petfile.Read(&buffer, petfile.FormatNDJSON)
+
+	assert.NoError(t, err)
+	assert.Len(t, records, 1)
+	assert.Equal(t, 3, records[0].ID)
+	assert.Equal(t, "bolognese", records[0].Pet.Name)
+}
@@ -0,0 +1,129 @@
+package tests
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	charmLog "github.com/charmbracelet/log"
+	"github.com/gorilla/mux"
+	"github.com/japhy-tech/backend-test/internal/config"
+	delivery "github.com/japhy-tech/backend-test/internal/delivery/http"
+	"github.com/japhy-tech/backend-test/internal/ratelimit"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMemoryStoreRefillsTokens(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	store := ratelimit.NewMemoryStoreWithClock(func() time.Time { return now })
+	budget := config.RateBudget{Requests: 2, Period: time.Minute}
+
+	for i := 0; i < 2; i++ {
+		result, err := store.Take(context.Background(), "client", budget)
+		assert.NoError(t, err)
+		assert.True(t, result.Allowed)
+	}
+
+	result, err := store.Take(context.Background(), "client", budget)
+	assert.NoError(t, err)
+	assert.False(t, result.Allowed)
+	assert.Equal(t, 0, result.Remaining)
+	assert.Equal(t, 30*time.Second, result.RetryAfter)
+	assert.Equal(t, time.Minute, result.Reset)
+
+	now = now.Add(30 * time.Second)
+
+	result, err = store.Take(context.Background(), "client", budget)
+	assert.NoError(t, err)
+	assert.True(t, result.Allowed)
+
+	result, err = store.Take(context.Background(), "other-client", budget)
+	assert.NoError(t, err)
+	assert.True(t, result.Allowed)
+	assert.Equal(t, 1, result.Remaining)
+}
+
+func TestRateLimitMiddleware(t *testing.T) {
+	limiter := ratelimit.NewLimiter(ratelimit.NewMemoryStore(), &config.RateLimitConfig{
+		Default: config.RateBudget{Requests: 10, Period: time.Minute},
+		Routes: []config.RouteBudget{
+			{Method: http.MethodPost, Route: "/pets/search", RateBudget: config.RateBudget{Requests: 1, Period: time.Minute}},
+		},
+	})
+
+	router := mux.NewRouter()
+	router.Use(delivery.RateLimit(limiter, charmLog.New(nil)))
+	router.HandleFunc("/pets/search", func(w http.ResponseWriter, r *http.Request) {}).Methods(http.MethodPost)
+	router.HandleFunc("/pets", func(w http.ResponseWriter, r *http.Request) {}).Methods(http.MethodGet)
+
+	request := func(method string, path string, remoteAddr string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, nil)
+		req.RemoteAddr = remoteAddr
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+
+		return rec
+	}
+
+	rec := request(http.MethodPost, "/pets/search", "10.0.0.1:1234")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "1", rec.Header().Get("RateLimit-Limit"))
+	assert.Equal(t, "0", rec.Header().Get("RateLimit-Remaining"))
+	assert.Equal(t, "60", rec.Header().Get("RateLimit-Reset"))
+
+	rec = request(http.MethodPost, "/pets/search", "10.0.0.1:5678")
+	assert.Equal(t, http.StatusTooManyRequests, rec.Code)
+	assert.Equal(t, "60", rec.Header().Get("Retry-After"))
+	assert.JSONEq(t, `{"status":"error","message":"Too many requests, retry in 60 seconds"}`, rec.Body.String())
+
+	// The other routes and the other clients have budgets of their own
+	rec = request(http.MethodGet, "/pets", "10.0.0.1:1234")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "10", rec.Header().Get("RateLimit-Limit"))
+
+	rec = request(http.MethodPost, "/pets/search", "10.0.0.2:1234")
+	assert.Equal(t, http.StatusOK, rec.Code)
+}
+
+func TestRateLimitAuthenticationMiddleware(t *testing.T) {
+	limiter := ratelimit.NewLimiter(ratelimit.NewMemoryStore(), &config.RateLimitConfig{
+		Default:         config.RateBudget{Requests: 10, Period: time.Minute},
+		Unauthenticated: config.RateBudget{Requests: 2, Period: time.Minute},
+	})
+
+	router := mux.NewRouter()
+	router.Use(delivery.RateLimitAuthentication(limiter, charmLog.New(nil)))
+	router.HandleFunc("/pets", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-API-Key") != "valid" {
+			w.WriteHeader(http.StatusUnauthorized)
+		}
+	}).Methods(http.MethodGet)
+
+	request := func(apiKey string, remoteAddr string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/pets", nil)
+		req.Header.Set("X-API-Key", apiKey)
+		req.RemoteAddr = remoteAddr
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+
+		return rec
+	}
+
+	// The authenticated requests don't take from the budget
+	for i := 0; i < 3; i++ {
+		assert.Equal(t, http.StatusOK, request("valid", "10.0.0.1:1234").Code)
+	}
+
+	for i := 0; i < 2; i++ {
+		assert.Equal(t, http.StatusUnauthorized, request("guess", "10.0.0.1:1234").Code)
+	}
+
+	// Once the budget is exhausted, the credentials are no longer checked
+	rec := request("valid", "10.0.0.1:1234")
+	assert.Equal(t, http.StatusTooManyRequests, rec.Code)
+	assert.Equal(t, "30", rec.Header().Get("Retry-After"))
+
+	assert.Equal(t, http.StatusUnauthorized, request("guess", "10.0.0.2:1234").Code)
+}
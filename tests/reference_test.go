@@ -0,0 +1,210 @@
+package tests
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	charmLog "github.com/charmbracelet/log"
+	"github.com/gorilla/mux"
+	delivery "github.com/japhy-tech/backend-test/internal/delivery/http"
+	"github.com/japhy-tech/backend-test/internal/entity"
+	"github.com/japhy-tech/backend-test/internal/repository"
+	"github.com/japhy-tech/backend-test/internal/usecase"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func newReferenceRouter(mockRepo *repository.MockPetRepository) *mux.Router {
+	router := mux.NewRouter()
+	withoutAuth(router)
+	delivery.NewReferenceHandler(router, usecase.NewReferenceUsecase(mockRepo), charmLog.New(io.Discard))
+
+	return router
+}
+
+func TestCreateSpeciesHandler(t *testing.T) {
+	mockRepo := new(repository.MockPetRepository)
+	router := newReferenceRouter(mockRepo)
+
+	rabbit := &entity.Species{Name: "rabbit", MinWeight: 500, MaxWeight: 8000}
+	mockRepo.On("CreateSpecies", rabbit).Return(nil)
+
+	req := httptest.NewRequest(http.MethodPost, "/species", strings.NewReader(`{"name": "rabbit", "min_weight": 500, "max_weight": 8000}`))
+	rec := httptest.NewRecorder()
+
+	router.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), `"name":"rabbit"`)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestCreateSpeciesHandlerValidationErrors(t *testing.T) {
+	mockRepo := new(repository.MockPetRepository)
+	router := newReferenceRouter(mockRepo)
+
+	req := httptest.NewRequest(http.MethodPost, "/species", strings.NewReader(`{"name": "Rabbit", "min_weight": 9000, "max_weight": 8000}`))
+	rec := httptest.NewRecorder()
+
+	router.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+	assert.JSONEq(t, `{
+		"status": "error",
+		"message": "Validation failed",
+		"errors": [
+			{"field": "name", "message": "must only contain lowercase letters and digits separated by underscores"},
+			{"field": "min_weight", "message": "must not exceed the max weight"}
+		]
+	}`, rec.Body.String())
+	mockRepo.AssertNotCalled(t, "CreateSpecies", mock.Anything)
+}
+
+func TestReferenceHandlerErrors(t *testing.T) {
+	tests := []struct {
+		name       string
+		method     string
+		target     string
+		body       string
+		setup      func(mockRepo *repository.MockPetRepository)
+		statusCode int
+		message    string
+	}{
+		{
+			name:   "species in use",
+			method: http.MethodDelete,
+			target: "/species/dog",
+			setup: func(mockRepo *repository.MockPetRepository) {
+				mockRepo.On("DeleteSpecies", "dog").Return(0, repository.ErrInUse)
+			},
+			statusCode: http.StatusConflict,
+			message:    "species dog is used by pets",
+		},
+		{
+			name:   "unknown species",
+			method: http.MethodDelete,
+			target: "/species/hamster",
+			setup: func(mockRepo *repository.MockPetRepository) {
+				mockRepo.On("DeleteSpecies", "hamster").Return(0, nil)
+			},
+			statusCode: http.StatusNotFound,
+			message:    "species hamster not found",
+		},
+		{
+			name:   "stored size",
+			method: http.MethodPost,
+			target: "/sizes",
+			body:   `{"name": "medium", "position": 3}`,
+			setup: func(mockRepo *repository.MockPetRepository) {
+				mockRepo.On("CreatePetSize", mock.Anything).Return(repository.ErrDuplicate)
+			},
+			statusCode: http.StatusConflict,
+			message:    "pet size medium already exists",
+		},
+		{
+			name:   "unknown size",
+			method: http.MethodPut,
+			target: "/sizes/huge",
+			body:   `{"name": "huge", "position": 4}`,
+			setup: func(mockRepo *repository.MockPetRepository) {
+				mockRepo.On("UpdatePetSize", "huge", mock.Anything).Return(0, nil)
+				mockRepo.On("GetAllPetSizes").Return(entity.DefaultPetSizes, nil)
+			},
+			statusCode: http.StatusNotFound,
+			message:    "pet size huge not found",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := new(repository.MockPetRepository)
+			router := newReferenceRouter(mockRepo)
+			tt.setup(mockRepo)
+
+			req := httptest.NewRequest(tt.method, tt.target, strings.NewReader(tt.body))
+			rec := httptest.NewRecorder()
+
+			router.ServeHTTP(rec, req)
+
+			assert.Equal(t, tt.statusCode, rec.Code)
+			assert.Contains(t, rec.Body.String(), tt.message)
+			mockRepo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestUpdateSpeciesUsecaseUnchanged(t *testing.T) {
+	mockRepo := new(repository.MockPetRepository)
+	referenceUsecase := usecase.NewReferenceUsecase(mockRepo)
+
+	dog := entity.DefaultSpecies[1]
+
+	// MySQL affects no row when the species is left as is
+	mockRepo.On("UpdateSpecies", "dog", &dog).Return(0, nil)
+	mockRepo.On("GetAllSpecies").Return(entity.DefaultSpecies, nil)
+
+	species, err := referenceUsecase.UpdateSpecies(context.Background(), "dog", &dog)
+
+	assert.NoError(t, err)
+	assert.Equal(t, &dog, species)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestCreatePetUsecaseValidatesTheStoredSpecies(t *testing.T) {
+	mockRepo := new(repository.MockPetRepository)
+	petUsecase := usecase.NewPetUsecase(mockRepo)
+
+	mockRepo.On("GetAllSpecies").Return([]entity.Species{{Name: "rabbit", MinWeight: 500, MaxWeight: 8000}}, nil)
+	mockRepo.On("GetAllPetSizes").Return([]entity.PetSize{{Name: "tiny", Position: 1}}, nil)
+
+	_, err := petUsecase.CreatePet(context.Background(), &entity.CreatePet{
+		Species:                  "dog",
+		PetSize:                  "tiny",
+		Name:                     "bolognese",
+		AverageMaleAdultWeight:   4000,
+		AverageFemaleAdultWeight: 3000,
+	})
+
+	var validationErr *usecase.ValidationError
+	assert.ErrorAs(t, err, &validationErr)
+	assert.Equal(t, []usecase.FieldError{{Field: "species", Message: "must be one of rabbit"}}, validationErr.Errors)
+	mockRepo.AssertNotCalled(t, "Create", mock.Anything)
+}
+
+func TestUpdatePetSizeUsecaseRecordsTheRenamedPets(t *testing.T) {
+	ctx := usecase.WithActor(context.Background(), "alice")
+	petRepo := repository.NewMemoryPetRepository()
+	petUsecase := usecase.NewPetUsecase(petRepo)
+	referenceUsecase := usecase.NewReferenceUsecase(petRepo)
+
+	id, err := petRepo.Create(ctx, &entity.CreatePet{Species: "dog", PetSize: "tall", Name: "great_dane", AverageMaleAdultWeight: 70000, AverageFemaleAdultWeight: 55000})
+	require.NoError(t, err)
+	_, err = petRepo.Create(ctx, &entity.CreatePet{Species: "cat", PetSize: "small", Name: "siamese", AverageMaleAdultWeight: 4000, AverageFemaleAdultWeight: 3000})
+	require.NoError(t, err)
+
+	_, err = referenceUsecase.UpdatePetSize(ctx, "tall", &entity.PetSize{Name: "large", Position: 3})
+	require.NoError(t, err)
+
+	pet, err := petUsecase.GetPetByID(ctx, id)
+	require.NoError(t, err)
+	assert.Equal(t, "large", pet.PetSize)
+	assert.Equal(t, entity.InitialVersion+1, pet.Version)
+
+	history, err := petUsecase.GetPetHistory(ctx, id, entity.NewPageRequest())
+	require.NoError(t, err)
+	require.Len(t, history.Entries, 1)
+	assert.Equal(t, entity.AuditActionUpdate, history.Entries[0].Action)
+	assert.Equal(t, "alice", history.Entries[0].Actor)
+	assert.Contains(t, string(history.Entries[0].Before), `"pet_size":"tall"`)
+	assert.Contains(t, string(history.Entries[0].After), `"pet_size":"large"`)
+
+	// Only the renamed pets are recorded
+	entries, err := petRepo.GetAuditEntries(ctx, &entity.AuditFilter{}, entity.NewPageRequest())
+	require.NoError(t, err)
+	assert.Len(t, entries, 1)
+}
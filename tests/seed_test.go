@@ -0,0 +1,278 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/japhy-tech/backend-test/database_actions"
+	"github.com/japhy-tech/backend-test/internal/entity"
+	"github.com/japhy-tech/backend-test/internal/petfile"
+	"github.com/japhy-tech/backend-test/internal/repository"
+	"github.com/japhy-tech/backend-test/internal/usecase"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeSeed(t *testing.T, content string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "seed.csv")
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	return path
+}
+
+func seedLines(lines []usecase.SeedLine) []int {
+	numbers := make([]int, len(lines))
+	for i, line := range lines {
+		numbers[i] = line.Line
+	}
+	return numbers
+}
+
+func TestLoadBreedsFile(t *testing.T) {
+	petRepo := repository.NewMemoryPetRepository()
+
+	report, err := database_actions.LoadPets(context.Background(), petRepo, "../database_actions/seeds/breeds.csv")
+
+	require.NoError(t, err)
+	assert.Len(t, report.Inserted, 325)
+	assert.Empty(t, report.Updated)
+	assert.Empty(t, report.Skipped)
+	assert.Empty(t, report.Invalid)
+
+	count, err := petRepo.Count(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 325, count)
+
+	// Seeding again changes nothing
+	report, err = database_actions.LoadPets(context.Background(), petRepo, "../database_actions/seeds/breeds.csv")
+
+	require.NoError(t, err)
+	assert.Empty(t, report.Inserted)
+	assert.Empty(t, report.Updated)
+	assert.Len(t, report.Skipped, 325)
+	assert.Equal(t, "unchanged", report.Skipped[0].Reason)
+}
+
+func TestLoadPetsUpsertsOnSpeciesAndName(t *testing.T) {
+	ctx := context.Background()
+	petRepo := repository.NewMemoryPetRepository()
+
+	_, err := database_actions.LoadPets(ctx, petRepo, writeSeed(t,
+		"species,pet_size,name,average_male_adult_weight,average_female_adult_weight\n"+
+			"dog,small,bolognese,4000,3000\n"+
+			"dog,tall,akita,40000,35000\n"+
+			"cat,medium,siamese,0,0\n"))
+	require.NoError(t, err)
+
+	_, err = petRepo.Delete(ctx, 2, entity.InitialVersion)
+	require.NoError(t, err)
+
+	// The columns are mapped through the header, the IDs of the file are ignored
+	report, err := database_actions.LoadPets(ctx, petRepo, writeSeed(t,
+		"name,species,id,pet_size,average_female_adult_weight,average_male_adult_weight\n"+
+			"bolognese,dog,9,small,3500,4500\n"+
+			"akita,dog,8,tall,35000,40000\n"+
+			"siamese,cat,7,medium,0,0\n"+
+			"persian,cat,6,medium,4000,5000\n"))
+	require.NoError(t, err)
+
+	assert.Equal(t, []usecase.SeedLine{{Line: 5, ID: 4, Species: "cat", Name: "persian"}}, report.Inserted)
+	assert.Equal(t, []usecase.SeedLine{{Line: 2, ID: 1, Species: "dog", Name: "bolognese"}}, report.Updated)
+	assert.Equal(t, []usecase.SeedLine{
+		{Line: 3, ID: 2, Species: "dog", Name: "akita", Reason: "in the trash"},
+		{Line: 4, ID: 3, Species: "cat", Name: "siamese", Reason: "unchanged"},
+	}, report.Skipped)
+	assert.Empty(t, report.Invalid)
+
+	bolognese, err := petRepo.GetByID(ctx, 1)
+	require.NoError(t, err)
+	assert.Equal(t, uint(4500), bolognese.AverageMaleAdultWeight)
+	assert.Equal(t, uint(3500), bolognese.AverageFemaleAdultWeight)
+	assert.Equal(t, entity.InitialVersion+1, bolognese.Version)
+
+	entries, err := petRepo.GetAuditEntries(ctx, &entity.AuditFilter{Actor: database_actions.SeedActor, Action: entity.AuditActionUpdate}, entity.NewPageRequest())
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.Equal(t, 1, entries[0].EntityID)
+}
+
+func TestLoadPetsReportsInvalidLines(t *testing.T) {
+	ctx := context.Background()
+	petRepo := repository.NewMemoryPetRepository()
+
+	report, err := database_actions.LoadPets(ctx, petRepo, writeSeed(t,
+		"species,pet_size,name,average_male_adult_weight,average_female_adult_weight\n"+
+			"dog,small,bolognese,4000,3000\n"+
+			"dog,huge,akita,40000,35000\n"+
+			"dog,small,bolognese,5000,4000\n"+
+			"dog,tall,beagle,heavy,10000\n"+
+			"cat,medium,siamese,0,0\n"))
+	require.NoError(t, err)
+
+	assert.Equal(t, []int{2, 6}, seedLines(report.Inserted))
+	assert.Equal(t, []int{3, 4, 5}, seedLines(report.Invalid))
+
+	assert.Equal(t, "invalid fields", report.Invalid[0].Reason)
+	assert.Equal(t, "pet_size", report.Invalid[0].Errors[0].Field)
+	assert.Equal(t, "bolognese is already on line 2", report.Invalid[1].Reason)
+	assert.Equal(t, "unreadable line", report.Invalid[2].Reason)
+	assert.Equal(t, "average_male_adult_weight", report.Invalid[2].Errors[0].Field)
+
+	count, err := petRepo.Count(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 2, count)
+}
+
+func TestSeedMoreLinesThanTheBatchSize(t *testing.T) {
+	ctx := context.Background()
+	petRepo := repository.NewMemoryPetRepository()
+
+	records := make([]entity.PetRecord, 250)
+	for i := range records {
+		records[i] = entity.PetRecord{
+			Line: i + 2,
+			Pet: entity.CreatePet{
+				Species:                  "dog",
+				PetSize:                  "small",
+				Name:                     "dog_" + string(rune('a'+i/26)) + string(rune('a'+i%26)),
+				AverageMaleAdultWeight:   5000,
+				AverageFemaleAdultWeight: 4000,
+			},
+		}
+	}
+
+	report, err := usecase.NewPetSeeder(petRepo, 100).Seed(ctx, records)
+
+	require.NoError(t, err)
+	assert.Len(t, report.Inserted, 250)
+
+	count, err := petRepo.Count(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 250, count)
+}
+
+func TestLoadPetsMissingFile(t *testing.T) {
+	_, err := database_actions.LoadPets(context.Background(), repository.NewMemoryPetRepository(), "missing.csv")
+
+	assert.Error(t, err)
+}
+
+func TestImportAnExportOfTheSeededPets(t *testing.T) {
+	ctx := context.Background()
+	petRepo := repository.NewMemoryPetRepository()
+
+	_, err := database_actions.LoadPets(ctx, petRepo, "../database_actions/seeds/breeds.csv")
+	require.NoError(t, err)
+
+	petUsecase := usecase.NewPetUsecase(petRepo)
+
+	var buffer bytes.Buffer
+	writer, err := petfile.NewWriter(&buffer, petfile.FormatCSV)
+	require.NoError(t, err)
+	require.NoError(t, petUsecase.ExportPets(ctx, func(pets []entity.Pet) error {
+		for i := range pets {
+			if err := writer.Write(&pets[i]); err != nil {
+				return err
+			}
+		}
+		return nil
+	}))
+	require.NoError(t, writer.Flush())
+
+	records, err := petfile.Read(&buffer, petfile.FormatCSV)
+	require.NoError(t, err)
+
+	// The cats of unknown weights are imported back as they are
+	report, err := petUsecase.ImportPets(ctx, records, false)
+
+	require.NoError(t, err)
+	assert.True(t, report.Applied)
+	assert.Empty(t, report.New)
+	assert.Empty(t, report.Changed)
+	assert.Empty(t, report.Removed)
+	assert.Equal(t, 325, report.Unchanged)
+
+	// An unknown weight can be given to a new pet too
+	records = append(records, entity.PetRecord{Line: 327, Pet: entity.CreatePet{Species: "cat", PetSize: "small", Name: "minuet"}})
+	report, err = petUsecase.ImportPets(ctx, records, false)
+
+	require.NoError(t, err)
+	require.Len(t, report.New, 1)
+	assert.Equal(t, "minuet", report.New[0].Pet.Name)
+}
+
+func TestImportPetsUsecaseAppliesTheDifference(t *testing.T) {
+	ctx := context.Background()
+	petRepo := repository.NewMemoryPetRepository()
+	petUsecase := usecase.NewPetUsecase(petRepo)
+
+	for _, pet := range []entity.CreatePet{
+		{Species: "dog", PetSize: "small", Name: "bolognese", AverageMaleAdultWeight: 4000, AverageFemaleAdultWeight: 3000},
+		{Species: "dog", PetSize: "tall", Name: "akita", AverageMaleAdultWeight: 40000, AverageFemaleAdultWeight: 35000},
+		{Species: "cat", PetSize: "small", Name: "siamese", AverageMaleAdultWeight: 4000, AverageFemaleAdultWeight: 3000},
+	} {
+		_, err := petRepo.Create(ctx, &pet)
+		require.NoError(t, err)
+	}
+
+	records := []entity.PetRecord{
+		{Line: 2, Pet: entity.CreatePet{Species: "dog", PetSize: "small", Name: "bolognese", AverageMaleAdultWeight: 4500, AverageFemaleAdultWeight: 3000}},
+		{Line: 3, Pet: entity.CreatePet{Species: "cat", PetSize: "small", Name: "siamese", AverageMaleAdultWeight: 4000, AverageFemaleAdultWeight: 3000}},
+		{Line: 4, Pet: entity.CreatePet{Species: "dog", PetSize: "small", Name: "bichon_frize", AverageMaleAdultWeight: 8000, AverageFemaleAdultWeight: 7000}},
+	}
+
+	report, err := petUsecase.ImportPets(ctx, records, false)
+
+	require.NoError(t, err)
+	assert.True(t, report.Applied)
+	assert.Len(t, report.New, 1)
+	assert.Len(t, report.Changed, 1)
+	require.Len(t, report.Removed, 1)
+	assert.Equal(t, "akita", report.Removed[0].Name)
+	assert.Equal(t, 1, report.Unchanged)
+
+	pets, err := petUsecase.GetPets(ctx, entity.NewPageRequest())
+	require.NoError(t, err)
+	weights := make(map[string]uint, len(pets.Pets))
+	for _, pet := range pets.Pets {
+		weights[pet.Name] = pet.AverageMaleAdultWeight
+	}
+	assert.Equal(t, map[string]uint{"bolognese": 4500, "siamese": 4000, "bichon_frize": 8000}, weights)
+
+	// The removed pet is in the trash, along with the history of the import
+	deleted, err := petUsecase.GetDeletedPets(ctx, entity.NewPageRequest())
+	require.NoError(t, err)
+	require.Len(t, deleted.Pets, 1)
+	assert.Equal(t, "akita", deleted.Pets[0].Name)
+
+	history, err := petUsecase.GetPetHistory(ctx, report.Changed[0].Before.ID, entity.NewPageRequest())
+	require.NoError(t, err)
+	assert.Len(t, history.Entries, 1)
+}
+
+func TestImportPetsUsecaseRejectsAFileWithoutPets(t *testing.T) {
+	ctx := context.Background()
+	petRepo := repository.NewMemoryPetRepository()
+	petUsecase := usecase.NewPetUsecase(petRepo)
+
+	_, err := petRepo.Create(ctx, &entity.CreatePet{Species: "dog", PetSize: "tall", Name: "akita", AverageMaleAdultWeight: 40000, AverageFemaleAdultWeight: 35000})
+	require.NoError(t, err)
+
+	records, err := petfile.Read(strings.NewReader(`"id","species","pet_size","name","average_male_adult_weight","average_female_adult_weight"`+"\n"), petfile.FormatCSV)
+	require.NoError(t, err)
+
+	_, err = petUsecase.ImportPets(ctx, records, false)
+
+	var validationErr *usecase.ValidationError
+	require.ErrorAs(t, err, &validationErr)
+	assert.Equal(t, "file", validationErr.Errors[0].Field)
+
+	count, err := petRepo.Count(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, count)
+}
@@ -0,0 +1,175 @@
+package tests
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	charmLog "github.com/charmbracelet/log"
+	"github.com/gorilla/mux"
+	"github.com/japhy-tech/backend-test/internal/config"
+	delivery "github.com/japhy-tech/backend-test/internal/delivery/http"
+	"github.com/japhy-tech/backend-test/internal/repository"
+	"github.com/japhy-tech/backend-test/internal/tracing"
+	"github.com/japhy-tech/backend-test/internal/usecase"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+// recordSpans records the spans in memory until the end of the test.
+func recordSpans(t *testing.T) *tracetest.InMemoryExporter {
+	exporter := tracetest.NewInMemoryExporter()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter)))
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+
+	t.Cleanup(func() {
+		otel.SetTracerProvider(noop.NewTracerProvider())
+	})
+
+	return exporter
+}
+
+func findSpan(spans tracetest.SpanStubs, name string) *tracetest.SpanStub {
+	for i := range spans {
+		if spans[i].Name == name {
+			return &spans[i]
+		}
+	}
+
+	return nil
+}
+
+func spanAttribute(span *tracetest.SpanStub, key string) attribute.Value {
+	for _, kv := range span.Attributes {
+		if string(kv.Key) == key {
+			return kv.Value
+		}
+	}
+
+	return attribute.Value{}
+}
+
+func TestTracingFromHandlerToSQL(t *testing.T) {
+	exporter := recordSpans(t)
+
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	rows := sqlmock.NewRows([]string{"id", "species", "pet_size", "name", "average_male_adult_weight", "average_female_adult_weight", "version", "created_at", "updated_at", "deleted_at"}).
+		AddRow(3, "dog", "small", "bolognese", 4000, 3000, 1, petTimestamp, petTimestamp, nil)
+	mock.ExpectQuery(`SELECT .* FROM pets WHERE deleted_at IS NULL`).WillReturnRows(rows)
+	mock.ExpectQuery(`SELECT COUNT\(\*\) FROM pets`).WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+
+	petRepo := repository.NewTracingPetRepository(repository.NewPetRepository(db, 0))
+	router := mux.NewRouter()
+	router.Use(delivery.Tracing)
+	withoutAuth(router)
+	delivery.NewPetHandler(router, usecase.NewTracingPetUsecase(usecase.NewPetUsecase(petRepo)), charmLog.New(io.Discard))
+
+	req := httptest.NewRequest(http.MethodGet, "/pets", nil)
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	rec := httptest.NewRecorder()
+
+	router.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Header().Get("traceparent"), "00-4bf92f3577b34da6a3ce929d0e0e4736-")
+
+	spans := exporter.GetSpans()
+	server := findSpan(spans, "GET /pets")
+	usecaseSpan := findSpan(spans, "PetUsecase.GetPets")
+	repositorySpan := findSpan(spans, "PetRepository.GetAll")
+	sqlSpan := findSpan(spans, "sql")
+
+	if !assert.NotNil(t, server) || !assert.NotNil(t, usecaseSpan) || !assert.NotNil(t, repositorySpan) || !assert.NotNil(t, sqlSpan) {
+		return
+	}
+
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", server.SpanContext.TraceID().String())
+	assert.Equal(t, "00f067aa0ba902b7", server.Parent.SpanID().String())
+	assert.Equal(t, server.SpanContext.SpanID(), usecaseSpan.Parent.SpanID())
+	assert.Equal(t, usecaseSpan.SpanContext.SpanID(), repositorySpan.Parent.SpanID())
+	assert.Equal(t, repositorySpan.SpanContext.SpanID(), sqlSpan.Parent.SpanID())
+	assert.Equal(t, int64(200), spanAttribute(server, "http.response.status_code").AsInt64())
+	assert.Equal(t, int64(1), spanAttribute(repositorySpan, "db.rows_returned").AsInt64())
+	assert.Contains(t, spanAttribute(sqlSpan, "db.statement").AsString(), "FROM pets")
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestTracingExecRowsAffected(t *testing.T) {
+	exporter := recordSpans(t)
+
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	mock.ExpectExec(`UPDATE pets SET deleted_at = \?`).WillReturnResult(sqlmock.NewResult(0, 1))
+
+	repo := repository.NewPetRepository(db, 0)
+	_, err = repo.Delete(context.Background(), 3, 1)
+	assert.NoError(t, err)
+
+	sqlSpan := findSpan(exporter.GetSpans(), "sql")
+	if assert.NotNil(t, sqlSpan) {
+		assert.Equal(t, int64(1), spanAttribute(sqlSpan, "db.rows_affected").AsInt64())
+		assert.Equal(t, "mysql", spanAttribute(sqlSpan, "db.system").AsString())
+	}
+}
+
+func TestTracingStdoutExporterWritesFile(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "spans.json")
+	t.Cleanup(func() {
+		otel.SetTracerProvider(noop.NewTracerProvider())
+	})
+
+	shutdown, err := tracing.Setup(&config.TracingConfig{Exporter: config.TracingExporterStdout, File: file})
+	assert.NoError(t, err)
+
+	_, span := tracing.Start(context.Background(), "PetUsecase.SearchPets")
+	span.End()
+	assert.NoError(t, shutdown(context.Background()))
+
+	content, err := os.ReadFile(file)
+	assert.NoError(t, err)
+	assert.Contains(t, string(content), `"Name":"PetUsecase.SearchPets"`)
+}
+
+func TestTracingOTLPExporterSendsToCollector(t *testing.T) {
+	received := make(chan *http.Request, 10)
+	collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.Copy(io.Discard, r.Body)
+		received <- r
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer collector.Close()
+	t.Cleanup(func() {
+		otel.SetTracerProvider(noop.NewTracerProvider())
+	})
+
+	shutdown, err := tracing.Setup(&config.TracingConfig{Exporter: config.TracingExporterOTLP, Endpoint: collector.URL})
+	assert.NoError(t, err)
+
+	_, span := tracing.Start(context.Background(), "PetRepository.SearchPets")
+	span.End()
+	assert.NoError(t, shutdown(context.Background()))
+
+	select {
+	case r := <-received:
+		assert.Equal(t, http.MethodPost, r.Method)
+		assert.Equal(t, "/v1/traces", r.URL.Path)
+		assert.Equal(t, "application/x-protobuf", r.Header.Get("Content-Type"))
+	default:
+		t.Fatal("the collector received no spans")
+	}
+}